@@ -0,0 +1,276 @@
+// Command mesh_info prints statistics about a mesh and
+// checks it for common problems, optionally repairing it.
+//
+// Supported formats are STL, PLY, OBJ, and OFF.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+type MeshInfo struct {
+	Triangles int        `json:"triangles"`
+	Vertices  int        `json:"vertices"`
+	Min       [3]float64 `json:"min"`
+	Max       [3]float64 `json:"max"`
+	Area      float64    `json:"area"`
+	Volume    float64    `json:"volume"`
+
+	// Components is nil if the mesh is not manifold.
+	Components *int `json:"components"`
+
+	NeedsRepair       bool `json:"needs_repair"`
+	SingularVertices  int  `json:"singular_vertices"`
+	SelfIntersections int  `json:"self_intersections"`
+	InconsistentEdges int  `json:"inconsistent_edges"`
+	Orientable        bool `json:"orientable"`
+}
+
+type FixInfo struct {
+	FlippedNormals int       `json:"flipped_normals"`
+	OutputPath     string    `json:"output_path"`
+	Result         *MeshInfo `json:"result"`
+}
+
+type Output struct {
+	Path string    `json:"path"`
+	Info *MeshInfo `json:"info"`
+	Fix  *FixInfo  `json:"fix,omitempty"`
+}
+
+func main() {
+	var fix bool
+	var jsonOutput bool
+	var repairEpsilon float64
+	var coplanarEpsilon float64
+	var verbose bool
+	flag.BoolVar(&fix, "fix", false, "repair the mesh and write it to the output path")
+	flag.BoolVar(&jsonOutput, "json", false, "print results as JSON")
+	flag.Float64Var(&repairEpsilon, "repair-epsilon", 1e-5,
+		"distance for merging vertices and for checking normals during repair")
+	flag.Float64Var(&coplanarEpsilon, "coplanar-epsilon", 1e-8,
+		"normal tolerance for eliminating coplanar triangles during repair")
+	flag.BoolVar(&verbose, "verbose", false, "log progress to standard error")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: "+os.Args[0]+" [flags] <input> [output]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "The output path is required with -fix.")
+		fmt.Fprintln(os.Stderr, "Supported formats: .stl, .ply, .obj, .off")
+		fmt.Fprintln(os.Stderr, "Vertex colors are kept for .ply and .obj output.")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if (fix && len(args) != 2) || (!fix && len(args) != 1) {
+		flag.Usage()
+		os.Exit(1)
+	}
+	logf := func(format string, args ...any) {
+		if verbose {
+			log.Printf(format, args...)
+		}
+	}
+
+	inputPath := args[0]
+	logf("Loading mesh from %s ...", inputPath)
+	mesh, colors, err := ReadMesh(inputPath)
+	essentials.Must(err)
+
+	logf("Computing mesh info ...")
+	output := &Output{Path: inputPath, Info: ComputeInfo(mesh)}
+
+	if fix {
+		logf("Repairing mesh ...")
+		mesh = mesh.Repair(repairEpsilon)
+		var numFlipped int
+		mesh, numFlipped = mesh.RepairNormals(repairEpsilon)
+		mesh = mesh.EliminateCoplanar(coplanarEpsilon)
+
+		outputPath := args[1]
+		logf("Saving mesh to %s ...", outputPath)
+		essentials.Must(WriteMesh(outputPath, mesh, colors))
+
+		logf("Computing repaired mesh info ...")
+		output.Fix = &FixInfo{
+			FlippedNormals: numFlipped,
+			OutputPath:     outputPath,
+			Result:         ComputeInfo(mesh),
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(output, "", "  ")
+		essentials.Must(err)
+		fmt.Println(string(data))
+	} else {
+		fmt.Println("Mesh:", inputPath)
+		output.Info.Print()
+		if output.Fix != nil {
+			fmt.Println()
+			fmt.Println("Repaired mesh:", output.Fix.OutputPath)
+			fmt.Println("  Flipped normals:", output.Fix.FlippedNormals)
+			output.Fix.Result.Print()
+		}
+	}
+}
+
+// ComputeInfo computes statistics and validity checks for
+// a mesh.
+func ComputeInfo(mesh *model3d.Mesh) *MeshInfo {
+	info := &MeshInfo{
+		Triangles:         len(mesh.TriangleSlice()),
+		Vertices:          len(mesh.VertexSlice()),
+		Area:              mesh.Area(),
+		Volume:            mesh.Volume(),
+		NeedsRepair:       mesh.NeedsRepair(),
+		SingularVertices:  len(mesh.SingularVertices()),
+		SelfIntersections: mesh.SelfIntersections(),
+		InconsistentEdges: len(mesh.InconsistentEdges()),
+		Orientable:        mesh.Orientable(),
+	}
+	if info.Triangles > 0 {
+		info.Min = mesh.Min().Array()
+		info.Max = mesh.Max().Array()
+	}
+	if !info.NeedsRepair && info.SelfIntersections == 0 {
+		// MeshToHierarchy requires a manifold mesh without
+		// self-intersections.
+		var count int
+		var countHierarchy func(h []*model3d.MeshHierarchy)
+		countHierarchy = func(h []*model3d.MeshHierarchy) {
+			for _, x := range h {
+				count++
+				countHierarchy(x.Children)
+			}
+		}
+		countHierarchy(model3d.MeshToHierarchy(mesh))
+		info.Components = &count
+	}
+	return info
+}
+
+// Print writes a human-readable description of the info
+// to standard output.
+func (m *MeshInfo) Print() {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	fmt.Printf("  Triangles:          %d\n", m.Triangles)
+	fmt.Printf("  Vertices:           %d\n", m.Vertices)
+	fmt.Printf("  Min:                %g %g %g\n", m.Min[0], m.Min[1], m.Min[2])
+	fmt.Printf("  Max:                %g %g %g\n", m.Max[0], m.Max[1], m.Max[2])
+	fmt.Printf("  Size:               %g %g %g\n", m.Max[0]-m.Min[0], m.Max[1]-m.Min[1],
+		m.Max[2]-m.Min[2])
+	fmt.Printf("  Area:               %g\n", m.Area)
+	fmt.Printf("  Volume:             %g\n", m.Volume)
+	if m.Components != nil {
+		fmt.Printf("  Components:         %d\n", *m.Components)
+	} else {
+		fmt.Printf("  Components:         unknown (mesh is not manifold)\n")
+	}
+	fmt.Printf("  Needs repair:       %s\n", yesNo(m.NeedsRepair))
+	fmt.Printf("  Singular vertices:  %d\n", m.SingularVertices)
+	fmt.Printf("  Self-intersections: %d\n", m.SelfIntersections)
+	fmt.Printf("  Inconsistent edges: %d\n", m.InconsistentEdges)
+	fmt.Printf("  Orientable:         %s\n", yesNo(m.Orientable))
+}
+
+// ReadMesh loads a mesh from a file, using the extension
+// to determine the format.
+//
+// For PLY files, vertex colors are also returned.
+func ReadMesh(path string) (*model3d.Mesh, *model3d.CoordMap[[3]uint8], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	var tris []*model3d.Triangle
+	var colors *model3d.CoordMap[[3]uint8]
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".stl":
+		tris, err = model3d.ReadSTL(r)
+	case ".ply":
+		tris, colors, err = model3d.ReadColorPLY(r)
+	case ".obj":
+		tris, err = model3d.ReadOBJ(r)
+	case ".off":
+		tris, err = model3d.ReadOFF(r)
+	default:
+		err = fmt.Errorf("unknown extension: %s", ext)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "read mesh")
+	}
+	return model3d.NewMeshTriangles(tris), colors, nil
+}
+
+// WriteMesh saves a mesh to a file, using the extension
+// to determine the format.
+//
+// If colors is non-nil, it is used to color vertices for
+// formats that support it.
+func WriteMesh(path string, mesh *model3d.Mesh, colors *model3d.CoordMap[[3]uint8]) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".stl" {
+		return mesh.SaveGroupedSTL(path)
+	}
+
+	colorFunc := func(c model3d.Coord3D) [3]uint8 {
+		if colors != nil {
+			if color, ok := colors.Load(c); ok {
+				return color
+			}
+		}
+		return [3]uint8{255, 255, 255}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "write mesh")
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	switch ext {
+	case ".ply":
+		err = model3d.WritePLY(w, mesh.TriangleSlice(), colorFunc)
+	case ".off":
+		err = model3d.WriteOFF(w, mesh.TriangleSlice())
+	case ".obj":
+		err = model3d.WriteVertexColorOBJ(w, mesh.TriangleSlice(), func(c model3d.Coord3D) [3]float64 {
+			color := colorFunc(c)
+			return [3]float64{
+				float64(color[0]) / 255,
+				float64(color[1]) / 255,
+				float64(color[2]) / 255,
+			}
+		})
+	default:
+		err = fmt.Errorf("unsupported output extension: %s", ext)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return errors.Wrap(err, "write mesh")
+	}
+	return nil
+}
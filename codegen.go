@@ -41,6 +41,9 @@ func main() {
 	Generate2d3dTemplate("surface_estimator_test", checkNoChange)
 	Generate2d3dTemplate("metaball", checkNoChange)
 	Generate2d3dTemplate("metaball_test", checkNoChange)
+	Generate2d3dTemplate("bspline", checkNoChange)
+	Generate2d3dTemplate("bspline_test", checkNoChange)
+	Generate2d3dTemplate("grid_sdf", checkNoChange)
 }
 
 func Generate2d3dTemplate(name string, checkNoChange bool) {
@@ -0,0 +1,179 @@
+package fileformats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// An APNGWriter encodes frames of an animated PNG.
+//
+// Every frame must have the same dimensions, and all
+// frames should be opaque or all frames should contain
+// transparency, since frames are compressed with the
+// standard PNG encoder.
+//
+// Paletted frames are converted to NRGBA, since an APNG
+// can only have one palette for all of its frames.
+type APNGWriter struct {
+	w         io.Writer
+	numFrames int
+	numLoops  int
+
+	written  int
+	sequence uint32
+	header   []byte
+}
+
+// NewAPNGWriter creates an APNGWriter which will encode a
+// fixed number of frames.
+//
+// The numLoops argument specifies how many times to play
+// the animation, where 0 means to loop forever.
+func NewAPNGWriter(w io.Writer, numFrames, numLoops int) *APNGWriter {
+	return &APNGWriter{w: w, numFrames: numFrames, numLoops: numLoops}
+}
+
+// WriteFrame encodes the next frame of the animation.
+//
+// The frame is shown for delayNum/delayDen seconds.
+func (a *APNGWriter) WriteFrame(img image.Image, delayNum, delayDen uint16) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "write APNG frame")
+		}
+	}()
+	if a.written == a.numFrames {
+		return errors.New("too many frames")
+	}
+
+	if _, ok := img.(image.PalettedImage); ok {
+		nrgba := image.NewNRGBA(img.Bounds())
+		draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		img = nrgba
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if a.written == 0 {
+		if _, err := a.w.Write(pngSignature); err != nil {
+			return err
+		}
+	}
+
+	bounds := img.Bounds()
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case "IHDR":
+			if a.written == 0 {
+				a.header = chunk.Data
+				if err := writePNGChunk(a.w, "IHDR", chunk.Data); err != nil {
+					return err
+				}
+				actl := make([]byte, 8)
+				binary.BigEndian.PutUint32(actl, uint32(a.numFrames))
+				binary.BigEndian.PutUint32(actl[4:], uint32(a.numLoops))
+				if err := writePNGChunk(a.w, "acTL", actl); err != nil {
+					return err
+				}
+			} else if !bytes.Equal(a.header, chunk.Data) {
+				return errors.New("frame format does not match first frame")
+			}
+			fctl := make([]byte, 26)
+			binary.BigEndian.PutUint32(fctl, a.nextSequence())
+			binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+			binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+			binary.BigEndian.PutUint16(fctl[20:], delayNum)
+			binary.BigEndian.PutUint16(fctl[22:], delayDen)
+			if err := writePNGChunk(a.w, "fcTL", fctl); err != nil {
+				return err
+			}
+		case "IDAT":
+			if a.written == 0 {
+				err = writePNGChunk(a.w, "IDAT", chunk.Data)
+			} else {
+				data := make([]byte, 4+len(chunk.Data))
+				binary.BigEndian.PutUint32(data, a.nextSequence())
+				copy(data[4:], chunk.Data)
+				err = writePNGChunk(a.w, "fdAT", data)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	a.written++
+	if a.written == a.numFrames {
+		return writePNGChunk(a.w, "IEND", nil)
+	}
+	return nil
+}
+
+func (a *APNGWriter) nextSequence() uint32 {
+	res := a.sequence
+	a.sequence++
+	return res
+}
+
+type pngChunk struct {
+	Type string
+	Data []byte
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("invalid PNG signature")
+	}
+	data = data[len(pngSignature):]
+	var res []pngChunk
+	for len(data) > 0 {
+		if len(data) < 12 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		size := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		res = append(res, pngChunk{
+			Type: string(data[4:8]),
+			Data: data[8 : 8+size],
+		})
+		data = data[12+size:]
+	}
+	return res, nil
+}
+
+func writePNGChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package fileformats
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestAPNGWriter(t *testing.T) {
+	var frames []*image.RGBA
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 7, 5))
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 7; x++ {
+				img.Set(x, y, color.RGBA{R: uint8(x * 30), G: uint8(y * 40), B: uint8(i * 50), A: 255})
+			}
+		}
+		frames = append(frames, img)
+	}
+
+	var buf bytes.Buffer
+	w := NewAPNGWriter(&buf, len(frames), 0)
+	for _, frame := range frames {
+		if err := w.WriteFrame(frame, 1, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteFrame(frames[0], 1, 10); err == nil {
+		t.Error("expected error for extra frame")
+	}
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, c := range chunks {
+		counts[c.Type]++
+	}
+	if counts["acTL"] != 1 || counts["fcTL"] != 3 || counts["IEND"] != 1 ||
+		counts["IDAT"] == 0 || counts["fdAT"] == 0 {
+		t.Errorf("unexpected chunk counts: %v", counts)
+	}
+
+	// The default image should be the first frame.
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 7; x++ {
+			r1, g1, b1, _ := decoded.At(x, y).RGBA()
+			r2, g2, b2, _ := frames[0].At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				t.Fatalf("pixel (%d, %d) mismatch", x, y)
+			}
+		}
+	}
+}
+
+func TestAPNGWriterPaletted(t *testing.T) {
+	palettes := []color.Palette{
+		{color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}},
+		{color.RGBA{B: 255, A: 255}, color.RGBA{R: 255, G: 255, A: 255}},
+	}
+	var frames []*image.Paletted
+	for _, palette := range palettes {
+		img := image.NewPaletted(image.Rect(0, 0, 4, 3), palette)
+		for y := 0; y < 3; y++ {
+			for x := 0; x < 4; x++ {
+				img.SetColorIndex(x, y, uint8((x+y)%2))
+			}
+		}
+		frames = append(frames, img)
+	}
+
+	var buf bytes.Buffer
+	w := NewAPNGWriter(&buf, len(frames), 0)
+	for _, frame := range frames {
+		if err := w.WriteFrame(frame, 1, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range chunks {
+		if c.Type == "PLTE" {
+			t.Error("unexpected palette chunk")
+		}
+	}
+	decoded, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			r1, g1, b1, a1 := decoded.At(x, y).RGBA()
+			r2, g2, b2, a2 := frames[0].At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Fatalf("pixel (%d, %d) mismatch", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,729 @@
+package fileformats
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A DXFLine is a LINE entity in a DXF file.
+type DXFLine struct {
+	Layer string
+	Start [2]float64
+	End   [2]float64
+}
+
+// A DXFPolyline is an LWPOLYLINE or POLYLINE entity in a
+// DXF file.
+type DXFPolyline struct {
+	Layer  string
+	Points [][2]float64
+
+	// Bulges is either nil, or contains the bulge of the
+	// segment starting at each point.
+	//
+	// A bulge is the tangent of a quarter of the arc's
+	// included angle, where positive bulges are
+	// counter-clockwise and zero bulges are straight.
+	Bulges []float64
+
+	// Closed is true if the last point connects back to
+	// the first.
+	Closed bool
+}
+
+// A DXFArc is an ARC entity in a DXF file.
+//
+// The arc goes counter-clockwise from StartAngle to
+// EndAngle, which are measured in degrees.
+type DXFArc struct {
+	Layer      string
+	Center     [2]float64
+	Radius     float64
+	StartAngle float64
+	EndAngle   float64
+}
+
+// A DXFCircle is a CIRCLE entity in a DXF file.
+type DXFCircle struct {
+	Layer  string
+	Center [2]float64
+	Radius float64
+}
+
+// A DXFSpline is a SPLINE entity in a DXF file.
+type DXFSpline struct {
+	Layer  string
+	Degree int
+	Knots  []float64
+
+	ControlPoints [][2]float64
+
+	// Weights is either nil, for non-rational splines, or
+	// contains one weight per control point.
+	Weights []float64
+
+	// FitPoints are points that the spline passes through,
+	// which some files include instead of control points.
+	FitPoints [][2]float64
+
+	Closed bool
+}
+
+// A DXF3DFace is a 3DFACE entity in a DXF file.
+//
+// Triangles are stored with the last corner repeated.
+type DXF3DFace struct {
+	Layer   string
+	Corners [4][3]float64
+}
+
+// A DXFFile represents the supported entities of an ASCII
+// DXF file.
+//
+// Except for 3D faces, entities are treated as planar, and
+// their Z coordinates are ignored.
+type DXFFile struct {
+	Lines     []*DXFLine
+	Polylines []*DXFPolyline
+	Arcs      []*DXFArc
+	Circles   []*DXFCircle
+	Splines   []*DXFSpline
+	Faces     []*DXF3DFace
+}
+
+// Layers gets the sorted names of all the layers used by
+// the entities in the file, where the empty layer name is
+// replaced by the default layer "0".
+func (d *DXFFile) Layers() []string {
+	layerSet := map[string]bool{}
+	for _, l := range d.Lines {
+		layerSet[dxfLayerName(l.Layer)] = true
+	}
+	for _, p := range d.Polylines {
+		layerSet[dxfLayerName(p.Layer)] = true
+	}
+	for _, a := range d.Arcs {
+		layerSet[dxfLayerName(a.Layer)] = true
+	}
+	for _, c := range d.Circles {
+		layerSet[dxfLayerName(c.Layer)] = true
+	}
+	for _, s := range d.Splines {
+		layerSet[dxfLayerName(s.Layer)] = true
+	}
+	for _, f := range d.Faces {
+		layerSet[dxfLayerName(f.Layer)] = true
+	}
+	var result []string
+	for layer := range layerSet {
+		result = append(result, layer)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Write encodes the file to w as an R12 DXF file and
+// returns the first write error encountered.
+//
+// Polylines are written as POLYLINE entities for
+// compatibility with older readers.
+// Splines are written as SPLINE entities, which are not
+// part of R12 and may be ignored by strict readers.
+func (d *DXFFile) Write(w io.Writer) error {
+	dw := &dxfWriter{w: bufio.NewWriter(w)}
+
+	dw.Section("HEADER")
+	dw.Pair(9, "$ACADVER")
+	dw.Pair(1, "AC1009")
+	dw.Pair(0, "ENDSEC")
+
+	dw.Section("TABLES")
+	layers := d.Layers()
+	dw.Pair(0, "TABLE")
+	dw.Pair(2, "LAYER")
+	dw.Int(70, len(layers))
+	for _, layer := range layers {
+		dw.Pair(0, "LAYER")
+		dw.Pair(2, layer)
+		dw.Int(70, 0)
+		dw.Int(62, 7)
+		dw.Pair(6, "CONTINUOUS")
+	}
+	dw.Pair(0, "ENDTAB")
+	dw.Pair(0, "ENDSEC")
+
+	dw.Section("ENTITIES")
+	for _, l := range d.Lines {
+		dw.Entity("LINE", l.Layer)
+		dw.Point(0, l.Start[0], l.Start[1], 0)
+		dw.Point(1, l.End[0], l.End[1], 0)
+	}
+	for _, p := range d.Polylines {
+		dw.Entity("POLYLINE", p.Layer)
+		dw.Int(66, 1)
+		dw.Point(0, 0, 0, 0)
+		if p.Closed {
+			dw.Int(70, 1)
+		} else {
+			dw.Int(70, 0)
+		}
+		for i, point := range p.Points {
+			dw.Entity("VERTEX", p.Layer)
+			dw.Point(0, point[0], point[1], 0)
+			if p.Bulges != nil && p.Bulges[i] != 0 {
+				dw.Float(42, p.Bulges[i])
+			}
+		}
+		dw.Entity("SEQEND", p.Layer)
+	}
+	for _, a := range d.Arcs {
+		dw.Entity("ARC", a.Layer)
+		dw.Point(0, a.Center[0], a.Center[1], 0)
+		dw.Float(40, a.Radius)
+		dw.Float(50, a.StartAngle)
+		dw.Float(51, a.EndAngle)
+	}
+	for _, c := range d.Circles {
+		dw.Entity("CIRCLE", c.Layer)
+		dw.Point(0, c.Center[0], c.Center[1], 0)
+		dw.Float(40, c.Radius)
+	}
+	for _, s := range d.Splines {
+		dw.Entity("SPLINE", s.Layer)
+		var flags int
+		if s.Closed {
+			flags |= 1
+		}
+		if s.Weights != nil {
+			flags |= 4
+		}
+		dw.Int(70, flags|8)
+		dw.Int(71, s.Degree)
+		dw.Int(72, len(s.Knots))
+		dw.Int(73, len(s.ControlPoints))
+		dw.Int(74, len(s.FitPoints))
+		for _, k := range s.Knots {
+			dw.Float(40, k)
+		}
+		for _, w := range s.Weights {
+			dw.Float(41, w)
+		}
+		for _, c := range s.ControlPoints {
+			dw.Point(0, c[0], c[1], 0)
+		}
+		for _, c := range s.FitPoints {
+			dw.Point(1, c[0], c[1], 0)
+		}
+	}
+	for _, f := range d.Faces {
+		dw.Entity("3DFACE", f.Layer)
+		for i, c := range f.Corners {
+			dw.Point(i, c[0], c[1], c[2])
+		}
+	}
+	dw.Pair(0, "ENDSEC")
+	dw.Pair(0, "EOF")
+
+	if dw.err != nil {
+		return errors.Wrap(dw.err, "write DXF")
+	}
+	return errors.Wrap(dw.w.Flush(), "write DXF")
+}
+
+type dxfWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (d *dxfWriter) Pair(code int, value string) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = d.w.WriteString(strconv.Itoa(code) + "\n" + value + "\n")
+}
+
+func (d *dxfWriter) Int(code, value int) {
+	d.Pair(code, strconv.Itoa(value))
+}
+
+func (d *dxfWriter) Float(code int, value float64) {
+	d.Pair(code, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (d *dxfWriter) Point(index int, x, y, z float64) {
+	d.Float(10+index, x)
+	d.Float(20+index, y)
+	d.Float(30+index, z)
+}
+
+func (d *dxfWriter) Section(name string) {
+	d.Pair(0, "SECTION")
+	d.Pair(2, name)
+}
+
+func (d *dxfWriter) Entity(name, layer string) {
+	d.Pair(0, name)
+	d.Pair(8, dxfLayerName(layer))
+}
+
+func dxfLayerName(layer string) string {
+	if layer == "" {
+		return "0"
+	}
+	return layer
+}
+
+type dxfGroup struct {
+	Code  int
+	Value string
+}
+
+func (d dxfGroup) Float() (float64, error) {
+	x, err := strconv.ParseFloat(d.Value, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "group code %d", d.Code)
+	}
+	return x, nil
+}
+
+func (d dxfGroup) Int() (int, error) {
+	x, err := strconv.Atoi(d.Value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "group code %d", d.Code)
+	}
+	return x, nil
+}
+
+type dxfEntity struct {
+	Type   string
+	Groups []dxfGroup
+}
+
+// ReadDXFFile decodes an ASCII DXF file.
+//
+// Only entities in the ENTITIES section are decoded, and
+// unsupported entities, such as text and block references,
+// are ignored.
+// Entities with a flipped extrusion direction, as produced
+// by mirroring in some CAD programs, are mirrored back into
+// the XY plane.
+func ReadDXFFile(r io.Reader) (*DXFFile, error) {
+	entities, err := readDXFEntities(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read DXF")
+	}
+	res := &DXFFile{}
+	for i := 0; i < len(entities); i++ {
+		e := entities[i]
+		var err error
+		switch e.Type {
+		case "LINE":
+			err = res.decodeLine(e)
+		case "LWPOLYLINE":
+			err = res.decodeLWPolyline(e)
+		case "POLYLINE":
+			var vertices []*dxfEntity
+			for i+1 < len(entities) && entities[i+1].Type == "VERTEX" {
+				i++
+				vertices = append(vertices, entities[i])
+			}
+			err = res.decodePolyline(e, vertices)
+		case "ARC":
+			err = res.decodeArc(e)
+		case "CIRCLE":
+			err = res.decodeCircle(e)
+		case "SPLINE":
+			err = res.decodeSpline(e)
+		case "3DFACE":
+			err = res.decodeFace(e)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read DXF: "+e.Type)
+		}
+	}
+	return res, nil
+}
+
+func readDXFEntities(r io.Reader) ([]*dxfEntity, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var lineNum int
+	var entities []*dxfEntity
+	var cur *dxfEntity
+	var section string
+	var expectSectionName bool
+	for {
+		if !scanner.Scan() {
+			break
+		}
+		lineNum++
+		codeStr := strings.TrimSpace(scanner.Text())
+		if !scanner.Scan() {
+			return nil, errors.Errorf("line %d: missing value for group code", lineNum)
+		}
+		lineNum++
+		value := strings.TrimSpace(scanner.Text())
+		code, err := strconv.Atoi(codeStr)
+		if err != nil {
+			return nil, errors.Errorf("line %d: invalid group code: %s", lineNum-1, codeStr)
+		}
+
+		if expectSectionName {
+			expectSectionName = false
+			if code == 2 {
+				section = value
+				continue
+			}
+		}
+		if code == 0 {
+			cur = nil
+			switch value {
+			case "SECTION":
+				expectSectionName = true
+			case "ENDSEC":
+				section = ""
+			case "EOF":
+				return entities, nil
+			default:
+				if section == "ENTITIES" {
+					cur = &dxfEntity{Type: value}
+					entities = append(entities, cur)
+				}
+			}
+		} else if cur != nil {
+			cur.Groups = append(cur.Groups, dxfGroup{Code: code, Value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// dxfCommon stores the properties shared by all entities.
+type dxfCommon struct {
+	Layer   string
+	Flipped bool
+}
+
+func decodeDXFCommon(e *dxfEntity) (*dxfCommon, error) {
+	res := &dxfCommon{}
+	for _, g := range e.Groups {
+		switch g.Code {
+		case 8:
+			res.Layer = g.Value
+		case 230:
+			z, err := g.Float()
+			if err != nil {
+				return nil, err
+			}
+			res.Flipped = z < 0
+		}
+	}
+	return res, nil
+}
+
+// decodeDXFPoints reads consecutive groups with the given X
+// and Y codes into a list of points, calling f for every
+// other group.
+func decodeDXFPoints(e *dxfEntity, xCode int, f func(g dxfGroup, numPoints int) error) ([][2]float64, error) {
+	var points [][2]float64
+	for _, g := range e.Groups {
+		switch g.Code {
+		case xCode:
+			x, err := g.Float()
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, [2]float64{x, 0})
+		case xCode + 10:
+			y, err := g.Float()
+			if err != nil {
+				return nil, err
+			}
+			if len(points) == 0 {
+				return nil, errors.Errorf("group code %d before %d", xCode+10, xCode)
+			}
+			points[len(points)-1][1] = y
+		default:
+			if f != nil {
+				if err := f(g, len(points)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return points, nil
+}
+
+func (d *DXFFile) decodeLine(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	start, err := decodeDXFPoints(e, 10, nil)
+	if err != nil {
+		return err
+	}
+	end, err := decodeDXFPoints(e, 11, nil)
+	if err != nil {
+		return err
+	}
+	if len(start) != 1 || len(end) != 1 {
+		return errors.New("missing endpoints")
+	}
+	d.Lines = append(d.Lines, &DXFLine{Layer: common.Layer, Start: start[0], End: end[0]})
+	return nil
+}
+
+func (d *DXFFile) decodeLWPolyline(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXFPolyline{Layer: common.Layer}
+	var bulges []float64
+	res.Points, err = decodeDXFPoints(e, 10, func(g dxfGroup, numPoints int) error {
+		switch g.Code {
+		case 70:
+			flags, err := g.Int()
+			if err != nil {
+				return err
+			}
+			res.Closed = flags&1 != 0
+		case 42:
+			if numPoints == 0 {
+				return errors.New("bulge before first vertex")
+			}
+			bulge, err := g.Float()
+			if err != nil {
+				return err
+			}
+			for len(bulges) < numPoints {
+				bulges = append(bulges, 0)
+			}
+			bulges[numPoints-1] = bulge
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if bulges != nil {
+		for len(bulges) < len(res.Points) {
+			bulges = append(bulges, 0)
+		}
+		res.Bulges = bulges
+	}
+	if common.Flipped {
+		res.mirror()
+	}
+	d.Polylines = append(d.Polylines, res)
+	return nil
+}
+
+func (d *DXFFile) decodePolyline(e *dxfEntity, vertices []*dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXFPolyline{Layer: common.Layer}
+	for _, g := range e.Groups {
+		if g.Code == 70 {
+			flags, err := g.Int()
+			if err != nil {
+				return err
+			}
+			if flags&(16|64) != 0 {
+				// Polygon and polyface meshes are not supported.
+				return nil
+			}
+			res.Closed = flags&1 != 0
+		}
+	}
+	var bulges []float64
+	for _, v := range vertices {
+		var bulge float64
+		var skip bool
+		points, err := decodeDXFPoints(v, 10, func(g dxfGroup, numPoints int) error {
+			var err error
+			switch g.Code {
+			case 42:
+				bulge, err = g.Float()
+			case 70:
+				var flags int
+				flags, err = g.Int()
+				// Skip spline frame control points.
+				skip = flags&16 != 0
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
+		if len(points) != 1 {
+			return errors.New("invalid vertex")
+		}
+		res.Points = append(res.Points, points[0])
+		if bulge != 0 && bulges == nil {
+			bulges = make([]float64, len(res.Points)-1, len(res.Points))
+		}
+		if bulges != nil {
+			bulges = append(bulges, bulge)
+		}
+	}
+	res.Bulges = bulges
+	if common.Flipped {
+		res.mirror()
+	}
+	d.Polylines = append(d.Polylines, res)
+	return nil
+}
+
+func (d *DXFPolyline) mirror() {
+	for i := range d.Points {
+		d.Points[i][0] *= -1
+	}
+	for i := range d.Bulges {
+		d.Bulges[i] *= -1
+	}
+}
+
+func (d *DXFFile) decodeArc(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXFArc{Layer: common.Layer}
+	centers, err := decodeDXFPoints(e, 10, func(g dxfGroup, numPoints int) error {
+		var err error
+		switch g.Code {
+		case 40:
+			res.Radius, err = g.Float()
+		case 50:
+			res.StartAngle, err = g.Float()
+		case 51:
+			res.EndAngle, err = g.Float()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	} else if len(centers) != 1 {
+		return errors.New("missing center")
+	}
+	res.Center = centers[0]
+	if common.Flipped {
+		res.Center[0] *= -1
+		res.StartAngle, res.EndAngle = 180-res.EndAngle, 180-res.StartAngle
+	}
+	d.Arcs = append(d.Arcs, res)
+	return nil
+}
+
+func (d *DXFFile) decodeCircle(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXFCircle{Layer: common.Layer}
+	centers, err := decodeDXFPoints(e, 10, func(g dxfGroup, numPoints int) error {
+		var err error
+		if g.Code == 40 {
+			res.Radius, err = g.Float()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	} else if len(centers) != 1 {
+		return errors.New("missing center")
+	}
+	res.Center = centers[0]
+	if common.Flipped {
+		res.Center[0] *= -1
+	}
+	d.Circles = append(d.Circles, res)
+	return nil
+}
+
+func (d *DXFFile) decodeSpline(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXFSpline{Layer: common.Layer}
+	res.ControlPoints, err = decodeDXFPoints(e, 10, func(g dxfGroup, numPoints int) error {
+		var err error
+		switch g.Code {
+		case 70:
+			var flags int
+			flags, err = g.Int()
+			res.Closed = flags&1 != 0
+		case 71:
+			res.Degree, err = g.Int()
+		case 40:
+			var k float64
+			k, err = g.Float()
+			res.Knots = append(res.Knots, k)
+		case 41:
+			var w float64
+			w, err = g.Float()
+			res.Weights = append(res.Weights, w)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	res.FitPoints, err = decodeDXFPoints(e, 11, nil)
+	if err != nil {
+		return err
+	}
+	if res.Weights != nil && len(res.Weights) != len(res.ControlPoints) {
+		return errors.New("mismatched number of weights")
+	}
+	if len(res.ControlPoints) > 0 && len(res.Knots) != len(res.ControlPoints)+res.Degree+1 {
+		return errors.New("unexpected number of knots")
+	}
+	d.Splines = append(d.Splines, res)
+	return nil
+}
+
+func (d *DXFFile) decodeFace(e *dxfEntity) error {
+	common, err := decodeDXFCommon(e)
+	if err != nil {
+		return err
+	}
+	res := &DXF3DFace{Layer: common.Layer}
+	var found [4][3]bool
+	for _, g := range e.Groups {
+		if g.Code < 10 || g.Code >= 40 || g.Code%10 > 3 {
+			continue
+		}
+		axis, corner := g.Code/10-1, g.Code%10
+		res.Corners[corner][axis], err = g.Float()
+		if err != nil {
+			return err
+		}
+		found[corner][axis] = true
+	}
+	for i := 0; i < 3; i++ {
+		if !found[i][0] || !found[i][1] {
+			return errors.Errorf("missing corner %d", i)
+		}
+	}
+	if !found[3][0] || !found[3][1] {
+		res.Corners[3] = res.Corners[2]
+	}
+	d.Faces = append(d.Faces, res)
+	return nil
+}
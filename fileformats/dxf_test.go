@@ -0,0 +1,91 @@
+package fileformats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDXFRoundTrip(t *testing.T) {
+	f := &DXFFile{
+		Lines: []*DXFLine{
+			{Layer: "cut", Start: [2]float64{1, 2}, End: [2]float64{3.5, -4}},
+		},
+		Polylines: []*DXFPolyline{
+			{Layer: "cut", Points: [][2]float64{{0, 0}, {1, 0}, {1, 1}}, Closed: true},
+			{Layer: "engrave", Points: [][2]float64{{0, 0}, {2, 0}}, Bulges: []float64{1, 0}},
+		},
+		Arcs: []*DXFArc{
+			{Layer: "engrave", Center: [2]float64{1, 1}, Radius: 2, StartAngle: 10, EndAngle: 350},
+		},
+		Circles: []*DXFCircle{
+			{Layer: "0", Center: [2]float64{-1, 1}, Radius: 0.5},
+		},
+		Splines: []*DXFSpline{
+			{
+				Layer:         "0",
+				Degree:        2,
+				Knots:         []float64{0, 0, 0, 1, 1, 1},
+				ControlPoints: [][2]float64{{1, 0}, {1, 1}, {0, 1}},
+				Weights:       []float64{1, 0.5, 1},
+			},
+		},
+		Faces: []*DXF3DFace{
+			{Layer: "0", Corners: [4][3]float64{{0, 0, 0}, {1, 0, 0}, {0, 1, 2}, {0, 1, 2}}},
+		},
+	}
+	if layers := f.Layers(); !reflect.DeepEqual(layers, []string{"0", "cut", "engrave"}) {
+		t.Errorf("unexpected layers: %v", layers)
+	}
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadDXFFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, decoded) {
+		t.Error("decoded file does not match original")
+	}
+}
+
+func TestReadDXFFile(t *testing.T) {
+	data := strings.Join([]string{
+		"  0", "SECTION", "  2", "HEADER", "  9", "$ACADVER", "  1", "AC1015",
+		"  0", "ENDSEC",
+		"  0", "SECTION", "  2", "ENTITIES",
+		"  0", "LWPOLYLINE", "  8", "L1", " 90", "3", " 70", "1",
+		" 10", "0.0", " 20", "0.0", " 42", "0.5",
+		" 10", "2.0", " 20", "0.0",
+		" 10", "2.0", " 20", "3.0",
+		"  0", "ARC", "  8", "L2", " 10", "1.0", " 20", "2.0", " 30", "0.0",
+		" 40", "3.0", " 50", "0.0", " 51", "90.0",
+		"210", "0.0", "220", "0.0", "230", "-1.0",
+		"  0", "TEXT", "  8", "L1", "  1", "ignored",
+		"  0", "ENDSEC",
+		"  0", "EOF",
+	}, "\r\n")
+	f, err := ReadDXFFile(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DXFFile{
+		Polylines: []*DXFPolyline{
+			{
+				Layer:  "L1",
+				Points: [][2]float64{{0, 0}, {2, 0}, {2, 3}},
+				Bulges: []float64{0.5, 0, 0},
+				Closed: true,
+			},
+		},
+		Arcs: []*DXFArc{
+			{Layer: "L2", Center: [2]float64{-1, 2}, Radius: 3, StartAngle: 90, EndAngle: 180},
+		},
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Errorf("unexpected result: %#v", f)
+	}
+}
@@ -151,3 +151,45 @@ func (o *OFFReader) readVertices() (err error) {
 	o.vertices = vertices
 	return nil
 }
+
+// An OFFWriter encodes a triangle mesh as an OFF file.
+type OFFWriter struct {
+	w io.Writer
+}
+
+// NewOFFWriter creates a new OFFWriter and writes the file
+// header.
+func NewOFFWriter(w io.Writer, numCoords, numTris int) (*OFFWriter, error) {
+	if _, err := fmt.Fprintf(w, "OFF\n%d %d 0\n", numCoords, numTris); err != nil {
+		return nil, errors.Wrap(err, "write OFF header")
+	}
+	return &OFFWriter{w: w}, nil
+}
+
+// WriteCoord writes the next coordinate to the file.
+//
+// This should be called exactly numCoords times, before
+// any triangles are written.
+func (o *OFFWriter) WriteCoord(c [3]float64) error {
+	_, err := fmt.Fprintf(o.w, "%s %s %s\n", formatOFFFloat(c[0]), formatOFFFloat(c[1]),
+		formatOFFFloat(c[2]))
+	if err != nil {
+		return errors.Wrap(err, "write OFF coordinate")
+	}
+	return nil
+}
+
+// WriteTriangle writes the next triangle to the file.
+//
+// This should be called exactly numTris times.
+func (o *OFFWriter) WriteTriangle(coords [3]int) error {
+	_, err := fmt.Fprintf(o.w, "3 %d %d %d\n", coords[0], coords[1], coords[2])
+	if err != nil {
+		return errors.Wrap(err, "write OFF triangle")
+	}
+	return nil
+}
+
+func formatOFFFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
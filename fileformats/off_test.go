@@ -0,0 +1,57 @@
+package fileformats
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestOFFWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewOFFWriter(&buf, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coords := [][3]float64{
+		{0, 0, 0},
+		{1.5, 0, 0},
+		{1.5, 1, 0},
+		{0, 1, -0.25},
+	}
+	indices := [][3]int{{0, 1, 2}, {0, 2, 3}}
+	for _, coord := range coords {
+		if err := w.WriteCoord(coord); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tri := range indices {
+		if err := w.WriteTriangle(tri); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual := buf.String()
+	expected := "OFF\n4 2 0\n0 0 0\n1.5 0 0\n1.5 1 0\n0 1 -0.25\n3 0 1 2\n3 0 2 3\n"
+	if actual != expected {
+		t.Fatalf("unexpected output: %#v", actual)
+	}
+
+	r, err := NewOFFReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tri := range indices {
+		face, err := r.ReadFace()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, idx := range tri {
+			if face[i] != coords[idx] {
+				t.Errorf("expected %v but got %v", coords[idx], face[i])
+			}
+		}
+	}
+	if _, err := r.ReadFace(); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"io"
 	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 // An OBJFileFaceGroup is a group of faces with one
@@ -114,6 +117,136 @@ func (o *OBJFile) encodeFace(coords [3][3]int) string {
 	return res + "\n"
 }
 
+// ReadOBJFile decodes a Wavefront obj file.
+//
+// Faces with more than three vertices are split into
+// triangle fans, and negative (relative) indices are
+// converted to absolute indices.
+// Unsupported statements, such as groups and smoothing
+// settings, are ignored.
+func ReadOBJFile(r io.Reader) (*OBJFile, error) {
+	res := &OBJFile{}
+	var group *OBJFileFaceGroup
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		lineErr := func(err error) error {
+			return errors.Wrapf(err, "read OBJ: line %d", lineNum)
+		}
+		switch fields[0] {
+		case "mtllib":
+			res.MaterialFiles = append(res.MaterialFiles, fields[1:]...)
+		case "usemtl":
+			group = &OBJFileFaceGroup{}
+			if len(fields) > 1 {
+				group.Material = fields[1]
+			}
+			res.FaceGroups = append(res.FaceGroups, group)
+		case "v":
+			values, err := parseOBJFloats(fields[1:], 3)
+			if err != nil {
+				return nil, lineErr(err)
+			}
+			res.Vertices = append(res.Vertices, [3]float64{values[0], values[1], values[2]})
+			if len(values) >= 6 {
+				// Pad earlier vertices which had no color.
+				for len(res.VertexColors) < len(res.Vertices)-1 {
+					res.VertexColors = append(res.VertexColors, [3]float64{})
+				}
+				res.VertexColors = append(res.VertexColors,
+					[3]float64{values[3], values[4], values[5]})
+			} else if res.VertexColors != nil {
+				res.VertexColors = append(res.VertexColors, [3]float64{})
+			}
+		case "vn":
+			values, err := parseOBJFloats(fields[1:], 3)
+			if err != nil {
+				return nil, lineErr(err)
+			}
+			res.Normals = append(res.Normals, [3]float64{values[0], values[1], values[2]})
+		case "vt":
+			values, err := parseOBJFloats(fields[1:], 2)
+			if err != nil {
+				return nil, lineErr(err)
+			}
+			res.UVs = append(res.UVs, [2]float64{values[0], values[1]})
+		case "f":
+			if len(fields) < 4 {
+				return nil, lineErr(errors.New("face has fewer than three vertices"))
+			}
+			var vertices [][3]int
+			for _, field := range fields[1:] {
+				v, err := res.parseFaceVertex(field)
+				if err != nil {
+					return nil, lineErr(err)
+				}
+				vertices = append(vertices, v)
+			}
+			if group == nil {
+				group = &OBJFileFaceGroup{}
+				res.FaceGroups = append(res.FaceGroups, group)
+			}
+			for i := 2; i < len(vertices); i++ {
+				group.Faces = append(group.Faces, [3][3]int{vertices[0], vertices[i-1], vertices[i]})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read OBJ")
+	}
+	return res, nil
+}
+
+func (o *OBJFile) parseFaceVertex(field string) ([3]int, error) {
+	var res [3]int
+	parts := strings.Split(field, "/")
+	if len(parts) > 3 {
+		return res, errors.New("invalid face vertex: " + field)
+	}
+	counts := [3]int{len(o.Vertices), len(o.UVs), len(o.Normals)}
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return res, errors.New("invalid face vertex: " + field)
+			}
+			continue
+		}
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return res, err
+		}
+		if idx < 0 {
+			idx += counts[i] + 1
+		}
+		if idx < 1 || idx > counts[i] {
+			return res, errors.New("index out of bounds: " + field)
+		}
+		res[i] = idx
+	}
+	return res, nil
+}
+
+func parseOBJFloats(fields []string, minCount int) ([]float64, error) {
+	if len(fields) < minCount {
+		return nil, errors.New("not enough values")
+	}
+	res := make([]float64, len(fields))
+	for i, field := range fields {
+		x, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = x
+	}
+	return res, nil
+}
+
 // MTLFileTextureMap is a configured texture map for an
 // MTLFileMaterial.
 type MTLFileTextureMap struct {
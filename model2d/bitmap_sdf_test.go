@@ -0,0 +1,64 @@
+package model2d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBitmapSDF(t *testing.T) {
+	t.Run("Rect", func(t *testing.T) {
+		bmp := NewBitmap(40, 30)
+		for y := 10; y < 20; y++ {
+			for x := 10; x < 30; x++ {
+				bmp.Set(x, y, true)
+			}
+		}
+		sdf := BitmapToSDF(bmp)
+		expected := NewRect(XY(10, 10), XY(30, 20))
+		for _, c := range []Coord{XY(20, 12), XY(11.3, 15), XY(20, 8), XY(35, 15), XY(25, 19.7)} {
+			if actual, expected := sdf.SDF(c), expected.SDF(c); math.Abs(actual-expected) > 1e-8 {
+				t.Errorf("point %v: expected SDF %f but got %f", c, expected, actual)
+			}
+		}
+	})
+
+	t.Run("Circle", func(t *testing.T) {
+		circle := &Circle{Center: XY(32, 30), Radius: 20}
+		bmp := NewBitmap(64, 64)
+		for y := 0; y < bmp.Height; y++ {
+			for x := 0; x < bmp.Width; x++ {
+				bmp.Set(x, y, circle.Contains(XY(float64(x)+0.5, float64(y)+0.5)))
+			}
+		}
+		sdf := BitmapToSDF(bmp)
+		if sdf.Min() != (Coord{}) || sdf.Max() != XY(64, 64) {
+			t.Errorf("unexpected bounds: %v, %v", sdf.Min(), sdf.Max())
+		}
+		for i := 0; i < 1000; i++ {
+			c := NewCoordRandBounds(XY(-10, -10), XY(74, 74))
+			expected := circle.SDF(c)
+			actual := sdf.SDF(c)
+			if math.Abs(expected) < 15 && math.Abs(actual-expected) > 1 {
+				t.Errorf("point %v: expected SDF %f but got %f", c, expected, actual)
+			}
+			if actual > expected+1 {
+				t.Errorf("point %v: SDF %f is not a lower bound of %f", c, actual, expected)
+			}
+
+			point, pointSDF := sdf.PointSDF(c)
+			if pointSDF != actual {
+				t.Errorf("point %v: mismatched SDFs %f and %f", c, actual, pointSDF)
+			}
+			if math.Abs(expected) < 15 && math.Abs(circle.SDF(point)) > 1 {
+				t.Errorf("point %v: nearest point %v is not on the surface", c, point)
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		sdf := BitmapToSDF(NewBitmap(5, 5))
+		if d := sdf.SDF(XY(2.5, 2.5)); d >= 0 {
+			t.Errorf("unexpected SDF: %f", d)
+		}
+	})
+}
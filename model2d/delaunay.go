@@ -0,0 +1,756 @@
+package model2d
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	DefaultDelaunayMaxSteinerPoints = 100000
+)
+
+// A DelaunayTriangulator creates constrained Delaunay
+// triangulations of the regions enclosed by meshes,
+// optionally refining them to improve the quality of the
+// triangles.
+//
+// Refinement uses Ruppert's algorithm, which inserts the
+// circumcenters of bad triangles and splits boundary
+// segments that these points would encroach upon.
+// Refinement is guaranteed to terminate for minimum angles
+// up to roughly 20 degrees, and usually works up to about
+// 30 degrees, unless the input itself has small angles.
+type DelaunayTriangulator struct {
+	// MinAngle, if non-zero, is the minimum angle (in
+	// radians) of any triangle in the refined result.
+	MinAngle float64
+
+	// MaxArea, if non-zero, is the maximum area of any
+	// triangle in the refined result.
+	MaxArea float64
+
+	// MaxSteinerPoints limits the number of points added
+	// during refinement.
+	//
+	// If 0, DefaultDelaunayMaxSteinerPoints is used.
+	MaxSteinerPoints int
+
+	// Points are extra vertices to include in the
+	// triangulation. Points outside of the mesh are
+	// ignored.
+	Points []Coord
+}
+
+// TriangulateMeshDelaunay creates a constrained Delaunay
+// triangulation of the region enclosed by a mesh, without
+// any refinement.
+//
+// See DelaunayTriangulator.Triangulate for details.
+func TriangulateMeshDelaunay(m *Mesh) [][3]Coord {
+	return (&DelaunayTriangulator{}).Triangulate(m)
+}
+
+// Triangulate creates a triangulation of the region
+// enclosed by the mesh.
+//
+// The mesh must be closed and may not intersect itself,
+// but it may have holes, and it is assumed to obey the
+// even-odd rule for containment.
+// Unlike TriangulateMesh, the mesh need not be manifold,
+// and its normals are ignored.
+//
+// As with TriangulateMesh, the vertices of the resulting
+// triangles are ordered clockwise.
+func (d *DelaunayTriangulator) Triangulate(m *Mesh) [][3]Coord {
+	vertices, tris := d.TriangulateIndexed(m)
+	result := make([][3]Coord, len(tris))
+	for i, t := range tris {
+		result[i] = [3]Coord{vertices[t[0]], vertices[t[1]], vertices[t[2]]}
+	}
+	return result
+}
+
+// TriangulateIndexed is like Triangulate, but returns a
+// list of unique vertices along with triangles which store
+// indices into this list.
+//
+// This is useful for finite element methods, where the
+// connectivity between triangles matters.
+func (d *DelaunayTriangulator) TriangulateIndexed(m *Mesh) ([]Coord, [][3]int) {
+	if m.NumSegments() == 0 {
+		return nil, nil
+	}
+	cdt := newCDT(m.Min(), m.Max())
+	var segments [][2]int
+	m.Iterate(func(s *Segment) {
+		segments = append(segments, [2]int{cdt.InsertPoint(s[0]), cdt.InsertPoint(s[1])})
+	})
+	for _, p := range d.Points {
+		cdt.InsertPoint(p)
+	}
+	for _, s := range segments {
+		if s[0] != s[1] && s[0] != -1 && s[1] != -1 {
+			cdt.InsertConstraint(s[0], s[1])
+		}
+	}
+	cdt.RemoveExterior()
+	if d.MinAngle != 0 || d.MaxArea != 0 {
+		d.refine(cdt)
+	}
+	return cdt.Result()
+}
+
+func (d *DelaunayTriangulator) refine(cdt *cdt) {
+	maxPoints := d.MaxSteinerPoints
+	if maxPoints == 0 {
+		maxPoints = DefaultDelaunayMaxSteinerPoints
+	}
+	maxRatio := math.Inf(1)
+	if d.MinAngle != 0 {
+		maxRatio = 1 / (2 * math.Sin(d.MinAngle))
+	}
+	minLength := cdt.Scale * 1e-8
+
+	numAdded := 0
+	skip := map[[3]int]bool{}
+	for numAdded < maxPoints {
+		numAdded += cdt.SplitEncroached(minLength, maxPoints-numAdded)
+
+		var bad [][3]int
+		cdt.IterateTriangles(func(t [3]int) {
+			if skip[t] {
+				return
+			}
+			p1, p2, p3 := cdt.Vertices[t[0]], cdt.Vertices[t[1]], cdt.Vertices[t[2]]
+			area := det(p2.Sub(p1), p3.Sub(p1)) / 2
+			l1, l2, l3 := p1.Dist(p2), p2.Dist(p3), p3.Dist(p1)
+			minEdge := math.Min(l1, math.Min(l2, l3))
+			if minEdge < minLength {
+				return
+			}
+			circumradius := l1 * l2 * l3 / (4 * area)
+			if (d.MaxArea != 0 && area > d.MaxArea) || circumradius/minEdge > maxRatio {
+				bad = append(bad, t)
+			}
+		})
+		if len(bad) == 0 {
+			break
+		}
+		progress := false
+		for _, t := range bad {
+			if numAdded >= maxPoints {
+				break
+			}
+			if !cdt.HasTriangle(t) {
+				continue
+			}
+			added, ok := cdt.InsertCircumcenter(t, minLength)
+			if !ok {
+				skip[t] = true
+			} else {
+				progress = true
+			}
+			numAdded += added
+		}
+		if !progress {
+			break
+		}
+	}
+}
+
+// A cdt is a constrained Delaunay triangulation.
+//
+// Triangles are stored by mapping each directed edge to
+// the vertex opposite to it in the triangle that contains
+// it, where the vertices of triangles are counter-clockwise.
+type cdt struct {
+	Vertices []Coord
+	Scale    float64
+
+	vertexIDs   *CoordMap[int]
+	edges       map[uint64]int
+	constraints map[uint64]int
+	vertexEdge  []int
+	lastVertex  int
+	numSuper    int
+}
+
+func newCDT(min, max Coord) *cdt {
+	center := min.Mid(max)
+	size := math.Max(max.Sub(min).MaxCoord(), 1e-8)
+	c := &cdt{
+		Scale:       size,
+		vertexIDs:   NewCoordMap[int](),
+		edges:       map[uint64]int{},
+		constraints: map[uint64]int{},
+	}
+
+	// Create a large triangle which contains every point.
+	for i := 0; i < 3; i++ {
+		p := center.Add(NewCoordPolar(float64(i)*2*math.Pi/3+math.Pi/2, size*20))
+		c.Vertices = append(c.Vertices, p)
+		c.vertexEdge = append(c.vertexEdge, -1)
+	}
+	c.numSuper = 3
+	c.addTriangle(0, 1, 2)
+	return c
+}
+
+func cdtEdgeKey(a, b int) uint64 {
+	return uint64(a)<<32 | uint64(uint32(b))
+}
+
+func cdtUndirectedKey(a, b int) uint64 {
+	if a > b {
+		a, b = b, a
+	}
+	return cdtEdgeKey(a, b)
+}
+
+func (c *cdt) addTriangle(a, b, d int) {
+	c.edges[cdtEdgeKey(a, b)] = d
+	c.edges[cdtEdgeKey(b, d)] = a
+	c.edges[cdtEdgeKey(d, a)] = b
+	c.vertexEdge[a] = b
+	c.vertexEdge[b] = d
+	c.vertexEdge[d] = a
+}
+
+func (c *cdt) removeTriangle(a, b, d int) {
+	delete(c.edges, cdtEdgeKey(a, b))
+	delete(c.edges, cdtEdgeKey(b, d))
+	delete(c.edges, cdtEdgeKey(d, a))
+}
+
+func (c *cdt) opposite(a, b int) (int, bool) {
+	v, ok := c.edges[cdtEdgeKey(a, b)]
+	return v, ok
+}
+
+func (c *cdt) isConstrained(a, b int) bool {
+	return c.constraints[cdtUndirectedKey(a, b)]%2 == 1
+}
+
+func (c *cdt) orient(a, b, p int) float64 {
+	return cdtOrient(c.Vertices[a], c.Vertices[b], c.Vertices[p])
+}
+
+func cdtOrient(a, b, p Coord) float64 {
+	return det(b.Sub(a), p.Sub(a))
+}
+
+// inCircle checks if p is strictly inside the circumcircle
+// of the counter-clockwise triangle a, b, d.
+func inCircle(a, b, d, p Coord) bool {
+	v1, v2, v3 := a.Sub(p), b.Sub(p), d.Sub(p)
+	n1, n2, n3 := v1.Dot(v1), v2.Dot(v2), v3.Dot(v3)
+	t1 := n1 * det(v2, v3)
+	t2 := n2 * det(v3, v1)
+	t3 := n3 * det(v1, v2)
+	permanent := math.Abs(t1) + math.Abs(t2) + math.Abs(t3)
+	return t1+t2+t3 > 1e-12*permanent
+}
+
+// IterateTriangles calls f for each triangle in a
+// deterministic order, where the first vertex always has
+// the smallest index.
+func (c *cdt) IterateTriangles(f func(t [3]int)) {
+	var tris [][3]int
+	for key, d := range c.edges {
+		a, b := int(key>>32), int(uint32(key))
+		if a < b && a < d {
+			tris = append(tris, [3]int{a, b, d})
+		}
+	}
+	sort.Slice(tris, func(i, j int) bool {
+		t1, t2 := tris[i], tris[j]
+		if t1[0] != t2[0] {
+			return t1[0] < t2[0]
+		}
+		return t1[1] < t2[1]
+	})
+	for _, t := range tris {
+		f(t)
+	}
+}
+
+func (c *cdt) HasTriangle(t [3]int) bool {
+	d, ok := c.opposite(t[0], t[1])
+	return ok && d == t[2]
+}
+
+// InsertPoint adds a vertex to the triangulation, or
+// returns an existing vertex at the same location.
+func (c *cdt) InsertPoint(p Coord) int {
+	if id, ok := c.vertexIDs.Load(p); ok {
+		return id
+	}
+	start := c.lastVertex
+	if start < c.numSuper {
+		start = 0
+	}
+	edge, outside := c.locate(p, start, c.vertexEdge[start])
+	if outside || edge[0] == -1 {
+		// This should only happen due to rounding error,
+		// since the super-triangle contains every point.
+		return -1
+	}
+	return c.insertAt(p, edge)
+}
+
+// insertAt adds a point inside a triangle given by one of
+// its directed edges.
+func (c *cdt) insertAt(p Coord, edge [2]int) int {
+	a, b := edge[0], edge[1]
+	d, _ := c.opposite(a, b)
+	for _, v := range []int{a, b, d} {
+		if c.Vertices[v] == p {
+			return v
+		}
+	}
+
+	id := len(c.Vertices)
+	c.Vertices = append(c.Vertices, p)
+	c.vertexEdge = append(c.vertexEdge, -1)
+	c.vertexIDs.Store(p, id)
+	c.lastVertex = id
+
+	tri := [3]int{a, b, d}
+	for i := 0; i < 3; i++ {
+		u, v := tri[i], tri[(i+1)%3]
+		if c.orient(u, v, id) == 0 {
+			c.splitEdge(u, v, id)
+			return id
+		}
+	}
+	c.removeTriangle(a, b, d)
+	c.addTriangle(a, b, id)
+	c.addTriangle(b, d, id)
+	c.addTriangle(d, a, id)
+	c.legalize(a, b)
+	c.legalize(b, d)
+	c.legalize(d, a)
+	return id
+}
+
+// splitEdge splits the edge between u and v at the new
+// vertex p, which should lie on the edge.
+func (c *cdt) splitEdge(u, v, p int) {
+	key := cdtUndirectedKey(u, v)
+	if count, ok := c.constraints[key]; ok {
+		delete(c.constraints, key)
+		c.constraints[cdtUndirectedKey(u, p)] += count
+		c.constraints[cdtUndirectedKey(p, v)] += count
+	}
+	var toLegalize [][2]int
+	for _, e := range [][2]int{{u, v}, {v, u}} {
+		w, ok := c.opposite(e[0], e[1])
+		if !ok {
+			continue
+		}
+		c.removeTriangle(e[0], e[1], w)
+		c.addTriangle(e[0], p, w)
+		c.addTriangle(p, e[1], w)
+		toLegalize = append(toLegalize, [2]int{w, e[0]}, [2]int{e[1], w})
+	}
+	for _, e := range toLegalize {
+		c.legalize(e[0], e[1])
+	}
+}
+
+// legalize restores the Delaunay property by flipping the
+// edge from a to b and subsequent edges as necessary.
+func (c *cdt) legalize(a, b int) {
+	stack := [][2]int{{a, b}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		s, t := e[0], e[1]
+		p, ok1 := c.opposite(s, t)
+		q, ok2 := c.opposite(t, s)
+		if !ok1 || !ok2 || c.isConstrained(s, t) {
+			continue
+		}
+		vs := c.Vertices
+		if !inCircle(vs[s], vs[t], vs[p], vs[q]) {
+			continue
+		}
+		// Flipping is only valid if the quad is convex.
+		if c.orient(s, q, p) <= 0 || c.orient(q, t, p) <= 0 {
+			continue
+		}
+		c.removeTriangle(s, t, p)
+		c.removeTriangle(t, s, q)
+		c.addTriangle(s, q, p)
+		c.addTriangle(q, t, p)
+		stack = append(stack, [2]int{s, q}, [2]int{q, t})
+	}
+}
+
+// locate walks from a triangle towards a point.
+//
+// It returns a directed edge of the triangle containing
+// the point. If the walk leaves the triangulation, then
+// outside is true and the returned edge is the boundary
+// edge which the walk tried to cross.
+//
+// If the walk fails, the first vertex of edge is -1.
+func (c *cdt) locate(p Coord, a, b int) (edge [2]int, outside bool) {
+	maxSteps := len(c.edges) + 10
+	for step := 0; step < maxSteps; step++ {
+		d, ok := c.opposite(a, b)
+		if !ok {
+			return [2]int{-1, -1}, false
+		}
+		tri := [3]int{a, b, d}
+		moved := false
+		for i := 0; i < 3; i++ {
+			// Rotate the starting edge to avoid cycles.
+			j := (i + step) % 3
+			u, v := tri[j], tri[(j+1)%3]
+			if cdtOrient(c.Vertices[u], c.Vertices[v], p) < 0 {
+				if _, ok := c.opposite(v, u); !ok {
+					return [2]int{u, v}, true
+				}
+				a, b = v, u
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			return [2]int{a, b}, false
+		}
+	}
+	return [2]int{-1, -1}, false
+}
+
+// InsertConstraint forces an edge between two vertices
+// into the triangulation.
+func (c *cdt) InsertConstraint(a, b int) {
+	if _, ok := c.opposite(a, b); ok {
+		c.constraints[cdtUndirectedKey(a, b)]++
+		return
+	}
+	if _, ok := c.opposite(b, a); ok {
+		c.constraints[cdtUndirectedKey(a, b)]++
+		return
+	}
+	pa, pb := c.Vertices[a], c.Vertices[b]
+
+	// Find the triangle around a which the segment enters.
+	first := c.vertexEdge[a]
+	u := first
+	var w int
+	for {
+		var ok bool
+		w, ok = c.opposite(a, u)
+		if !ok {
+			panic("invalid triangulation around vertex")
+		}
+		o1 := c.orient(a, u, b)
+		if o1 == 0 && c.Vertices[u].Sub(pa).Dot(pb.Sub(pa)) > 0 {
+			// The segment passes through u.
+			c.InsertConstraint(a, u)
+			c.InsertConstraint(u, b)
+			return
+		}
+		if o1 > 0 && c.orient(a, w, b) < 0 {
+			break
+		}
+		u = w
+		if u == first {
+			panic("constraint segment not found around vertex")
+		}
+	}
+
+	removed := [][3]int{{a, u, w}}
+	leftChain := []int{w}
+	rightChain := []int{u}
+	right, left := u, w
+	end := b
+	for {
+		v, _ := c.opposite(left, right)
+		removed = append(removed, [3]int{left, right, v})
+		if v == b {
+			break
+		}
+		o := cdtOrient(pa, pb, c.Vertices[v])
+		if o == 0 {
+			// The segment passes through v.
+			end = v
+			break
+		} else if o > 0 {
+			leftChain = append(leftChain, v)
+			left = v
+		} else {
+			rightChain = append(rightChain, v)
+			right = v
+		}
+	}
+	for _, t := range removed {
+		c.removeTriangle(t[0], t[1], t[2])
+	}
+	c.triangulatePseudoPolygon(a, end, leftChain)
+	c.triangulatePseudoPolygon(a, end, rightChain)
+	c.constraints[cdtUndirectedKey(a, end)]++
+	if end != b {
+		c.InsertConstraint(end, b)
+	}
+}
+
+// triangulatePseudoPolygon fills a polygon made up of a
+// base edge and a chain of vertices on one side of it.
+func (c *cdt) triangulatePseudoPolygon(a, b int, chain []int) {
+	if len(chain) == 0 {
+		return
+	}
+	vs := c.Vertices
+	best := 0
+	for i := 1; i < len(chain); i++ {
+		p1, p2, p3 := a, b, chain[best]
+		if c.orient(p1, p2, p3) < 0 {
+			p1, p2 = p2, p1
+		}
+		if inCircle(vs[p1], vs[p2], vs[p3], vs[chain[i]]) {
+			best = i
+		}
+	}
+	apex := chain[best]
+	if c.orient(a, b, apex) > 0 {
+		c.addTriangle(a, b, apex)
+	} else {
+		c.addTriangle(b, a, apex)
+	}
+	c.triangulatePseudoPolygon(a, apex, chain[:best])
+	c.triangulatePseudoPolygon(apex, b, chain[best+1:])
+}
+
+// RemoveExterior removes all triangles outside of the
+// constraints, using the even-odd rule.
+func (c *cdt) RemoveExterior() {
+	inside := map[[3]int]bool{}
+	visited := map[[3]int]bool{}
+
+	startC, _ := c.opposite(0, 1)
+	start := cdtTriangleKey(0, 1, startC)
+	visited[start] = true
+	queue := [][3]int{start}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for i := 0; i < 3; i++ {
+			u, v := t[i], t[(i+1)%3]
+			w, ok := c.opposite(v, u)
+			if !ok {
+				continue
+			}
+			next := cdtTriangleKey(v, u, w)
+			if _, ok := visited[next]; ok {
+				continue
+			}
+			visited[next] = true
+			inside[next] = inside[t] != c.isConstrained(u, v)
+			queue = append(queue, next)
+		}
+	}
+	for t := range visited {
+		if !inside[t] {
+			c.removeTriangle(t[0], t[1], t[2])
+		}
+	}
+
+	// Vertices may refer to removed triangles.
+	for i := range c.vertexEdge {
+		c.vertexEdge[i] = -1
+	}
+	for key := range c.edges {
+		c.vertexEdge[int(key>>32)] = int(uint32(key))
+	}
+}
+
+func cdtTriangleKey(a, b, d int) [3]int {
+	if b < a && b < d {
+		return [3]int{b, d, a}
+	} else if d < a && d < b {
+		return [3]int{d, a, b}
+	}
+	return [3]int{a, b, d}
+}
+
+// SplitEncroached splits boundary segments whose diametral
+// circles contain the opposite vertex of their triangle,
+// until no segments are encroached upon.
+//
+// Returns the number of added vertices.
+func (c *cdt) SplitEncroached(minLength float64, maxPoints int) int {
+	var numAdded int
+	for numAdded < maxPoints {
+		var encroached [][2]int
+		for key, count := range c.constraints {
+			if count%2 == 0 {
+				continue
+			}
+			u, v := int(key>>32), int(uint32(key))
+			if c.isEncroached(u, v) && c.Vertices[u].Dist(c.Vertices[v]) > minLength {
+				encroached = append(encroached, [2]int{u, v})
+			}
+		}
+		if len(encroached) == 0 {
+			break
+		}
+		sort.Slice(encroached, func(i, j int) bool {
+			s1, s2 := encroached[i], encroached[j]
+			return s1[0] < s2[0] || (s1[0] == s2[0] && s1[1] < s2[1])
+		})
+		for _, s := range encroached {
+			if numAdded >= maxPoints {
+				break
+			}
+			if c.splitSegment(s[0], s[1]) {
+				numAdded++
+			}
+		}
+	}
+	return numAdded
+}
+
+func (c *cdt) isEncroached(u, v int) bool {
+	for _, e := range [][2]int{{u, v}, {v, u}} {
+		if w, ok := c.opposite(e[0], e[1]); ok {
+			if c.inDiametralCircle(u, v, c.Vertices[w]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *cdt) inDiametralCircle(u, v int, p Coord) bool {
+	return c.Vertices[u].Sub(p).Dot(c.Vertices[v].Sub(p)) < 0
+}
+
+func (c *cdt) splitSegment(u, v int) bool {
+	if _, ok := c.constraints[cdtUndirectedKey(u, v)]; !ok {
+		return false
+	}
+	mid := c.Vertices[u].Mid(c.Vertices[v])
+	if _, ok := c.vertexIDs.Load(mid); ok {
+		return false
+	}
+	id := len(c.Vertices)
+	c.Vertices = append(c.Vertices, mid)
+	c.vertexEdge = append(c.vertexEdge, -1)
+	c.vertexIDs.Store(mid, id)
+	c.splitEdge(u, v, id)
+	return true
+}
+
+// InsertCircumcenter attempts to add the circumcenter of
+// a triangle, or splits the segments which it encroaches
+// upon instead.
+//
+// Returns the number of added vertices, and false if no
+// progress could be made.
+func (c *cdt) InsertCircumcenter(t [3]int, minLength float64) (int, bool) {
+	p1, p2, p3 := c.Vertices[t[0]], c.Vertices[t[1]], c.Vertices[t[2]]
+	center, ok := circumcenter(p1, p2, p3)
+	if !ok {
+		return 0, false
+	}
+	edge, outside := c.locate(center, t[0], t[1])
+	if edge[0] == -1 {
+		return 0, false
+	}
+	if outside {
+		if c.splitSegment(edge[0], edge[1]) {
+			return 1, true
+		}
+		return 0, false
+	}
+
+	// Find segments that the new vertex would encroach
+	// upon, which are on the boundary of the region that
+	// would be re-triangulated.
+	var encroached [][2]int
+	d, _ := c.opposite(edge[0], edge[1])
+	visited := map[[3]int]bool{cdtTriangleKey(edge[0], edge[1], d): true}
+	queue := [][3]int{{edge[0], edge[1], d}}
+	for len(queue) > 0 {
+		tri := queue[0]
+		queue = queue[1:]
+		for i := 0; i < 3; i++ {
+			u, v := tri[i], tri[(i+1)%3]
+			if c.isConstrained(u, v) {
+				if c.inDiametralCircle(u, v, center) {
+					encroached = append(encroached, [2]int{u, v})
+				}
+				continue
+			}
+			w, ok := c.opposite(v, u)
+			if !ok {
+				continue
+			}
+			key := cdtTriangleKey(v, u, w)
+			if visited[key] {
+				continue
+			}
+			vs := c.Vertices
+			if inCircle(vs[v], vs[u], vs[w], center) {
+				visited[key] = true
+				queue = append(queue, [3]int{v, u, w})
+			}
+		}
+	}
+	if len(encroached) > 0 {
+		var numAdded int
+		for _, s := range encroached {
+			if c.Vertices[s[0]].Dist(c.Vertices[s[1]]) > minLength && c.splitSegment(s[0], s[1]) {
+				numAdded++
+			}
+		}
+		return numAdded, numAdded > 0
+	}
+
+	tri := [3]Coord{c.Vertices[edge[0]], c.Vertices[edge[1]], c.Vertices[d]}
+	for _, p := range tri {
+		if p.Dist(center) < minLength {
+			return 0, false
+		}
+	}
+	c.insertAt(center, edge)
+	return 1, true
+}
+
+func circumcenter(p1, p2, p3 Coord) (Coord, bool) {
+	b := p2.Sub(p1)
+	d := p3.Sub(p1)
+	denom := 2 * det(b, d)
+	if denom == 0 {
+		return Coord{}, false
+	}
+	bn, dn := b.Dot(b), d.Dot(d)
+	return p1.Add(XY(d.Y*bn-b.Y*dn, b.X*dn-d.X*bn).Scale(1 / denom)), true
+}
+
+// Result gets the used vertices and the clockwise
+// triangles.
+func (c *cdt) Result() ([]Coord, [][3]int) {
+	mapping := map[int]int{}
+	var vertices []Coord
+	var tris [][3]int
+	c.IterateTriangles(func(t [3]int) {
+		var tri [3]int
+		for i, v := range t {
+			if id, ok := mapping[v]; ok {
+				tri[i] = id
+			} else {
+				mapping[v] = len(vertices)
+				tri[i] = len(vertices)
+				vertices = append(vertices, c.Vertices[v])
+			}
+		}
+		tri[1], tri[2] = tri[2], tri[1]
+		tris = append(tris, tri)
+	})
+	return vertices, tris
+}
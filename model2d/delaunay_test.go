@@ -0,0 +1,173 @@
+package model2d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTriangulateMeshDelaunay(t *testing.T) {
+	t.Run("Square", func(t *testing.T) {
+		mesh := NewMeshRect(XY(0, 0), XY(1, 1))
+		tris := TriangulateMeshDelaunay(mesh)
+		if len(tris) != 2 {
+			t.Errorf("expected 2 triangles but got %d", len(tris))
+		}
+		checkDelaunayTriangulation(t, mesh, tris)
+	})
+	t.Run("Hole", func(t *testing.T) {
+		mesh := NewMeshRect(XY(0, 0), XY(3, 3))
+		mesh.AddMesh(NewMeshRect(XY(1, 1), XY(2, 2)).Invert())
+		tris := TriangulateMeshDelaunay(mesh)
+		if len(tris) != 8 {
+			t.Errorf("expected 8 triangles but got %d", len(tris))
+		}
+		checkDelaunayTriangulation(t, mesh, tris)
+	})
+	t.Run("Circles", func(t *testing.T) {
+		mesh := NewMeshPolar(func(theta float64) float64 {
+			return 2 + math.Cos(theta*5)
+		}, 200)
+		mesh.AddMesh(NewMeshPolar(func(float64) float64 { return 0.5 }, 50).Invert())
+		tris := TriangulateMeshDelaunay(mesh)
+		checkDelaunayTriangulation(t, mesh, tris)
+		checkConstrainedDelaunay(t, tris)
+	})
+	t.Run("Colinear", func(t *testing.T) {
+		// A vertex in the middle of one of the edges, and a
+		// Steiner point on another edge.
+		mesh := NewMesh()
+		points := []Coord{XY(0, 0), XY(0, 1), XY(0, 2), XY(2, 2), XY(2, 0)}
+		for i, p := range points {
+			mesh.Add(&Segment{p, points[(i+1)%len(points)]})
+		}
+		triangulator := &DelaunayTriangulator{
+			Points: []Coord{XY(1, 0), XY(1, 1), XY(5, 5)},
+		}
+		vertices, tris := triangulator.TriangulateIndexed(mesh)
+		if len(vertices) != 7 {
+			t.Errorf("expected 7 vertices but got %d", len(vertices))
+		}
+		checkDelaunayTriangulation(t, mesh, indexedToTriangles(vertices, tris))
+	})
+}
+
+func TestDelaunayTriangulatorRefine(t *testing.T) {
+	mesh := NewMesh()
+	points := []Coord{XY(0, 0), XY(0, 1), XY(10, 1), XY(10, 0), XY(5, 0.1)}
+	for i, p := range points {
+		mesh.Add(&Segment{p, points[(i+1)%len(points)]})
+	}
+	mesh.AddMesh(NewMeshPolar(func(float64) float64 { return 0.2 }, 10).Translate(XY(2, 0.5)).Invert())
+
+	minAngle := 25 * math.Pi / 180
+	triangulator := &DelaunayTriangulator{MinAngle: minAngle, MaxArea: 0.05}
+	tris := triangulator.Triangulate(mesh)
+	checkDelaunayTriangulation(t, mesh, tris)
+	checkConstrainedDelaunay(t, tris)
+	for _, tri := range tris {
+		if a := triangleArea(tri); a > 0.05+1e-8 {
+			t.Errorf("triangle area %f is too large", a)
+		}
+		if a := triangleMinAngle(tri); a < minAngle-1e-8 {
+			t.Errorf("triangle angle %f is too small", a*180/math.Pi)
+		}
+	}
+}
+
+func checkDelaunayTriangulation(t *testing.T, m *Mesh, tris [][3]Coord) {
+	t.Helper()
+	var area float64
+	for _, tri := range tris {
+		a := triangleArea(tri)
+		if a <= 0 {
+			t.Errorf("triangle is not clockwise: %v", tri)
+		}
+		area += a
+	}
+	if math.Abs(area-m.Area()) > 1e-8 {
+		t.Errorf("expected area %f but got %f", m.Area(), area)
+	}
+
+	// Every boundary segment should be an edge of a
+	// triangle, possibly after being split.
+	edges := map[Segment]bool{}
+	for _, tri := range tris {
+		for i := 0; i < 3; i++ {
+			edges[Segment{tri[i], tri[(i+1)%3]}] = true
+		}
+	}
+	var boundaryLength float64
+	for _, tri := range tris {
+		for i := 0; i < 3; i++ {
+			s := Segment{tri[i], tri[(i+1)%3]}
+			if !edges[Segment{s[1], s[0]}] {
+				boundaryLength += s.Length()
+				mid := s.Mid()
+				found := false
+				m.Iterate(func(seg *Segment) {
+					if seg.Dist(mid) < 1e-8 {
+						found = true
+					}
+				})
+				if !found {
+					t.Errorf("unexpected boundary edge: %v", s)
+				}
+			}
+		}
+	}
+	var expectedLength float64
+	m.Iterate(func(s *Segment) {
+		expectedLength += s.Length()
+	})
+	if math.Abs(boundaryLength-expectedLength) > 1e-8 {
+		t.Errorf("expected boundary length %f but got %f", expectedLength, boundaryLength)
+	}
+}
+
+// checkConstrainedDelaunay checks that no triangle's
+// circumcircle contains the far vertex of a neighboring
+// triangle, unless the edge between them is a boundary.
+func checkConstrainedDelaunay(t *testing.T, tris [][3]Coord) {
+	t.Helper()
+	opposite := map[Segment]Coord{}
+	for _, tri := range tris {
+		for i := 0; i < 3; i++ {
+			opposite[Segment{tri[i], tri[(i+1)%3]}] = tri[(i+2)%3]
+		}
+	}
+	for _, tri := range tris {
+		center, _ := circumcenter(tri[0], tri[1], tri[2])
+		radius := center.Dist(tri[0])
+		for i := 0; i < 3; i++ {
+			if p, ok := opposite[Segment{tri[(i+1)%3], tri[i]}]; ok {
+				if center.Dist(p) < radius*(1-1e-8) {
+					t.Errorf("triangle %v is not Delaunay", tri)
+					return
+				}
+			}
+		}
+	}
+}
+
+func indexedToTriangles(vertices []Coord, tris [][3]int) [][3]Coord {
+	var result [][3]Coord
+	for _, t := range tris {
+		result = append(result, [3]Coord{vertices[t[0]], vertices[t[1]], vertices[t[2]]})
+	}
+	return result
+}
+
+// triangleArea computes the area of a clockwise triangle.
+func triangleArea(t [3]Coord) float64 {
+	return -det(t[1].Sub(t[0]), t[2].Sub(t[0])) / 2
+}
+
+func triangleMinAngle(t [3]Coord) float64 {
+	result := math.Inf(1)
+	for i := 0; i < 3; i++ {
+		v1 := t[(i+1)%3].Sub(t[i]).Normalize()
+		v2 := t[(i+2)%3].Sub(t[i]).Normalize()
+		result = math.Min(result, math.Acos(math.Max(-1, math.Min(1, v1.Dot(v2)))))
+	}
+	return result
+}
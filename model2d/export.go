@@ -3,6 +3,8 @@ package model2d
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"sort"
 
 	"github.com/unixpickle/model3d/fileformats"
 )
@@ -143,6 +145,48 @@ func EncodeCustomPathSVG(meshes []*Mesh, fillColors []string, strokeColors []str
 	return result.Bytes()
 }
 
+// EncodeDXF encodes the mesh as a DXF file.
+func EncodeDXF(m *Mesh) []byte {
+	var buf bytes.Buffer
+	WriteDXF(&buf, m)
+	return buf.Bytes()
+}
+
+// WriteDXF writes the mesh as a DXF file, where connected
+// segments are stored as polylines in the default layer.
+func WriteDXF(w io.Writer, m *Mesh) error {
+	return WriteDXFLayers(w, map[string]*Mesh{"0": m})
+}
+
+// WriteDXFLayers writes multiple meshes to a DXF file,
+// with each mesh in a different layer.
+//
+// This is useful for laser cutting, where different
+// layers may be cut or engraved with different settings.
+func WriteDXFLayers(w io.Writer, layers map[string]*Mesh) error {
+	var names []string
+	for name := range layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	f := &fileformats.DXFFile{}
+	for _, name := range names {
+		findPolylines(layers[name], func(points []Coord) {
+			poly := &fileformats.DXFPolyline{Layer: name}
+			if len(points) > 2 && points[0] == points[len(points)-1] {
+				poly.Closed = true
+				points = points[:len(points)-1]
+			}
+			for _, p := range points {
+				poly.Points = append(poly.Points, p.Array())
+			}
+			f.Polylines = append(f.Polylines, poly)
+		})
+	}
+	return f.Write(w)
+}
+
 // findPolylines finds sequences of connected segments and
 // calls f for each one.
 //
@@ -1,6 +1,12 @@
 package model2d
 
-import "testing"
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/fileformats"
+)
 
 func TestFindPolyline(t *testing.T) {
 	meshes := []*Mesh{
@@ -63,3 +69,121 @@ func TestEncodeCSV(t *testing.T) {
 		mesh.Remove(result[0])
 	}
 }
+
+func TestEncodeDXF(t *testing.T) {
+	mesh := NewMeshPolar(func(theta float64) float64 {
+		return 1 + 0.3*math.Cos(theta*3)
+	}, 30)
+	mesh.AddMesh(NewMeshRect(XY(-0.2, -0.2), XY(0.2, 0.2)).Invert())
+	mesh.Add(&Segment{XY(3, 3), XY(4, 3)})
+
+	decoded, err := ReadDXF(bytes.NewReader(EncodeDXF(mesh)), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meshesEqual(mesh, decoded) {
+		t.Error("decoded mesh does not match original")
+	}
+}
+
+func TestDXFCurves(t *testing.T) {
+	f := &fileformats.DXFFile{
+		Polylines: []*fileformats.DXFPolyline{
+			{Points: [][2]float64{{0, 0}, {2, 0}}, Bulges: []float64{1, 0}},
+		},
+		Arcs: []*fileformats.DXFArc{
+			{Layer: "arcs", Center: [2]float64{1, 1}, Radius: 2, StartAngle: 270, EndAngle: 90},
+		},
+		Circles: []*fileformats.DXFCircle{
+			{Layer: "arcs", Center: [2]float64{-1, 1}, Radius: 0.5},
+		},
+		Splines: []*fileformats.DXFSpline{
+			{
+				// A quarter circle as a rational quadratic.
+				Layer:         "splines",
+				Degree:        2,
+				Knots:         []float64{0, 0, 0, 1, 1, 1},
+				ControlPoints: [][2]float64{{1, 0}, {1, 1}, {0, 1}},
+				Weights:       []float64{1, math.Sqrt2 / 2, 1},
+			},
+			{
+				// A cubic with an interior knot.
+				Layer:         "splines",
+				Degree:        3,
+				Knots:         []float64{0, 0, 0, 0, 0.5, 1, 1, 1, 1},
+				ControlPoints: [][2]float64{{0, 0}, {1, 2}, {2, -1}, {3, 2}, {4, 0}},
+			},
+		},
+	}
+	curves := DXFCurves(f)
+	if len(curves["0"]) != 1 || len(curves["arcs"]) != 2 || len(curves["splines"]) != 2 {
+		t.Fatalf("unexpected curves: %v", curves)
+	}
+
+	checkPoint := func(name string, actual, expected Coord) {
+		if actual.Dist(expected) > 1e-8 {
+			t.Errorf("%s: expected %v but got %v", name, expected, actual)
+		}
+	}
+
+	// A bulge of 1 is a counter-clockwise semicircle.
+	bulge := curves["0"][0]
+	checkPoint("bulge start", bulge.Eval(0), XY(0, 0))
+	checkPoint("bulge middle", bulge.Eval(0.5), XY(1, -1))
+	checkPoint("bulge end", bulge.Eval(1), XY(2, 0))
+
+	arc := curves["arcs"][0]
+	checkPoint("arc start", arc.Eval(0), XY(1, -1))
+	checkPoint("arc middle", arc.Eval(0.5), XY(3, 1))
+	checkPoint("arc end", arc.Eval(1), XY(1, 3))
+
+	circle := curves["arcs"][1]
+	checkPoint("circle end", circle.Eval(1), circle.Eval(0))
+	for i := 0; i < 10; i++ {
+		c := circle.Eval(float64(i) / 10)
+		if math.Abs(c.Dist(XY(-1, 1))-0.5) > 1e-8 {
+			t.Errorf("circle point %v has incorrect radius", c)
+		}
+	}
+
+	quarter := curves["splines"][0]
+	for i := 0; i <= 10; i++ {
+		c := quarter.Eval(float64(i) / 10)
+		if math.Abs(c.Norm()-1) > 1e-8 {
+			t.Errorf("rational spline point %v is not on the unit circle", c)
+		}
+	}
+	checkPoint("rational spline end", quarter.Eval(1), XY(0, 1))
+
+	// Compare the cubic to the Cox-de Boor recursion.
+	cubic := curves["splines"][1]
+	spline := f.Splines[1]
+	var basis func(i, p int, u float64) float64
+	basis = func(i, p int, u float64) float64 {
+		k := spline.Knots
+		if p == 0 {
+			if k[i] <= u && u < k[i+1] {
+				return 1
+			}
+			return 0
+		}
+		var res float64
+		if k[i+p] > k[i] {
+			res += (u - k[i]) / (k[i+p] - k[i]) * basis(i, p-1, u)
+		}
+		if k[i+p+1] > k[i+1] {
+			res += (k[i+p+1] - u) / (k[i+p+1] - k[i+1]) * basis(i+1, p-1, u)
+		}
+		return res
+	}
+	for _, u := range []float64{0, 0.1, 0.3, 0.5, 0.6, 0.9} {
+		var expected Coord
+		for i, c := range spline.ControlPoints {
+			expected = expected.Add(NewCoordArray(c).Scale(basis(i, 3, u)))
+		}
+		// Each knot span takes up half of the curve, and
+		// the spans have the same length in knot space.
+		checkPoint("cubic spline", cubic.Eval(u), expected)
+	}
+	checkPoint("cubic spline end", cubic.Eval(1), XY(4, 0))
+}
@@ -0,0 +1,180 @@
+// Generated from templates/grid_sdf.template
+
+package model2d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/numerical"
+)
+
+// A BitmapSDF is an SDF computed from a Bitmap using
+// an exact Euclidean distance transform.
+//
+// Distances are computed between pixel centers, where a
+// true pixel is half a pixel inside the boundary if it
+// touches a false pixel, and SDF values are bilinearly
+// interpolated between pixel centers.
+//
+// Like BitmapToSolid, the bitmap occupies the
+// rectangle from the origin to (Width, Height).
+// Pixels outside of the bitmap are false.
+type BitmapSDF struct {
+	// Size of the bitmap along each axis.
+	size [2]int
+
+	// Values and surface points at pixel centers, padded
+	// by one pixel on each side.
+	values  []float64
+	surface []Coord
+}
+
+// BitmapToSDF computes the SDF of a bitmap.
+func BitmapToSDF(g *Bitmap) *BitmapSDF {
+	res := &BitmapSDF{
+		size: [2]int{g.Width, g.Height},
+	}
+	padded := res.paddedSize()
+	total := 1
+	for _, s := range padded {
+		total *= s
+	}
+
+	inside := make([]bool, total)
+	outside := make([]bool, total)
+	for i := range inside {
+		p := res.paddedCoords(i)
+		inside[i] = g.Get(p[0]-1, p[1]-1)
+		outside[i] = !inside[i]
+	}
+	shape := padded[:]
+	outsideDists, outsideNearest := numerical.DistanceTransform(shape, outside)
+	insideDists, insideNearest := numerical.DistanceTransform(shape, inside)
+
+	res.values = make([]float64, total)
+	res.surface = make([]Coord, total)
+	center := func(idx int) Coord {
+		var arr [2]float64
+		for axis, x := range res.paddedCoords(idx) {
+			arr[axis] = float64(x) - 0.5
+		}
+		return NewCoordArray(arr)
+	}
+	for i, in := range inside {
+		sign := 1.0
+		dist, nearest := outsideDists[i], outsideNearest[i]
+		if !in {
+			sign = -1
+			dist, nearest = insideDists[i], insideNearest[i]
+		}
+		c := center(i)
+		if nearest == -1 {
+			// The bitmap is empty.
+			var sizeArr [2]float64
+			for axis, s := range padded {
+				sizeArr[axis] = float64(s)
+			}
+			res.values[i] = -NewCoordArray(sizeArr).Norm()
+			res.surface[i] = c
+			continue
+		}
+		dist = math.Sqrt(dist)
+		res.values[i] = sign * (dist - 0.5)
+		res.surface[i] = center(nearest).Add(c.Sub(center(nearest)).Scale(0.5 / dist))
+	}
+	return res
+}
+
+// Min gets the minimum of the bitmap's bounds.
+func (g *BitmapSDF) Min() Coord {
+	return Coord{}
+}
+
+// Max gets the maximum of the bitmap's bounds.
+func (g *BitmapSDF) Max() Coord {
+	var arr [2]float64
+	for axis, s := range g.size {
+		arr[axis] = float64(s)
+	}
+	return NewCoordArray(arr)
+}
+
+// SDF computes the interpolated SDF at a point.
+//
+// Outside of the bitmap, the SDF is extrapolated as a
+// lower bound on the true SDF.
+func (g *BitmapSDF) SDF(c Coord) float64 {
+	_, res := g.interp(c, false)
+	return res
+}
+
+// PointSDF computes the interpolated SDF at a point, along
+// with the nearest surface point of the surrounding pixel
+// centers.
+func (g *BitmapSDF) PointSDF(c Coord) (Coord, float64) {
+	return g.interp(c, true)
+}
+
+func (g *BitmapSDF) paddedSize() [2]int {
+	var res [2]int
+	for axis, s := range g.size {
+		res[axis] = s + 2
+	}
+	return res
+}
+
+// paddedCoords converts a flat index into the padded grid
+// into coordinates, where the first axis varies fastest.
+func (g *BitmapSDF) paddedCoords(idx int) [2]int {
+	var res [2]int
+	for axis, s := range g.paddedSize() {
+		res[axis] = idx % s
+		idx /= s
+	}
+	return res
+}
+
+func (g *BitmapSDF) interp(c Coord, needPoint bool) (Coord, float64) {
+	padded := g.paddedSize()
+	var maxArr [2]float64
+	for axis, s := range padded {
+		maxArr[axis] = float64(s - 1)
+	}
+	gridPoint := c.AddScalar(0.5)
+	clamped := gridPoint.Max(Coord{}).Min(NewCoordArray(maxArr))
+	extra := gridPoint.Dist(clamped)
+
+	var start [2]int
+	var frac [2]float64
+	for axis, x := range clamped.Array() {
+		start[axis] = int(math.Min(math.Floor(x), float64(padded[axis]-2)))
+		frac[axis] = x - float64(start[axis])
+	}
+
+	var value float64
+	var point Coord
+	closest := math.Inf(1)
+	for corner := 0; corner < 1<<2; corner++ {
+		weight := 1.0
+		idx := 0
+		stride := 1
+		for axis := range start {
+			offset := (corner >> axis) & 1
+			if offset == 0 {
+				weight *= 1 - frac[axis]
+			} else {
+				weight *= frac[axis]
+			}
+			idx += (start[axis] + offset) * stride
+			stride *= padded[axis]
+		}
+		value += weight * g.values[idx]
+		if needPoint {
+			if d := g.surface[idx].Dist(c); d < closest {
+				closest = d
+				point = g.surface[idx]
+			}
+		}
+	}
+	return point, value - extra
+}
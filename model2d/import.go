@@ -3,6 +3,7 @@ package model2d
 import (
 	"bytes"
 	"io"
+	"math"
 
 	"github.com/unixpickle/model3d/fileformats"
 )
@@ -22,3 +23,240 @@ func DecodeCSV(data []byte) ([]*Segment, error) {
 	}
 	return res, nil
 }
+
+// ReadDXF decodes the 2D entities of a DXF file as a mesh,
+// combining all of the layers.
+//
+// Arcs, circles, bulged polyline segments, and splines are
+// approximated with curveSegments segments each.
+//
+// DXF files do not specify the orientation of closed
+// shapes, so the resulting segments may need to be
+// re-oriented with RepairNormals.
+func ReadDXF(r io.Reader, curveSegments int) (*Mesh, error) {
+	layers, err := ReadDXFLayers(r, curveSegments)
+	if err != nil {
+		return nil, err
+	}
+	result := NewMesh()
+	for _, m := range layers {
+		result.AddMesh(m)
+	}
+	return result, nil
+}
+
+// ReadDXFLayers is like ReadDXF, but returns a separate
+// mesh for each layer.
+func ReadDXFLayers(r io.Reader, curveSegments int) (map[string]*Mesh, error) {
+	f, err := fileformats.ReadDXFFile(r)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]*Mesh{}
+	for layer, curves := range DXFCurves(f) {
+		m := NewMesh()
+		for _, c := range curves {
+			n := curveSegments
+			if b, ok := c.(BezierCurve); ok && len(b) == 2 {
+				n = 1
+			}
+			m.AddMesh(CurveMesh(c, n))
+		}
+		result[layer] = m
+	}
+	return result, nil
+}
+
+// DXFCurves converts the 2D entities of a DXF file into
+// curves, grouped by layer.
+//
+// Lines and straight polyline segments become linear
+// BezierCurves, arcs become ArcCurves, and splines are
+// split into BezierCurves at their knots.
+// Rational splines are split into rational Bezier curves,
+// which are implemented as FuncCurves.
+func DXFCurves(f *fileformats.DXFFile) map[string][]Curve {
+	result := map[string][]Curve{}
+	add := func(layer string, c Curve) {
+		if layer == "" {
+			layer = "0"
+		}
+		result[layer] = append(result[layer], c)
+	}
+	for _, l := range f.Lines {
+		add(l.Layer, BezierCurve{NewCoordArray(l.Start), NewCoordArray(l.End)})
+	}
+	for _, p := range f.Polylines {
+		for i, p1 := range p.Points {
+			if i+1 == len(p.Points) && !p.Closed {
+				break
+			}
+			p2 := p.Points[(i+1)%len(p.Points)]
+			var bulge float64
+			if p.Bulges != nil {
+				bulge = p.Bulges[i]
+			}
+			add(p.Layer, dxfBulgeCurve(NewCoordArray(p1), NewCoordArray(p2), bulge))
+		}
+	}
+	for _, a := range f.Arcs {
+		add(a.Layer, dxfArcCurve(NewCoordArray(a.Center), a.Radius, a.StartAngle, a.EndAngle))
+	}
+	for _, c := range f.Circles {
+		add(c.Layer, dxfArcCurve(NewCoordArray(c.Center), c.Radius, 0, 360))
+	}
+	for _, s := range f.Splines {
+		if c := dxfSplineCurve(s); c != nil {
+			add(s.Layer, c)
+		}
+	}
+	return result
+}
+
+func dxfBulgeCurve(p1, p2 Coord, bulge float64) Curve {
+	if bulge == 0 || p1 == p2 {
+		return BezierCurve{p1, p2}
+	}
+	// The included angle is 4*atan(bulge), and the chord
+	// is 2*r*sin(angle/2).
+	angle := 4 * math.Atan(math.Abs(bulge))
+	radius := p1.Dist(p2) / (2 * math.Sin(angle/2))
+	return NewArcCurve(XY(radius, radius), p1, p2, 0, angle > math.Pi, bulge > 0)
+}
+
+func dxfArcCurve(center Coord, radius, startAngle, endAngle float64) Curve {
+	start := startAngle * math.Pi / 180
+	sweep := math.Mod(endAngle-startAngle, 360)
+	if sweep <= 0 {
+		sweep += 360
+	}
+	sweep *= math.Pi / 180
+	point := func(theta float64) Coord {
+		return center.Add(XY(math.Cos(theta), math.Sin(theta)).Scale(radius))
+	}
+	radii := XY(radius, radius)
+	p1 := point(start)
+	if sweep > math.Pi {
+		// Arcs cannot be full circles, so large arcs are
+		// split in two.
+		mid := point(start + sweep/2)
+		p2 := point(start + sweep)
+		if math.Abs(sweep-2*math.Pi) < 1e-8 {
+			p2 = p1
+		}
+		return JoinedCurve{
+			NewArcCurve(radii, p1, mid, 0, false, true),
+			NewArcCurve(radii, mid, p2, 0, false, true),
+		}
+	}
+	return NewArcCurve(radii, p1, point(start+sweep), 0, false, true)
+}
+
+// dxfSplineCurve converts a B-spline into a sequence of
+// Bezier curves, one per knot span.
+//
+// If the spline only has fit points, then the fit points
+// are connected with straight lines.
+func dxfSplineCurve(s *fileformats.DXFSpline) Curve {
+	if len(s.ControlPoints) == 0 {
+		var result JoinedCurve
+		for i := 1; i < len(s.FitPoints); i++ {
+			result = append(result, BezierCurve{
+				NewCoordArray(s.FitPoints[i-1]),
+				NewCoordArray(s.FitPoints[i]),
+			})
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	}
+	p := s.Degree
+	n := len(s.ControlPoints)
+	if p < 1 || len(s.Knots) != n+p+1 {
+		return nil
+	}
+
+	// Use homogeneous coordinates to support rational
+	// splines.
+	rational := false
+	points := make([][3]float64, n)
+	for i, c := range s.ControlPoints {
+		w := 1.0
+		if s.Weights != nil {
+			w = s.Weights[i]
+			if w != s.Weights[0] {
+				rational = true
+			}
+		}
+		points[i] = [3]float64{c[0] * w, c[1] * w, w}
+	}
+
+	var result JoinedCurve
+	for k := p; k < n; k++ {
+		a, b := s.Knots[k], s.Knots[k+1]
+		if a >= b {
+			continue
+		}
+		bezier := make([][3]float64, p+1)
+		args := make([]float64, p)
+		for j := 0; j <= p; j++ {
+			for i := range args {
+				if i < p-j {
+					args[i] = a
+				} else {
+					args[i] = b
+				}
+			}
+			bezier[j] = dxfBlossom(points, s.Knots, p, k, args)
+		}
+		if rational {
+			result = append(result, rationalBezier(bezier))
+		} else {
+			curve := make(BezierCurve, len(bezier))
+			for i, h := range bezier {
+				curve[i] = XY(h[0]/h[2], h[1]/h[2])
+			}
+			result = append(result, curve)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// dxfBlossom evaluates the blossom of a B-spline within the
+// knot span starting at knot k, using de Boor's algorithm
+// with a different argument at each level.
+func dxfBlossom(points [][3]float64, knots []float64, p, k int, args []float64) [3]float64 {
+	d := make([][3]float64, p+1)
+	copy(d, points[k-p:k+1])
+	for r := 1; r <= p; r++ {
+		u := args[r-1]
+		for j := p; j >= r; j-- {
+			i := k - p + j
+			alpha := (u - knots[i]) / (knots[i+p+1-r] - knots[i])
+			for axis := 0; axis < 3; axis++ {
+				d[j][axis] = (1-alpha)*d[j-1][axis] + alpha*d[j][axis]
+			}
+		}
+	}
+	return d[p]
+}
+
+// rationalBezier creates a curve for a Bezier curve with
+// homogeneous control points.
+func rationalBezier(points [][3]float64) FuncCurve {
+	return func(t float64) Coord {
+		d := append([][3]float64{}, points...)
+		for r := 1; r < len(d); r++ {
+			for j := 0; j < len(d)-r; j++ {
+				for axis := 0; axis < 3; axis++ {
+					d[j][axis] = (1-t)*d[j][axis] + t*d[j+1][axis]
+				}
+			}
+		}
+		return XY(d[0][0]/d[0][2], d[0][1]/d[0][2])
+	}
+}
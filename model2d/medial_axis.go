@@ -1,10 +1,17 @@
 package model2d
 
-import "math"
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/essentials"
+)
 
 const (
 	DefaultMedialAxisIters = 32
 	DefaultMedialAxisEps   = 1e-8
+
+	DefaultMedialAxisSpacingFraction = 1.0 / 256
 )
 
 // ProjectMedialAxis projects the point c onto the medial
@@ -64,3 +71,851 @@ func ProjectMedialAxis(p PointSDF, c Coord, iters int, eps float64) Coord {
 	// crossing the medial axis.
 	return minPoint
 }
+
+// A MedialAxisSite is a feature of a polygon's boundary,
+// either a segment or a reflex vertex, which generates part
+// of the medial axis.
+type MedialAxisSite struct {
+	// Segment is the segment for segment sites, or nil for
+	// vertex sites.
+	Segment *Segment
+
+	// Vertex is the location of a vertex site.
+	Vertex Coord
+}
+
+// Dist computes the distance from c to the site.
+func (m *MedialAxisSite) Dist(c Coord) float64 {
+	if m.Segment != nil {
+		return m.Segment.Dist(c)
+	}
+	return m.Vertex.Dist(c)
+}
+
+// lineDist computes the signed distance from c to the line
+// containing a segment site, which is positive inside the
+// polygon, along with the gradient of this distance.
+//
+// For vertex sites, this is the regular distance.
+func (m *MedialAxisSite) lineDist(c Coord) (float64, Coord) {
+	if m.Segment != nil {
+		n := m.Segment.Normal().Scale(-1)
+		return n.Dot(c.Sub(m.Segment[0])), n
+	}
+	d := c.Sub(m.Vertex)
+	norm := d.Norm()
+	if norm == 0 {
+		return 0, Coord{}
+	}
+	return norm, d.Scale(1 / norm)
+}
+
+// A MedialAxisEdge is a piece of the medial axis which is
+// equidistant to two sites.
+//
+// If one site is a segment and the other is a vertex, the
+// edge is a parabolic arc. Otherwise, it is a straight
+// line segment.
+type MedialAxisEdge struct {
+	Sites [2]*MedialAxisSite
+
+	// P1 and P2 are the endpoints of the edge.
+	P1 Coord
+	P2 Coord
+
+	// R1 and R2 are the radii of the maximal inscribed
+	// circles at the endpoints, i.e. the distances to the
+	// boundary.
+	R1 float64
+	R2 float64
+}
+
+// Parabolic returns true if the edge is a parabolic arc.
+func (m *MedialAxisEdge) Parabolic() bool {
+	return (m.Sites[0].Segment == nil) != (m.Sites[1].Segment == nil)
+}
+
+// Eval computes a point along the edge and its radius,
+// where t ranges from 0 (at P1) to 1 (at P2).
+//
+// For parabolic arcs, t is proportional to the distance
+// along the directrix rather than along the arc.
+func (m *MedialAxisEdge) Eval(t float64) (Coord, float64) {
+	if !m.Parabolic() {
+		p := m.P1.Scale(1 - t).Add(m.P2.Scale(t))
+		return p, m.Sites[0].Dist(p)
+	}
+	seg, focus := m.Sites[0].Segment, m.Sites[1].Vertex
+	if seg == nil {
+		seg, focus = m.Sites[1].Segment, m.Sites[0].Vertex
+	}
+	return parabolaPoint(seg, focus, m.P1, m.P2, t)
+}
+
+// parabolaPoint evaluates the parabola with a focus and a
+// directrix, parameterized by the projection of the points
+// onto the directrix.
+func parabolaPoint(directrix *Segment, focus, p1, p2 Coord, t float64) (Coord, float64) {
+	origin := directrix[0]
+	dir := directrix[1].Sub(origin).Normalize()
+	normal := directrix.Normal().Scale(-1)
+	s1 := dir.Dot(p1.Sub(origin))
+	s2 := dir.Dot(p2.Sub(origin))
+	s := s1*(1-t) + s2*t
+	sFocus := dir.Dot(focus.Sub(origin))
+	h := normal.Dot(focus.Sub(origin))
+	r := ((s-sFocus)*(s-sFocus) + h*h) / (2 * h)
+	return origin.Add(dir.Scale(s)).Add(normal.Scale(r)), r
+}
+
+// Polyline approximates the edge with a series of points,
+// using n segments for parabolic arcs.
+func (m *MedialAxisEdge) Polyline(n int) []Coord {
+	if !m.Parabolic() {
+		return []Coord{m.P1, m.P2}
+	}
+	result := make([]Coord, n+1)
+	for i := 0; i <= n; i++ {
+		result[i], _ = m.Eval(float64(i) / float64(n))
+	}
+	result[0], result[n] = m.P1, m.P2
+	return result
+}
+
+// Length approximates the length of the edge.
+func (m *MedialAxisEdge) Length() float64 {
+	var result float64
+	points := m.Polyline(32)
+	for i := 1; i < len(points); i++ {
+		result += points[i].Dist(points[i-1])
+	}
+	return result
+}
+
+// A MedialAxis is a graph of edges making up the medial
+// axis of a polygon.
+//
+// Edges which meet at a vertex of the graph share the same
+// endpoint exactly.
+type MedialAxis struct {
+	Edges []*MedialAxisEdge
+}
+
+// NewMedialAxis computes the medial axis of the region
+// inside of a mesh.
+//
+// The mesh should be manifold, with no self-intersections,
+// and with normals facing outwards. It may contain holes.
+//
+// The topology of the medial axis is found from a Voronoi
+// diagram of points sampled along the boundary with the
+// given spacing. Each edge is then computed exactly from
+// its two sites, and each junction is solved for with
+// Newton's method. If the spacing is too large, very short
+// edges may be missed or slightly misplaced.
+//
+// If spacing is 0, a small fraction of the size of the
+// mesh is used.
+func NewMedialAxis(m *Mesh, spacing float64) *MedialAxis {
+	if m.NumSegments() == 0 {
+		return &MedialAxis{}
+	}
+	if spacing == 0 {
+		spacing = m.Max().Sub(m.Min()).MaxCoord() * DefaultMedialAxisSpacingFraction
+	}
+	b := newMedialAxisBuilder(m, spacing)
+	return b.Build()
+}
+
+// Mesh creates a mesh of segments along the medial axis,
+// approximating each parabolic arc with arcSegments
+// segments.
+func (m *MedialAxis) Mesh(arcSegments int) *Mesh {
+	result := NewMesh()
+	for _, e := range m.Edges {
+		points := e.Polyline(arcSegments)
+		for i := 1; i < len(points); i++ {
+			if points[i-1] != points[i] {
+				result.Add(&Segment{points[i-1], points[i]})
+			}
+		}
+	}
+	return result
+}
+
+// Prune removes spurious branches from the medial axis,
+// such as those caused by small bumps or by the vertices of
+// a polygon approximating a smooth curve.
+//
+// A branch is a path of edges from a leaf of the graph to
+// a junction. A branch is removed if its erosion thickness
+// is less than minThickness, where the erosion thickness
+// is the length of the branch minus the increase in radius
+// from the leaf to the junction.
+// For example, a branch ending at a right-angle corner has
+// an erosion thickness of (sqrt(2)-1) times the radius at
+// its junction.
+//
+// Branches are removed repeatedly until no more branches
+// can be removed, but paths between two leaves are never
+// removed entirely. If every branch at a junction is thin,
+// the two thickest branches are kept.
+//
+// Edges with zero length are removed before pruning.
+func (m *MedialAxis) Prune(minThickness float64) {
+	var nonEmpty []*MedialAxisEdge
+	for _, e := range m.Edges {
+		if e.P1 != e.P2 {
+			nonEmpty = append(nonEmpty, e)
+		}
+	}
+	m.Edges = nonEmpty
+
+	type prunedBranch struct {
+		Edges     []*MedialAxisEdge
+		Thickness float64
+	}
+	for {
+		degrees := map[Coord]int{}
+		edgesAt := map[Coord][]*MedialAxisEdge{}
+		for _, e := range m.Edges {
+			for _, p := range []Coord{e.P1, e.P2} {
+				degrees[p]++
+				edgesAt[p] = append(edgesAt[p], e)
+			}
+		}
+		junctionBranches := map[Coord][]prunedBranch{}
+		var junctions []Coord
+		for _, e := range m.Edges {
+			for i, leaf := range []Coord{e.P1, e.P2} {
+				if degrees[leaf] != 1 {
+					continue
+				}
+				branch, junction, innerRadius, ok := m.followBranch(e, i, degrees, edgesAt)
+				if !ok {
+					continue
+				}
+				var length float64
+				for _, b := range branch {
+					length += b.Length()
+				}
+				leafRadius := e.R1
+				if i == 1 {
+					leafRadius = e.R2
+				}
+				thickness := length - (innerRadius - leafRadius)
+				if thickness < minThickness {
+					if _, ok := junctionBranches[junction]; !ok {
+						junctions = append(junctions, junction)
+					}
+					junctionBranches[junction] = append(junctionBranches[junction],
+						prunedBranch{Edges: branch, Thickness: thickness})
+				}
+			}
+		}
+
+		removed := map[*MedialAxisEdge]bool{}
+		for _, junction := range junctions {
+			branches := junctionBranches[junction]
+			if len(branches) >= degrees[junction] {
+				// Keep a path through the junction.
+				sort.SliceStable(branches, func(i, j int) bool {
+					return branches[i].Thickness > branches[j].Thickness
+				})
+				branches = branches[2:]
+			}
+			for _, b := range branches {
+				for _, e := range b.Edges {
+					removed[e] = true
+				}
+			}
+		}
+		if len(removed) == 0 {
+			return
+		}
+		var remaining []*MedialAxisEdge
+		for _, e := range m.Edges {
+			if !removed[e] {
+				remaining = append(remaining, e)
+			}
+		}
+		m.Edges = remaining
+	}
+}
+
+// followBranch walks from the leaf endpoint of an edge
+// until reaching a junction.
+//
+// Returns the junction and its radius, or false if the
+// path ends at another leaf.
+func (m *MedialAxis) followBranch(e *MedialAxisEdge, leafIdx int, degrees map[Coord]int,
+	edgesAt map[Coord][]*MedialAxisEdge) (branch []*MedialAxisEdge, junction Coord,
+	radius float64, ok bool) {
+	for {
+		branch = append(branch, e)
+		next, nextRadius := e.P2, e.R2
+		if leafIdx == 1 {
+			next, nextRadius = e.P1, e.R1
+		}
+		switch degrees[next] {
+		case 1:
+			return nil, Coord{}, 0, false
+		case 2:
+			for _, e1 := range edgesAt[next] {
+				if e1 != e {
+					e = e1
+					break
+				}
+			}
+			if e.P1 == next {
+				leafIdx = 0
+			} else {
+				leafIdx = 1
+			}
+			if len(branch) > len(m.Edges) {
+				// The path is a loop.
+				return nil, Coord{}, 0, false
+			}
+		default:
+			return branch, next, nextRadius, true
+		}
+	}
+}
+
+type medialAxisBuilder struct {
+	mesh    *Mesh
+	spacing float64
+
+	sites       []*MedialAxisSite
+	segmentSite map[*Segment]int
+	vertexSite  map[Coord]int
+	reflex      map[Coord]bool
+	samples     []Coord
+	labels      []int
+}
+
+func newMedialAxisBuilder(m *Mesh, spacing float64) *medialAxisBuilder {
+	b := &medialAxisBuilder{
+		mesh:        m,
+		spacing:     spacing,
+		segmentSite: map[*Segment]int{},
+		vertexSite:  map[Coord]int{},
+		reflex:      map[Coord]bool{},
+	}
+	for _, s := range m.SegmentSlice() {
+		b.segmentSite[s] = len(b.sites)
+		b.sites = append(b.sites, &MedialAxisSite{Segment: s})
+	}
+	for _, v := range m.VertexSlice() {
+		var in, out *Segment
+		for _, s := range m.Find(v) {
+			if s[1] == v {
+				in = s
+			} else {
+				out = s
+			}
+		}
+		if in == nil || out == nil {
+			continue
+		}
+		if det(in[1].Sub(in[0]), out[1].Sub(out[0])) > 0 {
+			b.reflex[v] = true
+			b.vertexSite[v] = len(b.sites)
+			b.sites = append(b.sites, &MedialAxisSite{Vertex: v})
+		}
+	}
+
+	// Sample points along the boundary, labeled by their
+	// sites.
+	m.IterateSorted(func(s *Segment) {
+		n := essentials.MaxInt(1, int(math.Ceil(s.Length()/spacing)))
+		for i := 0; i < n; i++ {
+			t := (float64(i) + 0.5) / float64(n)
+			b.samples = append(b.samples, s[0].Add(s[1].Sub(s[0]).Scale(t)))
+			b.labels = append(b.labels, b.segmentSite[s])
+		}
+	}, segmentLess)
+	for _, v := range m.VertexSlice() {
+		if id, ok := b.vertexSite[v]; ok {
+			b.samples = append(b.samples, v)
+			b.labels = append(b.labels, id)
+		}
+	}
+	return b
+}
+
+func segmentLess(s1, s2 *Segment) bool {
+	for i := 0; i < 2; i++ {
+		if s1[i].X != s2[i].X {
+			return s1[i].X < s2[i].X
+		} else if s1[i].Y != s2[i].Y {
+			return s1[i].Y < s2[i].Y
+		}
+	}
+	return false
+}
+
+// adjacent checks if two sites touch along the boundary in
+// a way that does not produce a branch of the medial axis.
+func (b *medialAxisBuilder) adjacent(i, j int) bool {
+	s1, s2 := b.sites[i], b.sites[j]
+	if s1.Segment == nil && s2.Segment == nil {
+		return false
+	} else if s1.Segment == nil || s2.Segment == nil {
+		if s1.Segment == nil {
+			s1, s2 = s2, s1
+		}
+		return s1.Segment[0] == s2.Vertex || s1.Segment[1] == s2.Vertex
+	}
+	for _, p1 := range s1.Segment {
+		for _, p2 := range s2.Segment {
+			if p1 == p2 && b.reflex[p1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convexCorner finds the vertex shared by two segment
+// sites, if it is a convex corner.
+func (b *medialAxisBuilder) convexCorner(i, j int) (Coord, bool) {
+	s1, s2 := b.sites[i].Segment, b.sites[j].Segment
+	if s1 == nil || s2 == nil {
+		return Coord{}, false
+	}
+	for _, p1 := range s1 {
+		for _, p2 := range s2 {
+			if p1 == p2 && !b.reflex[p1] {
+				return p1, true
+			}
+		}
+	}
+	return Coord{}, false
+}
+
+type medialAxisGraphEdge struct {
+	Nodes [2]int
+	Sites [2]int
+}
+
+func (b *medialAxisBuilder) Build() *MedialAxis {
+	min, max := b.mesh.Min(), b.mesh.Max()
+	cdt, ids := pointsCDT(b.samples, min, max)
+	labels := make([]int, len(cdt.Vertices))
+	for i := range labels {
+		labels[i] = -1
+	}
+	for i, id := range ids {
+		if id != -1 {
+			labels[id] = b.labels[i]
+		}
+	}
+
+	// Create a node at the circumcenter of every triangle
+	// inside the polygon.
+	solid := b.mesh.Solid()
+	nodeIDs := map[[3]int]int{}
+	var nodes []Coord
+	cdt.IterateTriangles(func(t [3]int) {
+		if t[0] < cdt.numSuper {
+			return
+		}
+		vs := cdt.Vertices
+		center, ok := circumcenter(vs[t[0]], vs[t[1]], vs[t[2]])
+		if ok && solid.Contains(center) {
+			nodeIDs[t] = len(nodes)
+			nodes = append(nodes, center)
+		}
+	})
+
+	// Connect nodes across Delaunay edges between samples
+	// from different sites.
+	var graphEdges []*medialAxisGraphEdge
+	cdt.IterateTriangles(func(t [3]int) {
+		node1, ok := nodeIDs[t]
+		if !ok {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			u, v := t[i], t[(i+1)%3]
+			if u > v {
+				// Only visit each edge once.
+				continue
+			}
+			w, ok := cdt.opposite(v, u)
+			if !ok {
+				continue
+			}
+			node2, ok := nodeIDs[cdtTriangleKey(v, u, w)]
+			if !ok {
+				continue
+			}
+			l1, l2 := labels[u], labels[v]
+			if l1 == l2 || l1 == -1 || l2 == -1 || b.adjacent(l1, l2) {
+				continue
+			}
+			if l1 > l2 {
+				l1, l2 = l2, l1
+			}
+			graphEdges = append(graphEdges, &medialAxisGraphEdge{
+				Nodes: [2]int{node1, node2},
+				Sites: [2]int{l1, l2},
+			})
+		}
+	})
+
+	return b.chainEdges(nodes, graphEdges)
+}
+
+// chainEdges merges paths of graph edges with the same
+// sites into medial axis edges, and computes the exact
+// locations of their endpoints.
+func (b *medialAxisBuilder) chainEdges(nodes []Coord,
+	graphEdges []*medialAxisGraphEdge) *MedialAxis {
+	nodeEdges := make([][]*medialAxisGraphEdge, len(nodes))
+	for _, e := range graphEdges {
+		for _, n := range e.Nodes {
+			nodeEdges[n] = append(nodeEdges[n], e)
+		}
+	}
+	isBreak := func(n int) bool {
+		es := nodeEdges[n]
+		return len(es) != 2 || es[0].Sites != es[1].Sites
+	}
+
+	type chain struct {
+		Start, End int
+		Sites      [2]int
+	}
+	var chains []chain
+	visited := map[*medialAxisGraphEdge]bool{}
+	walk := func(start int, e *medialAxisGraphEdge) {
+		cur := start
+		for {
+			visited[e] = true
+			next := e.Nodes[0]
+			if next == cur {
+				next = e.Nodes[1]
+			}
+			if next == start || isBreak(next) {
+				chains = append(chains, chain{Start: start, End: next, Sites: e.Sites})
+				return
+			}
+			cur = next
+			for _, e1 := range nodeEdges[next] {
+				if e1 != e {
+					e = e1
+					break
+				}
+			}
+		}
+	}
+	for n := range nodes {
+		if !isBreak(n) {
+			continue
+		}
+		for _, e := range nodeEdges[n] {
+			if !visited[e] {
+				walk(n, e)
+			}
+		}
+	}
+	for _, e := range graphEdges {
+		if !visited[e] {
+			walk(e.Nodes[0], e)
+		}
+	}
+
+	// Compute the exact location of every junction.
+	nodeSites := map[int]map[int]bool{}
+	for _, c := range chains {
+		for _, n := range []int{c.Start, c.End} {
+			if nodeSites[n] == nil {
+				nodeSites[n] = map[int]bool{}
+			}
+			nodeSites[n][c.Sites[0]] = true
+			nodeSites[n][c.Sites[1]] = true
+		}
+	}
+	positions := map[int]Coord{}
+	radii := map[int]float64{}
+	for n, sites := range nodeSites {
+		if len(sites) < 3 {
+			continue
+		}
+		var siteIDs []int
+		for s := range sites {
+			siteIDs = append(siteIDs, s)
+		}
+		sort.Ints(siteIDs)
+		if p, r, ok := b.solveJunction(siteIDs, nodes[n]); ok {
+			positions[n] = p
+			radii[n] = r
+		}
+	}
+
+	result := &MedialAxis{}
+	for _, c := range chains {
+		if c.Start == c.End {
+			continue
+		}
+		e := &MedialAxisEdge{Sites: [2]*MedialAxisSite{b.sites[c.Sites[0]], b.sites[c.Sites[1]]}}
+		var endpoints [2]Coord
+		var endRadii [2]float64
+		for i, n := range []int{c.Start, c.End} {
+			if p, ok := positions[n]; ok {
+				endpoints[i], endRadii[i] = p, radii[n]
+			} else if corner, ok := b.convexCorner(c.Sites[0], c.Sites[1]); ok &&
+				len(nodeEdges[n]) == 1 {
+				endpoints[i], endRadii[i] = corner, 0
+			} else {
+				endpoints[i] = projectBisector(e.Sites[0], e.Sites[1], nodes[n])
+				endRadii[i] = e.Sites[0].Dist(endpoints[i])
+			}
+		}
+		e.P1, e.P2 = endpoints[0], endpoints[1]
+		e.R1, e.R2 = endRadii[0], endRadii[1]
+		result.Edges = append(result.Edges, e)
+	}
+
+	// Separate junctions may be solved to the same point
+	// up to rounding error, such as at the center of a
+	// regular polygon.
+	result.Edges = mergeMedialAxisEndpoints(result.Edges, b.spacing*medialAxisMergeFraction)
+	return result
+}
+
+// medialAxisMergeFraction is the distance, relative to the
+// sample spacing, within which endpoints are merged.
+const medialAxisMergeFraction = 1e-6
+
+// mergeMedialAxisEndpoints snaps together edge endpoints
+// which are within eps of each other, and then removes
+// edges which become degenerate or duplicated.
+func mergeMedialAxisEndpoints(edges []*MedialAxisEdge, eps float64) []*MedialAxisEdge {
+	var points []Coord
+	var radii []float64
+	pointIDs := map[Coord]int{}
+	addPoint := func(p Coord, r float64) {
+		if _, ok := pointIDs[p]; !ok {
+			pointIDs[p] = len(points)
+			points = append(points, p)
+			radii = append(radii, r)
+		}
+	}
+	for _, e := range edges {
+		addPoint(e.P1, e.R1)
+		addPoint(e.P2, e.R2)
+	}
+
+	// Union nearby points, using the lowest ID in each
+	// group as its representative.
+	parents := make([]int, len(points))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	cells := map[[2]int][]int{}
+	for i, p := range points {
+		cell := [2]int{int(math.Floor(p.X / eps)), int(math.Floor(p.Y / eps))}
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for _, j := range cells[[2]int{cell[0] + dx, cell[1] + dy}] {
+					if points[j].Dist(p) <= eps {
+						r1, r2 := find(i), find(j)
+						if r1 < r2 {
+							r1, r2 = r2, r1
+						}
+						parents[r1] = r2
+					}
+				}
+			}
+		}
+		cells[cell] = append(cells[cell], i)
+	}
+
+	type edgeKey struct {
+		Nodes [2]int
+		Sites [2]*MedialAxisSite
+	}
+	seen := map[edgeKey]bool{}
+	var result []*MedialAxisEdge
+	for _, e := range edges {
+		n1, n2 := find(pointIDs[e.P1]), find(pointIDs[e.P2])
+		if n1 == n2 {
+			continue
+		}
+		key := edgeKey{Nodes: [2]int{n1, n2}, Sites: e.Sites}
+		if n1 > n2 {
+			key.Nodes = [2]int{n2, n1}
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		e.P1, e.R1 = points[n1], radii[n1]
+		e.P2, e.R2 = points[n2], radii[n2]
+		result = append(result, e)
+	}
+	return result
+}
+
+// solveJunction finds the point where three or more
+// branches of the medial axis meet.
+//
+// If no point is equidistant to all of the sites, which
+// can happen when the sampled topology is slightly wrong,
+// the best point that is equidistant to three of the sites
+// is used.
+func (b *medialAxisBuilder) solveJunction(siteIDs []int, init Coord) (Coord, float64, bool) {
+	sites := make([]*MedialAxisSite, len(siteIDs))
+	for i, id := range siteIDs {
+		sites[i] = b.sites[id]
+	}
+	maxDist := 4 * b.spacing
+	if p, r, ok := solveEquidistant(sites, init); ok && p.Dist(init) < maxDist {
+		return p, r, true
+	}
+	var bestPoint Coord
+	var bestRadius float64
+	bestSpread := math.Inf(1)
+	for i := 0; i < len(sites); i++ {
+		for j := i + 1; j < len(sites); j++ {
+			for k := j + 1; k < len(sites); k++ {
+				triple := []*MedialAxisSite{sites[i], sites[j], sites[k]}
+				p, r, ok := solveEquidistant(triple, init)
+				if !ok || p.Dist(init) >= maxDist {
+					continue
+				}
+				var spread float64
+				for _, s := range sites {
+					spread = math.Max(spread, math.Abs(s.Dist(p)-r))
+				}
+				if spread < bestSpread {
+					bestPoint, bestRadius, bestSpread = p, r, spread
+				}
+			}
+		}
+	}
+	return bestPoint, bestRadius, !math.IsInf(bestSpread, 1)
+}
+
+// solveEquidistant uses Gauss-Newton to find a point which
+// is equidistant to three or more sites.
+func solveEquidistant(sites []*MedialAxisSite, init Coord) (Coord, float64, bool) {
+	// A segment and one of its endpoints are equidistant
+	// along the perpendicular through the endpoint, so in
+	// this case the point is constrained to this line.
+	perpendiculars := make([]*Coord, len(sites))
+	for i, s := range sites {
+		if s.Segment == nil {
+			continue
+		}
+		for _, s1 := range sites {
+			if s1.Segment == nil && (s1.Vertex == s.Segment[0] || s1.Vertex == s.Segment[1]) {
+				perpendiculars[i] = &s1.Vertex
+			}
+		}
+	}
+
+	p := init
+	var r float64
+	for _, s := range sites {
+		d, _ := s.lineDist(p)
+		r += d / float64(len(sites))
+	}
+	for iter := 0; iter < 50; iter++ {
+		// Accumulate the normal equations J^T*J*x = -J^T*f,
+		// where each row of J is (grad_x, grad_y, -1) for a
+		// distance constraint.
+		var jtj [3][3]float64
+		var jtf [3]float64
+		for i, s := range sites {
+			var row [3]float64
+			var f float64
+			if v := perpendiculars[i]; v != nil {
+				dir := s.Segment[1].Sub(s.Segment[0]).Normalize()
+				row = [3]float64{dir.X, dir.Y, 0}
+				f = dir.Dot(p.Sub(*v))
+			} else {
+				d, grad := s.lineDist(p)
+				row = [3]float64{grad.X, grad.Y, -1}
+				f = d - r
+			}
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					jtj[i][j] += row[i] * row[j]
+				}
+				jtf[i] += row[i] * f
+			}
+		}
+		step, ok := solve3x3(jtj, [3]float64{-jtf[0], -jtf[1], -jtf[2]})
+		if !ok {
+			return p, r, false
+		}
+		p = p.Add(XY(step[0], step[1]))
+		r += step[2]
+		if math.Abs(step[0])+math.Abs(step[1])+math.Abs(step[2]) < 1e-14*(1+math.Abs(r)) {
+			break
+		}
+	}
+	for _, s := range sites {
+		if math.Abs(s.Dist(p)-r) > 1e-8*(1+math.Abs(r)) {
+			return p, r, false
+		}
+	}
+	return p, r, true
+}
+
+func solve3x3(m [3][3]float64, b [3]float64) ([3]float64, bool) {
+	det3 := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det3(m)
+	if d == 0 || math.IsNaN(d) {
+		return [3]float64{}, false
+	}
+	var result [3]float64
+	for i := 0; i < 3; i++ {
+		m1 := m
+		for j := 0; j < 3; j++ {
+			m1[j][i] = b[j]
+		}
+		result[i] = det3(m1) / d
+	}
+	return result, true
+}
+
+// projectBisector moves a point onto the curve which is
+// equidistant to two sites.
+func projectBisector(s1, s2 *MedialAxisSite, p Coord) Coord {
+	if s1.Segment == nil && s2.Segment == nil {
+		normal := s2.Vertex.Sub(s1.Vertex).Normalize()
+		mid := s1.Vertex.Mid(s2.Vertex)
+		return p.Sub(normal.Scale(normal.Dot(p.Sub(mid))))
+	} else if s1.Segment != nil && s2.Segment != nil {
+		_, n1 := s1.lineDist(p)
+		_, n2 := s2.lineDist(p)
+		normal := n1.Sub(n2)
+		if normal.Norm() < 1e-8 {
+			return p
+		}
+		offset := n1.Dot(s1.Segment[0]) - n2.Dot(s2.Segment[0])
+		scale := 1 / normal.Dot(normal)
+		return p.Sub(normal.Scale((normal.Dot(p) - offset) * scale))
+	}
+	if s1.Segment == nil {
+		s1, s2 = s2, s1
+	}
+	result, _ := parabolaPoint(s1.Segment, s2.Vertex, p, p, 0)
+	return result
+}
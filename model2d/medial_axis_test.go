@@ -0,0 +1,191 @@
+package model2d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewMedialAxisRect(t *testing.T) {
+	mesh := NewMeshRect(XY(0, 0), XY(4, 2))
+	axis := NewMedialAxis(mesh, 0.05)
+	if len(axis.Edges) != 5 {
+		t.Fatalf("expected 5 edges but got %d", len(axis.Edges))
+	}
+	for _, e := range axis.Edges {
+		if e.Parabolic() {
+			t.Error("unexpected parabolic edge")
+		}
+		checkMedialAxisEdge(t, mesh, e)
+	}
+
+	axis.Prune(0.5)
+	if len(axis.Edges) != 1 {
+		t.Fatalf("expected 1 edge after pruning but got %d", len(axis.Edges))
+	}
+	e := axis.Edges[0]
+	p1, p2 := e.P1, e.P2
+	if p1.X > p2.X {
+		p1, p2 = p2, p1
+	}
+	if p1.Dist(XY(1, 1)) > 1e-8 || p2.Dist(XY(3, 1)) > 1e-8 {
+		t.Errorf("unexpected endpoints: %v, %v", p1, p2)
+	}
+	if math.Abs(e.R1-1) > 1e-8 || math.Abs(e.R2-1) > 1e-8 {
+		t.Errorf("unexpected radii: %f, %f", e.R1, e.R2)
+	}
+}
+
+func TestNewMedialAxisReflex(t *testing.T) {
+	// An L-shaped polygon with one reflex vertex at (1, 1).
+	mesh := NewMesh()
+	points := []Coord{XY(0, 0), XY(0, 2), XY(1, 2), XY(1, 1), XY(2, 1), XY(2, 0)}
+	for i, p := range points {
+		mesh.Add(&Segment{p, points[(i+1)%len(points)]})
+	}
+	if mesh.Area() != 3 {
+		t.Fatal("unexpected mesh area")
+	}
+	axis := NewMedialAxis(mesh, 0.02)
+
+	var numParabolic int
+	for _, e := range axis.Edges {
+		if e.Parabolic() {
+			numParabolic++
+		}
+		checkMedialAxisEdge(t, mesh, e)
+	}
+	if numParabolic == 0 {
+		t.Error("expected a parabolic edge")
+	}
+
+	// The axis should be connected.
+	degrees := map[Coord]int{}
+	for _, e := range axis.Edges {
+		degrees[e.P1]++
+		degrees[e.P2]++
+	}
+	var numLeaves int
+	for _, d := range degrees {
+		if d == 1 {
+			numLeaves++
+		}
+	}
+	if numLeaves != 5 {
+		t.Errorf("expected 5 leaves (one per convex corner) but got %d", numLeaves)
+	}
+	if len(degrees) != len(axis.Edges)+1 {
+		t.Errorf("expected a tree but got %d nodes and %d edges", len(degrees), len(axis.Edges))
+	}
+}
+
+func checkMedialAxisEdge(t *testing.T, mesh *Mesh, e *MedialAxisEdge) {
+	sdf := MeshToSDF(mesh)
+	for i := 0; i <= 10; i++ {
+		p, r := e.Eval(float64(i) / 10)
+		d1, d2 := e.Sites[0].Dist(p), e.Sites[1].Dist(p)
+		if math.Abs(d1-d2) > 1e-8 || math.Abs(d1-r) > 1e-8 {
+			t.Errorf("point %v not equidistant: %f, %f, %f", p, d1, d2, r)
+			return
+		}
+		if actual := sdf.SDF(p); math.Abs(actual-r) > 1e-8 {
+			t.Errorf("point %v has radius %f but distance %f", p, r, actual)
+			return
+		}
+	}
+	if p, _ := e.Eval(0); p.Dist(e.P1) > 1e-8 {
+		t.Errorf("start point %v does not match %v", p, e.P1)
+	}
+	if p, _ := e.Eval(1); p.Dist(e.P2) > 1e-8 {
+		t.Errorf("end point %v does not match %v", p, e.P2)
+	}
+}
+
+func TestNewMedialAxisSmooth(t *testing.T) {
+	for _, c := range []struct {
+		Name         string
+		Vertices     int
+		Radii        Coord
+		PrunedLeaves int
+	}{
+		// Corners of coarse polygons are kept.
+		{"Square", 4, XY(1, 1), 4},
+		{"Heptagon", 7, XY(1, 1), 7},
+
+		// Corners of smooth curves are pruned.
+		{"Circle", 200, XY(1, 1), 2},
+		{"Ellipse", 100, XY(2, 1), 2},
+	} {
+		t.Run(c.Name, func(t *testing.T) {
+			mesh := NewMesh()
+			points := make([]Coord, c.Vertices)
+			for i := range points {
+				theta := -2 * math.Pi * float64(i) / float64(c.Vertices)
+				points[i] = XY(math.Cos(theta), math.Sin(theta)).Mul(c.Radii)
+			}
+			for i, p := range points {
+				mesh.Add(&Segment{p, points[(i+1)%len(points)]})
+			}
+
+			axis := NewMedialAxis(mesh, 0)
+			checkMedialAxisTree(t, axis)
+			for _, e := range axis.Edges {
+				checkMedialAxisEdge(t, mesh, e)
+			}
+			if leaves := medialAxisLeaves(axis); leaves != c.Vertices {
+				t.Errorf("expected %d leaves but got %d", c.Vertices, leaves)
+			}
+
+			axis.Prune(0.05)
+			checkMedialAxisTree(t, axis)
+			if leaves := medialAxisLeaves(axis); leaves != c.PrunedLeaves {
+				t.Errorf("expected %d leaves after pruning but got %d", c.PrunedLeaves, leaves)
+			}
+		})
+	}
+}
+
+func checkMedialAxisTree(t *testing.T, axis *MedialAxis) {
+	t.Helper()
+	parents := map[Coord]Coord{}
+	var find func(c Coord) Coord
+	find = func(c Coord) Coord {
+		if p, ok := parents[c]; ok && p != c {
+			root := find(p)
+			parents[c] = root
+			return root
+		}
+		return c
+	}
+	for _, e := range axis.Edges {
+		if e.P1 == e.P2 {
+			t.Fatalf("edge has zero length: %v", e.P1)
+		}
+		r1, r2 := find(e.P1), find(e.P2)
+		if r1 == r2 {
+			t.Fatalf("edge from %v to %v creates a cycle", e.P1, e.P2)
+		}
+		parents[r1] = r2
+	}
+	roots := map[Coord]bool{}
+	for _, e := range axis.Edges {
+		roots[find(e.P1)] = true
+	}
+	if len(roots) > 1 {
+		t.Fatalf("expected a connected axis but got %d components", len(roots))
+	}
+}
+
+func medialAxisLeaves(axis *MedialAxis) int {
+	degrees := map[Coord]int{}
+	for _, e := range axis.Edges {
+		degrees[e.P1]++
+		degrees[e.P2]++
+	}
+	var count int
+	for _, d := range degrees {
+		if d == 1 {
+			count++
+		}
+	}
+	return count
+}
@@ -402,6 +402,19 @@ func (m *Mesh) SavePathSVG(path string) error {
 	return nil
 }
 
+// SaveDXF encodes the mesh to a DXF file.
+func (m *Mesh) SaveDXF(path string) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "save DXF")
+	}
+	defer w.Close()
+	if err := WriteDXF(w, m); err != nil {
+		return errors.Wrap(err, "save DXF")
+	}
+	return nil
+}
+
 // SegmentSlice gets a snapshot of all the segments
 // currently in the mesh. The resulting slice is a copy,
 // and will not change as the mesh is updated.
@@ -0,0 +1,394 @@
+package model2d
+
+import (
+	"math"
+	"sort"
+)
+
+// A NestingPart is an outline to be cut out of sheets,
+// along with the number of copies and the ways it may be
+// rotated.
+type NestingPart struct {
+	// Mesh is a manifold outline of the part.
+	Mesh *Mesh
+
+	// Quantity is the number of copies to place.
+	// If 0, one copy is placed.
+	Quantity int
+
+	// Rotations contains the allowed rotations, in radians.
+	// If empty, the part is never rotated.
+	Rotations []float64
+}
+
+// A NestingPlacement positions one copy of a part on a
+// sheet.
+type NestingPlacement struct {
+	// Part is the index of the part.
+	Part int
+
+	// Sheet is the index of the sheet.
+	Sheet int
+
+	// Rotation is the angle, in radians, by which the part
+	// is rotated about the origin before it is translated.
+	Rotation float64
+
+	// Offset is the translation applied after rotation.
+	Offset Coord
+}
+
+// Transform gets the transformation from part coordinates
+// to sheet coordinates.
+func (n *NestingPlacement) Transform() Transform {
+	return JoinedTransform{Rotation(n.Rotation), &Translate{Offset: n.Offset}}
+}
+
+// A Nesting is the result of arranging parts on sheets.
+//
+// Sheets span the rectangle from the origin to Size.
+type Nesting struct {
+	Size       Coord
+	Parts      []*NestingPart
+	Placements []*NestingPlacement
+	NumSheets  int
+
+	// Unplaced contains the part index of each copy which
+	// could not be placed.
+	Unplaced []int
+}
+
+// SheetMeshes gets the transformed meshes for every part
+// placed on a sheet.
+func (n *Nesting) SheetMeshes(sheet int) []*Mesh {
+	var res []*Mesh
+	for _, p := range n.Placements {
+		if p.Sheet == sheet {
+			res = append(res, n.Parts[p.Part].Mesh.Transform(p.Transform()))
+		}
+	}
+	return res
+}
+
+// SheetMesh combines all of the meshes on a sheet.
+func (n *Nesting) SheetMesh(sheet int) *Mesh {
+	res := NewMesh()
+	for _, m := range n.SheetMeshes(sheet) {
+		res.AddMesh(m)
+	}
+	return res
+}
+
+// EncodeSheetSVG encodes the outlines on a sheet as an SVG
+// file, where the bounds of the file are the sheet.
+func (n *Nesting) EncodeSheetSVG(sheet int) []byte {
+	bounds := &Rect{MaxVal: n.Size}
+	return EncodeCustomSVG([]*Mesh{n.SheetMesh(sheet)}, []string{"black"}, []float64{1.0}, bounds)
+}
+
+// EncodeSheetDXF encodes the outlines on a sheet as a DXF
+// file.
+func (n *Nesting) EncodeSheetDXF(sheet int) []byte {
+	return EncodeDXF(n.SheetMesh(sheet))
+}
+
+// A Nester arranges parts on rectangular sheets, such as
+// for laser cutting.
+//
+// Parts are placed one at a time, from largest to
+// smallest, using a bottom-left-fill heuristic: each part
+// is started from candidate positions next to the parts
+// already on a sheet, and then slid down and to the left
+// until it touches another part or an edge of the sheet.
+type Nester struct {
+	// Width and Height are the dimensions of each sheet.
+	Width  float64
+	Height float64
+
+	// Spacing is the minimum distance between parts, such
+	// as the kerf of a laser cutter.
+	Spacing float64
+
+	// Margin is the minimum distance between parts and the
+	// edges of a sheet.
+	Margin float64
+
+	// MaxSheets, if non-zero, limits the number of sheets.
+	// Copies which do not fit are reported as unplaced.
+	MaxSheets int
+}
+
+// Nest arranges copies of the parts on sheets.
+func (n *Nester) Nest(parts []*NestingPart) *Nesting {
+	res := &Nesting{
+		Size:  XY(n.Width, n.Height),
+		Parts: parts,
+	}
+
+	var copies []int
+	shapes := make([][]*nestingShape, len(parts))
+	areas := make([]float64, len(parts))
+	for i, p := range parts {
+		rotations := p.Rotations
+		if len(rotations) == 0 {
+			rotations = []float64{0}
+		}
+		for _, r := range rotations {
+			shapes[i] = append(shapes[i], newNestingShape(p.Mesh, r, n.Spacing))
+		}
+		areas[i] = p.Mesh.Area()
+		quantity := p.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		for j := 0; j < quantity; j++ {
+			copies = append(copies, i)
+		}
+	}
+	sort.SliceStable(copies, func(i, j int) bool {
+		return areas[copies[i]] > areas[copies[j]]
+	})
+
+	var sheets []*nestingSheet
+	for _, partIdx := range copies {
+		var placed bool
+		for sheetIdx := 0; !placed; sheetIdx++ {
+			if sheetIdx == len(sheets) {
+				if n.MaxSheets != 0 && len(sheets) == n.MaxSheets {
+					break
+				}
+				sheets = append(sheets, &nestingSheet{})
+			}
+			sheet := sheets[sheetIdx]
+			shape, offset, ok := n.bestPlacement(sheet, shapes[partIdx])
+			if ok {
+				sheet.Add(shape, offset)
+				res.Placements = append(res.Placements, &NestingPlacement{
+					Part:     partIdx,
+					Sheet:    sheetIdx,
+					Rotation: shape.Rotation,
+					Offset:   offset.Add(shape.Shift),
+				})
+				placed = true
+			} else if len(sheet.Items) == 0 {
+				// The part does not fit on an empty sheet.
+				sheets = sheets[:sheetIdx]
+				break
+			}
+		}
+		if !placed {
+			res.Unplaced = append(res.Unplaced, partIdx)
+		}
+	}
+	res.NumSheets = len(sheets)
+	return res
+}
+
+// bestPlacement finds the lowest, and then left-most,
+// position for any rotation of a part.
+func (n *Nester) bestPlacement(sheet *nestingSheet, shapes []*nestingShape) (*nestingShape,
+	Coord, bool) {
+	var bestShape *nestingShape
+	var bestOffset Coord
+	for _, shape := range shapes {
+		offset, ok := n.placeShape(sheet, shape)
+		if !ok {
+			continue
+		}
+		if bestShape == nil || offset.Y < bestOffset.Y ||
+			(offset.Y == bestOffset.Y && offset.X < bestOffset.X) {
+			bestShape = shape
+			bestOffset = offset
+		}
+	}
+	return bestShape, bestOffset, bestShape != nil
+}
+
+func (n *Nester) placeShape(sheet *nestingSheet, shape *nestingShape) (Coord, bool) {
+	minOffset := XY(n.Margin, n.Margin)
+	maxOffset := XY(n.Width-n.Margin, n.Height-n.Margin).Sub(shape.Size)
+	if maxOffset.X < minOffset.X || maxOffset.Y < minOffset.Y {
+		return Coord{}, false
+	}
+
+	xs := []float64{minOffset.X}
+	ys := []float64{minOffset.Y, maxOffset.Y}
+	for _, item := range sheet.Items {
+		xs = append(xs, item.Max.X+shape.Gap)
+		ys = append(ys, item.Max.Y+shape.Gap)
+	}
+	var candidates []Coord
+	for _, x := range xs {
+		for _, y := range ys {
+			c := XY(x, y)
+			if c.Min(maxOffset) == c && c.Max(minOffset) == c {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		c1, c2 := candidates[i], candidates[j]
+		return c1.Y < c2.Y || (c1.Y == c2.Y && c1.X < c2.X)
+	})
+
+	eps := 1e-9 * math.Max(n.Width, n.Height)
+	var best Coord
+	var found bool
+	for _, c := range candidates {
+		if found && c.Y > best.Y {
+			break
+		}
+		if sheet.Collides(shape, c) {
+			continue
+		}
+		for i := 0; i < 100; i++ {
+			down := sheet.SlideDistance(shape, c, XY(0, -1), c.Y-minOffset.Y)
+			c.Y -= math.Max(0, down-eps)
+			left := sheet.SlideDistance(shape, c, XY(-1, 0), c.X-minOffset.X)
+			c.X -= math.Max(0, left-eps)
+			if down <= eps && left <= eps {
+				break
+			}
+		}
+		if !found || c.Y < best.Y || (c.Y == best.Y && c.X < best.X) {
+			best = c
+			found = true
+		}
+	}
+	return best, found
+}
+
+// A nestingShape is a rotated part, translated so that its
+// minimum is at the origin.
+type nestingShape struct {
+	Rotation float64
+
+	// Shift is the translation applied after rotation to
+	// move the minimum of the part to the origin.
+	Shift Coord
+
+	// Size is the size of the bounding box of the part.
+	Size Coord
+
+	// Gap is the distance by which the part is expanded
+	// to keep it away from other parts.
+	Gap float64
+
+	Mesh     *Mesh
+	Collider MultiCollider
+
+	// Expanded is the part grown by the spacing, which must
+	// not overlap other parts.
+	Expanded         *Mesh
+	ExpandedCollider MultiCollider
+	ExpandedMin      Coord
+	ExpandedMax      Coord
+}
+
+func newNestingShape(m *Mesh, rotation, spacing float64) *nestingShape {
+	rotated := m.Transform(Rotation(rotation))
+	shift := rotated.Min().Scale(-1)
+	mesh := rotated.Translate(shift)
+
+	// Round joins are approximated by chords, so we expand
+	// slightly further to keep the chords at least spacing
+	// away from the part. We also subtract a small epsilon
+	// so that touching parts do not collide.
+	const arcTolerance = 1e-3
+	gap := spacing * (1 + arcTolerance)
+	epsilon := 1e-9 * mesh.Max().Norm()
+	o := &MeshOffsetter{Join: RoundJoin, ArcTolerance: spacing * arcTolerance}
+	expanded := o.Offset(mesh, gap-epsilon)
+	return &nestingShape{
+		Rotation:         rotation,
+		Shift:            shift,
+		Size:             mesh.Max(),
+		Gap:              gap,
+		Mesh:             mesh,
+		Collider:         MeshToCollider(mesh),
+		Expanded:         expanded,
+		ExpandedCollider: MeshToCollider(expanded),
+		ExpandedMin:      expanded.Min(),
+		ExpandedMax:      expanded.Max(),
+	}
+}
+
+type nestingItem struct {
+	Mesh     *Mesh
+	Collider MultiCollider
+	Min      Coord
+	Max      Coord
+}
+
+type nestingSheet struct {
+	Items []*nestingItem
+}
+
+func (n *nestingSheet) Add(shape *nestingShape, offset Coord) {
+	mesh := shape.Mesh.Translate(offset)
+	n.Items = append(n.Items, &nestingItem{
+		Mesh:     mesh,
+		Collider: MeshToCollider(mesh),
+		Min:      mesh.Min(),
+		Max:      mesh.Max(),
+	})
+}
+
+// Collides checks if the expanded shape overlaps any of the
+// parts on the sheet.
+func (n *nestingSheet) Collides(shape *nestingShape, offset Coord) bool {
+	min, max := shape.ExpandedMin.Add(offset), shape.ExpandedMax.Add(offset)
+	for _, item := range n.Items {
+		if item.Max.X < min.X || item.Max.Y < min.Y || item.Min.X > max.X || item.Min.Y > max.Y {
+			continue
+		}
+		var collides bool
+		shape.Expanded.Iterate(func(s *Segment) {
+			if !collides {
+				collides = item.Collider.SegmentCollision(&Segment{s[0].Add(offset), s[1].Add(offset)})
+			}
+		})
+		if collides {
+			return true
+		}
+		// Check if either outline is entirely inside the other.
+		if ColliderContains(item.Collider, shape.Expanded.VertexSlice()[0].Add(offset), 0) {
+			return true
+		}
+		if ColliderContains(shape.ExpandedCollider, item.Mesh.VertexSlice()[0].Sub(offset), 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// SlideDistance computes how far the expanded shape can
+// move in a direction before touching a part on the sheet,
+// up to a maximum distance.
+func (n *nestingSheet) SlideDistance(shape *nestingShape, offset, direction Coord,
+	maxDist float64) float64 {
+	dist := maxDist
+	min, max := shape.ExpandedMin.Add(offset), shape.ExpandedMax.Add(offset)
+	for _, item := range n.Items {
+		// Skip parts which cannot be reached by moving in
+		// the direction.
+		if direction.X == 0 && (item.Max.X < min.X || item.Min.X > max.X || item.Min.Y > max.Y) {
+			continue
+		} else if direction.Y == 0 && (item.Max.Y < min.Y || item.Min.Y > max.Y || item.Min.X > max.X) {
+			continue
+		}
+		shape.Expanded.IterateVertices(func(c Coord) {
+			ray := &Ray{Origin: c.Add(offset), Direction: direction}
+			if rc, ok := item.Collider.FirstRayCollision(ray); ok {
+				dist = math.Min(dist, rc.Scale)
+			}
+		})
+		item.Mesh.IterateVertices(func(c Coord) {
+			ray := &Ray{Origin: c.Sub(offset), Direction: direction.Scale(-1)}
+			if rc, ok := shape.ExpandedCollider.FirstRayCollision(ray); ok {
+				dist = math.Min(dist, rc.Scale)
+			}
+		})
+	}
+	return dist
+}
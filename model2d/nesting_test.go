@@ -0,0 +1,116 @@
+package model2d
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestNesterRects(t *testing.T) {
+	nester := &Nester{Width: 5, Height: 4}
+	parts := []*NestingPart{
+		{Mesh: NewMeshRect(XY(0, 0), XY(2, 1)), Quantity: 10},
+	}
+	res := nester.Nest(parts)
+	if len(res.Unplaced) != 0 {
+		t.Fatalf("unexpected unplaced parts: %v", res.Unplaced)
+	}
+	if len(res.Placements) != 10 {
+		t.Fatalf("expected 10 placements but got %d", len(res.Placements))
+	}
+	if res.NumSheets != 2 {
+		t.Errorf("expected 2 sheets but got %d", res.NumSheets)
+	}
+	checkNesting(t, nester, res)
+}
+
+func TestNesterRotations(t *testing.T) {
+	nester := &Nester{Width: 3, Height: 10, Spacing: 0.1, Margin: 0.2}
+	parts := []*NestingPart{
+		{Mesh: NewMeshRect(XY(0, 0), XY(8, 1)), Quantity: 2, Rotations: []float64{0, math.Pi / 2}},
+		{Mesh: NewMeshRect(XY(0, 0), XY(20, 1))},
+	}
+	res := nester.Nest(parts)
+	if len(res.Unplaced) != 1 || res.Unplaced[0] != 1 {
+		t.Errorf("unexpected unplaced parts: %v", res.Unplaced)
+	}
+	if len(res.Placements) != 2 || res.NumSheets != 1 {
+		t.Fatalf("unexpected placements: %d on %d sheets", len(res.Placements), res.NumSheets)
+	}
+	for _, p := range res.Placements {
+		if p.Rotation != math.Pi/2 {
+			t.Errorf("unexpected rotation: %f", p.Rotation)
+		}
+	}
+	checkNesting(t, nester, res)
+}
+
+func TestNesterConcave(t *testing.T) {
+	lShape := NewMesh()
+	points := []Coord{XY(0, 0), XY(0, 3), XY(1, 3), XY(1, 1), XY(3, 1), XY(3, 0)}
+	for i, p := range points {
+		lShape.Add(&Segment{p, points[(i+1)%len(points)]})
+	}
+	nester := &Nester{Width: 10, Height: 8, Spacing: 0.25, Margin: 0.1}
+	parts := []*NestingPart{
+		{
+			Mesh:      lShape,
+			Quantity:  12,
+			Rotations: []float64{0, math.Pi / 2, math.Pi, 3 * math.Pi / 2},
+		},
+		{Mesh: NewMeshPolar(func(theta float64) float64 { return 0.7 }, 20), Quantity: 5},
+	}
+	res := nester.Nest(parts)
+	if len(res.Unplaced) != 0 {
+		t.Fatalf("unexpected unplaced parts: %v", res.Unplaced)
+	}
+	checkNesting(t, nester, res)
+
+	if data := res.EncodeSheetSVG(0); !bytes.Contains(data, []byte("<svg")) {
+		t.Error("unexpected SVG data")
+	}
+	mesh, err := ReadDXF(bytes.NewReader(res.EncodeSheetDXF(0)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meshesEqual(mesh, res.SheetMesh(0)) {
+		t.Error("unexpected DXF mesh")
+	}
+}
+
+func checkNesting(t *testing.T, n *Nester, res *Nesting) {
+	for sheet := 0; sheet < res.NumSheets; sheet++ {
+		meshes := res.SheetMeshes(sheet)
+		if len(meshes) == 0 {
+			t.Errorf("sheet %d is empty", sheet)
+		}
+		for i, m := range meshes {
+			min, max := m.Min(), m.Max()
+			if min.X < n.Margin-1e-8 || min.Y < n.Margin-1e-8 ||
+				max.X > n.Width-n.Margin+1e-8 || max.Y > n.Height-n.Margin+1e-8 {
+				t.Errorf("sheet %d part %d out of bounds: %v, %v", sheet, i, min, max)
+			}
+			for j, m1 := range meshes[:i] {
+				if d := nestingMeshDist(m, m1); d < n.Spacing-1e-6 {
+					t.Errorf("sheet %d parts %d and %d are %f apart", sheet, j, i, d)
+				}
+			}
+		}
+	}
+}
+
+func nestingMeshDist(m1, m2 *Mesh) float64 {
+	res := math.Inf(1)
+	for _, pair := range [][2]*Mesh{{m1, m2}, {m2, m1}} {
+		sdf := MeshToSDF(pair[1])
+		for _, v := range pair[0].VertexSlice() {
+			d := sdf.SDF(v)
+			if d > 0 {
+				// The vertex is inside the other mesh.
+				return -d
+			}
+			res = math.Min(res, -d)
+		}
+	}
+	return res
+}
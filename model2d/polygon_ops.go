@@ -0,0 +1,765 @@
+package model2d
+
+import (
+	"math"
+	"sort"
+)
+
+// A FillRule determines which points are inside of a mesh
+// that may intersect itself or contain overlapping loops.
+//
+// The winding number of a point is the number of times
+// the mesh winds around it, where clockwise loops (whose
+// normals face outwards) count positively.
+type FillRule int
+
+const (
+	// NonZeroFill includes points with a non-zero winding
+	// number.
+	NonZeroFill FillRule = iota
+
+	// EvenOddFill includes points with an odd winding
+	// number.
+	EvenOddFill
+
+	// PositiveFill includes points with a positive winding
+	// number.
+	PositiveFill
+)
+
+func (f FillRule) inside(winding int) bool {
+	switch f {
+	case NonZeroFill:
+		return winding != 0
+	case EvenOddFill:
+		return winding%2 != 0
+	case PositiveFill:
+		return winding > 0
+	}
+	panic("unknown fill rule")
+}
+
+// A BooleanOp is an operation for MeshBoolean.
+type BooleanOp int
+
+const (
+	BooleanUnion BooleanOp = iota
+	BooleanIntersection
+	BooleanDifference
+	BooleanXor
+)
+
+func (b BooleanOp) apply(in1, in2 bool) bool {
+	switch b {
+	case BooleanUnion:
+		return in1 || in2
+	case BooleanIntersection:
+		return in1 && in2
+	case BooleanDifference:
+		return in1 && !in2
+	case BooleanXor:
+		return in1 != in2
+	}
+	panic("unknown boolean operation")
+}
+
+// MeshBoolean computes a boolean operation between the
+// regions enclosed by two meshes.
+//
+// The result is computed exactly, up to floating point
+// error, by splitting segments at their intersections, so
+// the vertices of the result are vertices of the inputs
+// or intersections between input segments.
+//
+// The inputs need not be manifold, and they may intersect
+// themselves; the fill rule determines which points each
+// mesh contains.
+// The result is a manifold mesh with no self-intersections
+// (except possibly at vertices), with normals facing
+// outwards.
+//
+// To resolve the self-intersections in a single mesh, use
+// an empty mesh as the second argument of a union.
+func MeshBoolean(op BooleanOp, m1, m2 *Mesh, rule FillRule) *Mesh {
+	var segs []booleanSegment
+	for i, m := range []*Mesh{m1, m2} {
+		m.Iterate(func(s *Segment) {
+			if s[0] != s[1] {
+				segs = append(segs, booleanSegment{Segment: *s, Operand: i})
+			}
+		})
+	}
+	if len(segs) == 0 {
+		return NewMesh()
+	}
+	eps := booleanEpsilon(segs)
+	edges := splitBooleanSegments(segs, eps)
+
+	left, right := booleanWindings(edges)
+	var result []*Segment
+	for i, e := range edges {
+		inLeft := op.apply(rule.inside(left[i][0]), rule.inside(left[i][1]))
+		inRight := op.apply(rule.inside(right[i][0]), rule.inside(right[i][1]))
+		if inRight && !inLeft {
+			result = append(result, &Segment{e.Segment[0], e.Segment[1]})
+		} else if inLeft && !inRight {
+			result = append(result, &Segment{e.Segment[1], e.Segment[0]})
+		}
+	}
+	return NewMeshSegments(mergeColinearSegments(result))
+}
+
+// MeshUnion computes the union of the regions enclosed by
+// two meshes.
+//
+// See MeshBoolean for details.
+func MeshUnion(m1, m2 *Mesh, rule FillRule) *Mesh {
+	return MeshBoolean(BooleanUnion, m1, m2, rule)
+}
+
+// MeshIntersection computes the intersection of the
+// regions enclosed by two meshes.
+//
+// See MeshBoolean for details.
+func MeshIntersection(m1, m2 *Mesh, rule FillRule) *Mesh {
+	return MeshBoolean(BooleanIntersection, m1, m2, rule)
+}
+
+// MeshDifference subtracts the region enclosed by m2 from
+// the region enclosed by m1.
+//
+// See MeshBoolean for details.
+func MeshDifference(m1, m2 *Mesh, rule FillRule) *Mesh {
+	return MeshBoolean(BooleanDifference, m1, m2, rule)
+}
+
+// MeshXor computes the region enclosed by exactly one of
+// two meshes.
+//
+// See MeshBoolean for details.
+func MeshXor(m1, m2 *Mesh, rule FillRule) *Mesh {
+	return MeshBoolean(BooleanXor, m1, m2, rule)
+}
+
+// A JoinStyle determines how MeshOffsetter connects the
+// offset segments at a corner.
+type JoinStyle int
+
+const (
+	// MiterJoin extends the segments until they meet,
+	// unless the resulting point is too far from the
+	// corner, in which case SquareJoin is used.
+	MiterJoin JoinStyle = iota
+
+	// RoundJoin connects the segments with a circular arc.
+	RoundJoin
+
+	// SquareJoin cuts off the corner at the offset
+	// distance.
+	SquareJoin
+
+	// BevelJoin cuts off the corner with a line between
+	// the ends of the offset segments.
+	BevelJoin
+)
+
+const (
+	DefaultMeshOffsetterMiterLimit = 2.0
+)
+
+// A MeshOffsetter grows or shrinks the region enclosed by
+// a mesh by a fixed distance, producing a new mesh.
+type MeshOffsetter struct {
+	Join JoinStyle
+
+	// MiterLimit is the maximum distance of a miter from
+	// its corner, as a multiple of the offset distance.
+	//
+	// If 0, DefaultMeshOffsetterMiterLimit is used.
+	MiterLimit float64
+
+	// ArcTolerance is the maximum distance between the
+	// round joins and true circular arcs.
+	//
+	// If 0, the offset distance divided by 1000 is used.
+	ArcTolerance float64
+}
+
+// OffsetMesh is a shorthand for offsetting a mesh with a
+// MeshOffsetter with the given join style.
+func OffsetMesh(m *Mesh, delta float64, join JoinStyle) *Mesh {
+	o := &MeshOffsetter{Join: join}
+	return o.Offset(m, delta)
+}
+
+// Offset moves the boundary of the region enclosed by m
+// outward by delta, or inward if delta is negative.
+//
+// The region is determined with the non-zero fill rule,
+// so the mesh should have consistent normals, but it may
+// intersect itself.
+// The result is a manifold mesh with normals facing
+// outwards.
+func (o *MeshOffsetter) Offset(m *Mesh, delta float64) *Mesh {
+	m = MeshUnion(m, NewMesh(), NonZeroFill)
+	if delta == 0 {
+		return m
+	}
+
+	outgoing := NewCoordToSlice[*Segment]()
+	m.Iterate(func(s *Segment) {
+		outgoing.Append(s[0], s)
+	})
+
+	raw := NewMesh()
+	m.Iterate(func(s *Segment) {
+		n1 := s.Normal()
+		raw.Add(&Segment{s[0].Add(n1.Scale(delta)), s[1].Add(n1.Scale(delta))})
+
+		next := nextBoundarySegment(s, outgoing.Value(s[1]))
+		if next == nil {
+			return
+		}
+		v := s[1]
+		n2 := next.Normal()
+		joint := o.join(v, s[1].Sub(s[0]).Normalize(), next[1].Sub(next[0]).Normalize(),
+			n1, n2, delta)
+		for i := 0; i < len(joint)-1; i++ {
+			if joint[i] != joint[i+1] {
+				raw.Add(&Segment{joint[i], joint[i+1]})
+			}
+		}
+	})
+	return MeshUnion(raw, NewMesh(), PositiveFill)
+}
+
+// join creates a path from the end of one offset segment
+// to the start of the next offset segment, around the
+// corner v.
+func (o *MeshOffsetter) join(v, dir1, dir2, n1, n2 Coord, delta float64) []Coord {
+	p1 := v.Add(n1.Scale(delta))
+	p2 := v.Add(n2.Scale(delta))
+
+	turn := det(dir1, dir2)
+	dot := dir1.Dot(dir2)
+	if math.Abs(turn) < 1e-12 && dot > 0 {
+		// The segments are co-linear.
+		return []Coord{p1, p2}
+	} else if delta*turn > 0 {
+		// The offset segments overlap, and the resulting
+		// loop will be removed by the fill rule.
+		return []Coord{p1, v, p2}
+	}
+
+	absDelta := math.Abs(delta)
+	switch o.Join {
+	case RoundJoin:
+		angle := math.Atan2(det(n1, n2), n1.Dot(n2))
+		if math.Abs(turn) < 1e-12 {
+			// The path reverses direction.
+			angle = -math.Pi * math.Copysign(1, delta)
+		}
+		tolerance := o.ArcTolerance
+		if tolerance == 0 {
+			tolerance = absDelta / 1000
+		}
+		step := 2 * math.Acos(math.Max(-1, 1-tolerance/absDelta))
+		numSteps := int(math.Ceil(math.Abs(angle) / step))
+		result := []Coord{p1}
+		offset := n1.Scale(delta)
+		for i := 1; i < numSteps; i++ {
+			rotation := NewMatrix2Rotation(angle * float64(i) / float64(numSteps))
+			result = append(result, v.Add(rotation.MulColumn(offset)))
+		}
+		return append(result, p2)
+	case MiterJoin:
+		limit := o.MiterLimit
+		if limit == 0 {
+			limit = DefaultMeshOffsetterMiterLimit
+		}
+		cosSum := 1 + n1.Dot(n2)
+		if cosSum > 0 && 2/cosSum <= limit*limit {
+			miter := v.Add(n1.Add(n2).Scale(delta / cosSum))
+			return []Coord{p1, miter, p2}
+		}
+	case BevelJoin:
+		return []Coord{p1, p2}
+	}
+
+	// Square joins cut off the corner with a line that is
+	// perpendicular to the bisector of the corner.
+	var axis Coord
+	if math.Abs(turn) < 1e-12 {
+		axis = dir1
+	} else {
+		axis = n1.Add(n2).Scale(delta).Normalize()
+	}
+	t1 := (absDelta - n1.Scale(delta).Dot(axis)) / dir1.Dot(axis)
+	t2 := (absDelta - n2.Scale(delta).Dot(axis)) / -dir2.Dot(axis)
+	return []Coord{p1, p1.Add(dir1.Scale(t1)), p2.Sub(dir2.Scale(t2)), p2}
+}
+
+// nextBoundarySegment finds the segment which follows s
+// along the boundary of the region to the right of s.
+func nextBoundarySegment(s *Segment, candidates []*Segment) *Segment {
+	back := s[0].Sub(s[1])
+	backAngle := math.Atan2(back.Y, back.X)
+	var best *Segment
+	bestAngle := math.Inf(1)
+	for _, c := range candidates {
+		d := c[1].Sub(c[0])
+		angle := math.Atan2(d.Y, d.X) - backAngle
+		for angle <= 0 {
+			angle += 2 * math.Pi
+		}
+		if angle < bestAngle {
+			bestAngle = angle
+			best = c
+		}
+	}
+	return best
+}
+
+type booleanSegment struct {
+	Segment Segment
+	Operand int
+}
+
+// A booleanEdge is a unique segment after splitting all
+// the segments at their intersections.
+type booleanEdge struct {
+	Segment Segment
+
+	// Counts stores, for each operand, the number of times
+	// the edge appears in its direction minus the number of
+	// times it appears in the reverse direction.
+	Counts [2]int
+}
+
+func booleanEpsilon(segs []booleanSegment) float64 {
+	min, max := segs[0].Segment.Min(), segs[0].Segment.Max()
+	for _, s := range segs[1:] {
+		min = min.Min(s.Segment.Min())
+		max = max.Max(s.Segment.Max())
+	}
+	scale := math.Max(max.Sub(min).MaxCoord(), math.Max(min.Abs().MaxCoord(),
+		max.Abs().MaxCoord()))
+	if scale == 0 {
+		scale = 1
+	}
+	return scale * 1e-10
+}
+
+// splitBooleanSegments splits segments at all of their
+// intersections and merges duplicate edges, so that the
+// resulting edges only touch at their endpoints.
+func splitBooleanSegments(segs []booleanSegment, eps float64) []*booleanEdge {
+	snapper := newCoordSnapper(eps)
+	splits := make([][]Coord, len(segs))
+	for i, s := range segs {
+		s.Segment[0] = snapper.Snap(s.Segment[0])
+		s.Segment[1] = snapper.Snap(s.Segment[1])
+		segs[i] = s
+		splits[i] = []Coord{s.Segment[0], s.Segment[1]}
+	}
+
+	// Sweep along the x-axis to find pairs of segments with
+	// overlapping bounding boxes.
+	order := make([]int, len(segs))
+	mins := make([]Coord, len(segs))
+	maxes := make([]Coord, len(segs))
+	for i, s := range segs {
+		order[i] = i
+		mins[i] = s.Segment.Min()
+		maxes[i] = s.Segment.Max()
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return mins[order[i]].X < mins[order[j]].X
+	})
+	for i, idx1 := range order {
+		s1 := segs[idx1].Segment
+		min1, max1 := mins[idx1], maxes[idx1]
+		for _, idx2 := range order[i+1:] {
+			s2 := segs[idx2].Segment
+			min2, max2 := mins[idx2], maxes[idx2]
+			if min2.X > max1.X+eps {
+				break
+			}
+			if min2.Y > max1.Y+eps || max2.Y < min1.Y-eps {
+				continue
+			}
+			for _, p := range segmentIntersections(&s1, &s2, eps) {
+				p = snapper.Snap(p)
+				splits[idx1] = append(splits[idx1], p)
+				splits[idx2] = append(splits[idx2], p)
+			}
+		}
+	}
+
+	edges := NewEdgeMap[*booleanEdge]()
+	var edgeList []*booleanEdge
+	for i, s := range segs {
+		points := splits[i]
+		start := s.Segment[0]
+		dir := s.Segment[1].Sub(start)
+		sort.Slice(points, func(j, k int) bool {
+			return points[j].Sub(start).Dot(dir) < points[k].Sub(start).Dot(dir)
+		})
+		for j := 0; j < len(points)-1; j++ {
+			p1, p2 := points[j], points[j+1]
+			if p1 == p2 {
+				continue
+			}
+			key := [2]Coord{p1, p2}
+			sign := 1
+			if p2.X < p1.X || (p2.X == p1.X && p2.Y < p1.Y) {
+				key = [2]Coord{p2, p1}
+				sign = -1
+			}
+			edge, ok := edges.Load(key)
+			if !ok {
+				edge = &booleanEdge{Segment: Segment(key)}
+				edges.Store(key, edge)
+				edgeList = append(edgeList, edge)
+			}
+			edge.Counts[s.Operand] += sign
+		}
+	}
+
+	var result []*booleanEdge
+	for _, e := range edgeList {
+		if e.Counts != [2]int{} {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// segmentIntersections finds the points where two
+// segments touch, excluding the endpoints of each segment
+// which are only on that segment.
+func segmentIntersections(s1, s2 *Segment, eps float64) []Coord {
+	var result []Coord
+
+	// Endpoints which lie on the other segment, which
+	// covers touching and co-linear segments.
+	for _, p := range s1 {
+		if p != s2[0] && p != s2[1] && s2.Dist(p) < eps {
+			result = append(result, p)
+		}
+	}
+	for _, p := range s2 {
+		if p != s1[0] && p != s1[1] && s1.Dist(p) < eps {
+			result = append(result, p)
+		}
+	}
+
+	d1 := s1[1].Sub(s1[0])
+	d2 := s2[1].Sub(s2[0])
+	denom := det(d1, d2)
+	if denom == 0 {
+		return result
+	}
+	offset := s2[0].Sub(s1[0])
+	t1 := det(offset, d2) / denom
+	t2 := det(offset, d1) / denom
+	if t1 <= 0 || t1 >= 1 || t2 <= 0 || t2 >= 1 {
+		return result
+	}
+	p := s1[0].Add(d1.Scale(t1))
+	for _, endpoint := range []Coord{s1[0], s1[1], s2[0], s2[1]} {
+		if endpoint.Dist(p) < eps {
+			return result
+		}
+	}
+	return append(result, p)
+}
+
+// A coordSnapper merges points that are within a small
+// distance of each other.
+type coordSnapper struct {
+	eps   float64
+	cells map[[2]int64][]Coord
+}
+
+func newCoordSnapper(eps float64) *coordSnapper {
+	return &coordSnapper{eps: eps, cells: map[[2]int64][]Coord{}}
+}
+
+func (c *coordSnapper) Snap(p Coord) Coord {
+	cx := int64(math.Floor(p.X / c.eps))
+	cy := int64(math.Floor(p.Y / c.eps))
+	for x := cx - 1; x <= cx+1; x++ {
+		for y := cy - 1; y <= cy+1; y++ {
+			for _, p1 := range c.cells[[2]int64{x, y}] {
+				if p1.Dist(p) < c.eps {
+					return p1
+				}
+			}
+		}
+	}
+	key := [2]int64{cx, cy}
+	c.cells[key] = append(c.cells[key], p)
+	return p
+}
+
+// booleanWindings computes the winding numbers of each
+// operand to the left and right of every edge.
+//
+// The edges form a planar graph, since they only touch at
+// their endpoints. The faces of this graph are traced, and
+// winding numbers are propagated between adjacent faces,
+// so that only one ray must be cast for each connected
+// component of the graph.
+func booleanWindings(edges []*booleanEdge) (left, right [][2]int) {
+	vertexIDs := NewCoordMap[int]()
+	var vertices []Coord
+	vertexID := func(c Coord) int {
+		if id, ok := vertexIDs.Load(c); ok {
+			return id
+		}
+		vertexIDs.Store(c, len(vertices))
+		vertices = append(vertices, c)
+		return len(vertices) - 1
+	}
+
+	// Half-edge 2*i goes along edge i, and half-edge 2*i+1
+	// goes in reverse.
+	origins := make([]int, len(edges)*2)
+	angles := make([]float64, len(edges)*2)
+	for i, e := range edges {
+		origins[2*i] = vertexID(e.Segment[0])
+		origins[2*i+1] = vertexID(e.Segment[1])
+		d := e.Segment[1].Sub(e.Segment[0])
+		angles[2*i] = math.Atan2(d.Y, d.X)
+		angles[2*i+1] = math.Atan2(-d.Y, -d.X)
+	}
+	outgoing := make([][]int, len(vertices))
+	for h, v := range origins {
+		outgoing[v] = append(outgoing[v], h)
+	}
+	positions := make([]int, len(origins))
+	for _, hs := range outgoing {
+		sort.Slice(hs, func(i, j int) bool {
+			return angles[hs[i]] < angles[hs[j]]
+		})
+		for i, h := range hs {
+			positions[h] = i
+		}
+	}
+
+	// Trace the face to the left of each half-edge. The
+	// next half-edge is the first one clockwise from the
+	// twin of the current half-edge.
+	faces := make([]int, len(origins))
+	for i := range faces {
+		faces[i] = -1
+	}
+	var numFaces int
+	for start := range faces {
+		if faces[start] != -1 {
+			continue
+		}
+		for h := start; faces[h] == -1; {
+			faces[h] = numFaces
+			twin := h ^ 1
+			hs := outgoing[origins[twin]]
+			h = hs[(positions[twin]+len(hs)-1)%len(hs)]
+		}
+		numFaces++
+	}
+
+	// Find the connected components, and the face outside
+	// of each component, to the left of its leftmost vertex.
+	components := make([]int, len(vertices))
+	for i := range components {
+		components[i] = -1
+	}
+	var outerFaces []int
+	var outerPoints []Coord
+	for start := range vertices {
+		if components[start] != -1 {
+			continue
+		}
+		id := len(outerFaces)
+		components[start] = id
+		leftmost := start
+		queue := []int{start}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			if c, l := vertices[v], vertices[leftmost]; c.X < l.X || (c.X == l.X && c.Y < l.Y) {
+				leftmost = v
+			}
+			for _, h := range outgoing[v] {
+				if other := origins[h^1]; components[other] == -1 {
+					components[other] = id
+					queue = append(queue, other)
+				}
+			}
+		}
+		hs := outgoing[leftmost]
+		outerFaces = append(outerFaces, faces[hs[len(hs)-1]])
+		outerPoints = append(outerPoints, vertices[leftmost])
+	}
+
+	faceEdges := make([][]int, numFaces)
+	for i := range edges {
+		faceEdges[faces[2*i]] = append(faceEdges[faces[2*i]], i)
+		faceEdges[faces[2*i+1]] = append(faceEdges[faces[2*i+1]], i)
+	}
+	windings := make([][2]int, numFaces)
+	known := make([]bool, numFaces)
+	index := newWindingIndex(edges)
+	for id, face := range outerFaces {
+		windings[face] = index.RayCount(outerPoints[id], func(edge int) bool {
+			return components[origins[2*edge]] != id
+		})
+		known[face] = true
+		queue := []int{face}
+		for len(queue) > 0 {
+			f := queue[0]
+			queue = queue[1:]
+			for _, i := range faceEdges[f] {
+				// The winding number to the right of an edge is
+				// the number to the left plus the edge's count,
+				// since clockwise loops are positive.
+				fl, fr := faces[2*i], faces[2*i+1]
+				counts := edges[i].Counts
+				if !known[fl] {
+					known[fl] = true
+					windings[fl] = [2]int{windings[fr][0] - counts[0], windings[fr][1] - counts[1]}
+					queue = append(queue, fl)
+				} else if !known[fr] {
+					known[fr] = true
+					windings[fr] = [2]int{windings[fl][0] + counts[0], windings[fl][1] + counts[1]}
+					queue = append(queue, fr)
+				}
+			}
+		}
+	}
+
+	left = make([][2]int, len(edges))
+	right = make([][2]int, len(edges))
+	for i := range edges {
+		left[i] = windings[faces[2*i]]
+		right[i] = windings[faces[2*i+1]]
+	}
+	return
+}
+
+// A windingIndex computes winding numbers by casting rays
+// along the negative x-axis, using horizontal strips to
+// avoid checking every edge.
+type windingIndex struct {
+	edges     []*booleanEdge
+	minY      float64
+	stripSize float64
+	strips    [][]int
+}
+
+func newWindingIndex(edges []*booleanEdge) *windingIndex {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, e := range edges {
+		minY = math.Min(minY, math.Min(e.Segment[0].Y, e.Segment[1].Y))
+		maxY = math.Max(maxY, math.Max(e.Segment[0].Y, e.Segment[1].Y))
+	}
+	numStrips := int(math.Sqrt(float64(len(edges)))) + 1
+	w := &windingIndex{
+		edges:     edges,
+		minY:      minY,
+		stripSize: math.Max((maxY-minY)/float64(numStrips), 1e-300),
+		strips:    make([][]int, numStrips),
+	}
+	for i, e := range edges {
+		start := w.strip(math.Min(e.Segment[0].Y, e.Segment[1].Y))
+		end := w.strip(math.Max(e.Segment[0].Y, e.Segment[1].Y))
+		for j := start; j <= end; j++ {
+			w.strips[j] = append(w.strips[j], i)
+		}
+	}
+	return w
+}
+
+func (w *windingIndex) strip(y float64) int {
+	idx := int((y - w.minY) / w.stripSize)
+	if idx < 0 {
+		return 0
+	} else if idx >= len(w.strips) {
+		return len(w.strips) - 1
+	}
+	return idx
+}
+
+// RayCount computes the winding numbers just to the left
+// of a point, only considering edges for which include
+// returns true.
+func (w *windingIndex) RayCount(origin Coord, include func(edge int) bool) [2]int {
+	var result [2]int
+	for _, i := range w.strips[w.strip(origin.Y)] {
+		e := w.edges[i]
+		a, b := e.Segment[0], e.Segment[1]
+		if (a.Y <= origin.Y) == (b.Y <= origin.Y) || !include(i) {
+			continue
+		}
+		crossX := a.X + (origin.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if crossX >= origin.X {
+			continue
+		}
+		// Clockwise loops cross the ray upward.
+		sign := 1
+		if b.Y < a.Y {
+			sign = -1
+		}
+		result[0] += sign * e.Counts[0]
+		result[1] += sign * e.Counts[1]
+	}
+	return result
+}
+
+// mergeColinearSegments joins consecutive segments which
+// are nearly co-linear and not connected to any other
+// segments.
+func mergeColinearSegments(segs []*Segment) []*Segment {
+	incoming := map[Coord][]*Segment{}
+	outgoing := map[Coord][]*Segment{}
+	for _, s := range segs {
+		outgoing[s[0]] = append(outgoing[s[0]], s)
+		incoming[s[1]] = append(incoming[s[1]], s)
+	}
+	removed := map[*Segment]bool{}
+	for _, s := range segs {
+		v := s[0]
+		in, out := incoming[v], outgoing[v]
+		if len(in) != 1 || len(out) != 1 || in[0] == out[0] {
+			continue
+		}
+		prev, next := in[0], out[0]
+		d1 := v.Sub(prev[0])
+		d2 := next[1].Sub(v)
+		if d1.Dot(d2) <= 0 || math.Abs(det(d1, d2)) > 1e-10*d1.Norm()*d2.Norm() {
+			continue
+		}
+		if prev[0] == next[1] {
+			continue
+		}
+		// Extend prev to replace next.
+		prev[1] = next[1]
+		removed[next] = true
+		in2 := incoming[next[1]]
+		for i, x := range in2 {
+			if x == next {
+				in2[i] = prev
+			}
+		}
+		delete(incoming, v)
+		delete(outgoing, v)
+	}
+	result := make([]*Segment, 0, len(segs)-len(removed))
+	for _, s := range segs {
+		if !removed[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package model2d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeshBoolean(t *testing.T) {
+	m1 := NewMeshRect(XY(0, 0), XY(2, 2))
+	m2 := NewMeshRect(XY(1, 1), XY(3, 3))
+
+	testCases := []struct {
+		Op          BooleanOp
+		Area        float64
+		NumVertices int
+	}{
+		{BooleanUnion, 7, 8},
+		{BooleanIntersection, 1, 4},
+		{BooleanDifference, 3, 6},
+		{BooleanXor, 6, 10},
+	}
+	for _, tc := range testCases {
+		for _, rule := range []FillRule{NonZeroFill, EvenOddFill, PositiveFill} {
+			result := MeshBoolean(tc.Op, m1, m2, rule)
+			checkBooleanResult(t, result, tc.Area, tc.NumVertices, tc.Op != BooleanXor)
+		}
+	}
+
+	// Inverted normals should not matter for these rules.
+	for _, rule := range []FillRule{NonZeroFill, EvenOddFill} {
+		result := MeshUnion(m1.Invert(), m2, rule)
+		checkBooleanResult(t, result, 7, 8, true)
+	}
+
+	// Intersection vertices should be exact.
+	inter := MeshIntersection(m1, m2, NonZeroFill)
+	for _, v := range inter.VertexSlice() {
+		if v != XY(1, 1) && v != XY(1, 2) && v != XY(2, 1) && v != XY(2, 2) {
+			t.Errorf("unexpected vertex: %v", v)
+		}
+	}
+}
+
+func TestMeshBooleanSharedEdges(t *testing.T) {
+	m1 := NewMeshRect(XY(0, 0), XY(2, 1))
+	m2 := NewMeshRect(XY(1, 0), XY(3, 1))
+	checkBooleanResult(t, MeshUnion(m1, m2, NonZeroFill), 3, 4, true)
+	checkBooleanResult(t, MeshIntersection(m1, m2, NonZeroFill), 1, 4, true)
+	checkBooleanResult(t, MeshDifference(m1, m2, NonZeroFill), 1, 4, true)
+
+	// Touching but not overlapping.
+	m3 := NewMeshRect(XY(2, 0), XY(3, 1))
+	checkBooleanResult(t, MeshUnion(m1, m3, NonZeroFill), 3, 4, true)
+	if n := MeshIntersection(m1, m3, NonZeroFill).NumSegments(); n != 0 {
+		t.Errorf("expected empty intersection but got %d segments", n)
+	}
+}
+
+func TestMeshBooleanFillRules(t *testing.T) {
+	// Two overlapping loops in one mesh.
+	m := NewMeshRect(XY(0, 0), XY(2, 2))
+	m.AddMesh(NewMeshRect(XY(1, 1), XY(3, 3)))
+	checkBooleanResult(t, MeshUnion(m, NewMesh(), NonZeroFill), 7, 8, true)
+	checkBooleanResult(t, MeshUnion(m, NewMesh(), EvenOddFill), 6, 10, false)
+
+	// A loop with a hole that has the same orientation.
+	m = NewMeshRect(XY(0, 0), XY(3, 3))
+	m.AddMesh(NewMeshRect(XY(1, 1), XY(2, 2)))
+	checkBooleanResult(t, MeshUnion(m, NewMesh(), NonZeroFill), 9, 4, true)
+	checkBooleanResult(t, MeshUnion(m, NewMesh(), EvenOddFill), 8, 8, true)
+
+	// A self-intersecting bow-tie.
+	bowTie := NewMesh()
+	bowTie.Add(&Segment{XY(0, 0), XY(2, 2)})
+	bowTie.Add(&Segment{XY(2, 2), XY(2, 0)})
+	bowTie.Add(&Segment{XY(2, 0), XY(0, 2)})
+	bowTie.Add(&Segment{XY(0, 2), XY(0, 0)})
+	checkBooleanResult(t, MeshUnion(bowTie, NewMesh(), NonZeroFill), 2, 5, false)
+	checkBooleanResult(t, MeshUnion(bowTie, NewMesh(), PositiveFill), 1, 3, true)
+}
+
+func TestMeshBooleanCircles(t *testing.T) {
+	c1 := NewMeshPolar(func(float64) float64 { return 1 }, 300)
+	c2 := c1.Translate(XY(1, 0))
+	result := MeshUnion(c1, c2, NonZeroFill)
+	if !result.Manifold() {
+		t.Error("result is not manifold")
+	}
+	if _, n := result.RepairNormals(1e-8); n != 0 {
+		t.Errorf("expected no inverted normals but got %d", n)
+	}
+	expected := 2*c1.Area() - MeshIntersection(c1, c2, NonZeroFill).Area()
+	if math.Abs(result.Area()-expected) > 1e-8 {
+		t.Errorf("expected area %f but got %f", expected, result.Area())
+	}
+	lensArea := 2*math.Acos(0.5) - 0.5*math.Sqrt(3)
+	if math.Abs(MeshIntersection(c1, c2, NonZeroFill).Area()-lensArea) > 1e-3 {
+		t.Errorf("unexpected intersection area")
+	}
+}
+
+func TestOffsetMesh(t *testing.T) {
+	square := NewMeshRect(XY(0, 0), XY(2, 2))
+
+	miter := OffsetMesh(square, 1, MiterJoin)
+	checkBooleanResult(t, miter, 16, 4, true)
+	if min, max := miter.Min(), miter.Max(); min != XY(-1, -1) || max != XY(3, 3) {
+		t.Errorf("unexpected bounds: %v, %v", min, max)
+	}
+
+	round := OffsetMesh(square, 1, RoundJoin)
+	if a := round.Area(); math.Abs(a-(12+math.Pi)) > 1e-2 {
+		t.Errorf("unexpected round area: %f", a)
+	}
+	square2 := OffsetMesh(square, 1, SquareJoin)
+	checkBooleanResult(t, square2, 16-4*math.Pow(math.Sqrt2-1, 2), 8, true)
+	bevel := OffsetMesh(square, 1, BevelJoin)
+	checkBooleanResult(t, bevel, 14, 8, true)
+
+	// Shrinking a square.
+	checkBooleanResult(t, OffsetMesh(miter, -1, RoundJoin), 4, 4, true)
+	if n := OffsetMesh(square, -1.5, MiterJoin).NumSegments(); n != 0 {
+		t.Errorf("expected empty mesh but got %d segments", n)
+	}
+
+	// Growing a shape with a hole should shrink the hole.
+	ring := MeshDifference(NewMeshRect(XY(0, 0), XY(10, 10)),
+		NewMeshRect(XY(3, 3), XY(7, 7)), NonZeroFill)
+	checkBooleanResult(t, OffsetMesh(ring, 1, MiterJoin), 144-4, 8, true)
+	checkBooleanResult(t, OffsetMesh(ring, 2.5, MiterJoin), 225, 4, true)
+
+	// A concave shape should get round joins only on the
+	// convex corners.
+	lShape := MeshUnion(NewMeshRect(XY(0, 0), XY(4, 1)), NewMeshRect(XY(0, 0), XY(1, 4)),
+		NonZeroFill)
+	grown := OffsetMesh(lShape, 0.5, RoundJoin)
+	if !grown.Manifold() {
+		t.Error("offset L shape is not manifold")
+	}
+	expected := lShape.Area() + 0.5*(4+4+4+4) + 5*math.Pi*0.25/4 - 0.25
+	if a := grown.Area(); math.Abs(a-expected) > 1e-2 {
+		t.Errorf("expected area %f but got %f", expected, a)
+	}
+}
+
+func checkBooleanResult(t *testing.T, m *Mesh, area float64, numVertices int, manifold bool) {
+	t.Helper()
+	if manifold && !m.Manifold() {
+		t.Error("mesh is not manifold")
+	}
+	if a := m.Area(); math.Abs(a-area) > 1e-8 {
+		t.Errorf("expected area %f but got %f", area, a)
+	}
+	if n := len(m.VertexSlice()); n != numVertices {
+		t.Errorf("expected %d vertices but got %d", numVertices, n)
+	}
+	if _, n := m.RepairNormals(1e-8); n != 0 {
+		t.Errorf("expected no inverted normals but got %d", n)
+	}
+}
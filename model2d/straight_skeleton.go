@@ -0,0 +1,462 @@
+package model2d
+
+import (
+	"math"
+	"sort"
+)
+
+// A StraightSkeletonArc is a segment of a straight
+// skeleton, traced out by a vertex of the wavefront as it
+// moves inward.
+type StraightSkeletonArc struct {
+	Segment
+
+	// Heights stores the time at which the wavefront
+	// passed each endpoint, which is also the distance from
+	// the endpoint to the lines of the faces it bounds.
+	Heights [2]float64
+}
+
+// A StraightSkeletonFace is the region swept out by one
+// segment of the original mesh.
+type StraightSkeletonFace struct {
+	Segment *Segment
+
+	// Polygon is the boundary of the face in clockwise
+	// order, starting with the two endpoints of Segment.
+	Polygon []Coord
+}
+
+// Contains checks if a point is inside the face.
+func (s *StraightSkeletonFace) Contains(c Coord) bool {
+	var inside bool
+	for i, p1 := range s.Polygon {
+		p2 := s.Polygon[(i+1)%len(s.Polygon)]
+		if (p1.Y > c.Y) != (p2.Y > c.Y) {
+			x := p1.X + (c.Y-p1.Y)*(p2.X-p1.X)/(p2.Y-p1.Y)
+			if x > c.X {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Height computes the distance from c to the line
+// containing the face's segment.
+func (s *StraightSkeletonFace) Height(c Coord) float64 {
+	normal := s.Segment.Normal().Scale(-1)
+	return normal.Dot(c.Sub(s.Segment[0]))
+}
+
+// A StraightSkeleton is the straight skeleton of a
+// polygon, which is traced out by the vertices of the
+// polygon as its edges move inward at a constant speed.
+//
+// Unlike the medial axis, the straight skeleton consists
+// only of line segments, and its faces form a roof over the
+// polygon with a constant slope.
+type StraightSkeleton struct {
+	// Arcs contains every segment of the skeleton,
+	// including arcs which touch the boundary at the
+	// original vertices.
+	Arcs []*StraightSkeletonArc
+
+	// Faces contains one face per segment of the original
+	// mesh. Faces which could not be traced due to
+	// numerical issues are omitted.
+	Faces []*StraightSkeletonFace
+}
+
+// NewStraightSkeleton computes the straight skeleton of
+// the region inside of a mesh.
+//
+// The mesh should be manifold, with no self-intersections,
+// and with normals facing outwards. It may contain holes.
+//
+// This uses a direct simulation of the wavefront, taking
+// O(n^3) time for n segments, so it is intended for
+// moderately sized meshes such as glyphs of text.
+func NewStraightSkeleton(m *Mesh) *StraightSkeleton {
+	s := newSkeletonSimulation(m)
+	s.Run()
+	return &StraightSkeleton{
+		Arcs:  s.Arcs,
+		Faces: s.Faces(),
+	}
+}
+
+// Height computes the height of the roof defined by the
+// skeleton at a point, which is the time at which the
+// wavefront passes through the point.
+//
+// Returns false if c is not inside any face.
+func (s *StraightSkeleton) Height(c Coord) (float64, bool) {
+	for _, f := range s.Faces {
+		if f.Contains(c) {
+			return f.Height(c), true
+		}
+	}
+	return 0, false
+}
+
+// Mesh creates a mesh containing the arcs of the skeleton.
+//
+// If interior is true, arcs which touch the boundary of
+// the original mesh are excluded, leaving only the
+// centerline of the shape.
+func (s *StraightSkeleton) Mesh(interior bool) *Mesh {
+	result := NewMesh()
+	for _, a := range s.Arcs {
+		if interior && (a.Heights[0] == 0 || a.Heights[1] == 0) {
+			continue
+		}
+		seg := a.Segment
+		result.Add(&seg)
+	}
+	return result
+}
+
+type skeletonVertex struct {
+	Origin   Coord
+	Time     float64
+	Velocity Coord
+
+	// In and Out are the lines of the wavefront edges
+	// before and after this vertex.
+	In  int
+	Out int
+
+	Prev *skeletonVertex
+	Next *skeletonVertex
+
+	Reflex bool
+	Active bool
+}
+
+func (s *skeletonVertex) Pos(t float64) Coord {
+	return s.Origin.Add(s.Velocity.Scale(t - s.Time))
+}
+
+type skeletonSimulation struct {
+	Segments []*Segment
+	Normals  []Coord
+	Dirs     []Coord
+	Offsets  []float64
+
+	Vertices []*skeletonVertex
+	Arcs     []*StraightSkeletonArc
+	ArcFaces [][2]int
+
+	Now   float64
+	Eps   float64
+	nodes []Coord
+}
+
+func newSkeletonSimulation(m *Mesh) *skeletonSimulation {
+	segs := m.SegmentSlice()
+	sort.Slice(segs, func(i, j int) bool {
+		return segmentLess(segs[i], segs[j])
+	})
+	s := &skeletonSimulation{
+		Segments: segs,
+		Normals:  make([]Coord, len(segs)),
+		Dirs:     make([]Coord, len(segs)),
+		Offsets:  make([]float64, len(segs)),
+	}
+	if len(segs) == 0 {
+		return s
+	}
+	s.Eps = 1e-9 * m.Max().Sub(m.Min()).MaxCoord()
+
+	segIndex := map[*Segment]int{}
+	for i, seg := range segs {
+		segIndex[seg] = i
+		s.Normals[i] = seg.Normal().Scale(-1)
+		s.Dirs[i] = seg[1].Sub(seg[0]).Normalize()
+		s.Offsets[i] = s.Normals[i].Dot(seg[0])
+	}
+
+	// Create a vertex at the start of every segment, and
+	// link vertices along each loop.
+	startVertex := map[Coord]*skeletonVertex{}
+	for i, seg := range segs {
+		v := &skeletonVertex{Origin: seg[0], Out: i, Active: true}
+		startVertex[seg[0]] = v
+		s.Vertices = append(s.Vertices, v)
+	}
+	for i, seg := range segs {
+		v := startVertex[seg[0]]
+		next, ok := startVertex[seg[1]]
+		if !ok {
+			panic("mesh is not closed")
+		}
+		v.Next = next
+		next.Prev = v
+		next.In = i
+	}
+	for _, v := range s.Vertices {
+		s.initVertex(v)
+		s.nodes = append(s.nodes, v.Origin)
+	}
+	return s
+}
+
+// initVertex computes the velocity of a vertex from its
+// lines.
+func (s *skeletonSimulation) initVertex(v *skeletonVertex) {
+	n1, n2 := s.Normals[v.In], s.Normals[v.Out]
+	denom := 1 + n1.Dot(n2)
+	if denom < 1e-12 {
+		// Antiparallel lines meet everywhere at once.
+		v.Velocity = Coord{}
+	} else {
+		v.Velocity = n1.Add(n2).Scale(1 / denom)
+	}
+	v.Reflex = det(s.Dirs[v.In], s.Dirs[v.Out]) > 1e-12
+}
+
+type skeletonEvent struct {
+	Time float64
+
+	// Vertex is the first vertex of the collapsing edge,
+	// or the reflex vertex of a split event.
+	Vertex *skeletonVertex
+
+	// Edge is the first vertex of the edge that is hit by a
+	// split event, or nil for edge events.
+	Edge *skeletonVertex
+}
+
+func (s *skeletonSimulation) Run() {
+	maxSteps := 10*len(s.Vertices) + 10
+	for step := 0; step < maxSteps; step++ {
+		event, ok := s.nextEvent()
+		if !ok {
+			break
+		}
+		s.Now = math.Max(s.Now, event.Time)
+		if event.Edge == nil {
+			s.edgeEvent(event.Vertex)
+		} else {
+			s.splitEvent(event.Vertex, event.Edge)
+		}
+	}
+}
+
+func (s *skeletonSimulation) nextEvent() (*skeletonEvent, bool) {
+	var best *skeletonEvent
+	consider := func(e *skeletonEvent) {
+		if best == nil || e.Time < best.Time-s.Eps ||
+			(e.Time < best.Time+s.Eps && e.Edge == nil && best.Edge != nil) {
+			best = e
+		}
+	}
+	for _, v := range s.Vertices {
+		if !v.Active {
+			continue
+		}
+		if t, ok := s.edgeEventTime(v); ok {
+			consider(&skeletonEvent{Time: t, Vertex: v})
+		}
+		if !v.Reflex {
+			continue
+		}
+		for _, u := range s.Vertices {
+			if !u.Active || u == v || u.Next == v {
+				continue
+			}
+			if t, ok := s.splitEventTime(v, u); ok {
+				consider(&skeletonEvent{Time: t, Vertex: v, Edge: u})
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// edgeEventTime computes when the edge starting at v will
+// shrink to zero length.
+func (s *skeletonSimulation) edgeEventTime(v *skeletonVertex) (float64, bool) {
+	dir := s.Dirs[v.Out]
+	length := dir.Dot(v.Next.Pos(s.Now).Sub(v.Pos(s.Now)))
+	rate := dir.Dot(v.Next.Velocity.Sub(v.Velocity))
+	if length <= s.Eps {
+		return s.Now, true
+	} else if rate >= 0 {
+		return 0, false
+	}
+	return s.Now - length/rate, true
+}
+
+// splitEventTime computes when the reflex vertex v will
+// hit the edge starting at u.
+func (s *skeletonSimulation) splitEventTime(v, u *skeletonVertex) (float64, bool) {
+	normal := s.Normals[u.Out]
+	dist := normal.Dot(v.Pos(s.Now)) - s.Offsets[u.Out] - s.Now
+	rate := normal.Dot(v.Velocity) - 1
+	if dist < -s.Eps || rate >= 0 {
+		return 0, false
+	}
+	t := s.Now + math.Max(0, dist)/-rate
+
+	dir := s.Dirs[u.Out]
+	start := u.Pos(t)
+	hit := dir.Dot(v.Pos(t).Sub(start))
+	length := dir.Dot(u.Next.Pos(t).Sub(start))
+	if hit < -s.Eps || hit > length+s.Eps {
+		return 0, false
+	}
+	return t, true
+}
+
+func (s *skeletonSimulation) edgeEvent(v *skeletonVertex) {
+	next := v.Next
+	p := s.snap(v.Pos(s.Now).Mid(next.Pos(s.Now)))
+	s.finish(v, p)
+	s.finish(next, p)
+	merged := &skeletonVertex{
+		Origin: p,
+		Time:   s.Now,
+		In:     v.In,
+		Out:    next.Out,
+		Prev:   v.Prev,
+		Next:   next.Next,
+		Active: true,
+	}
+	s.initVertex(merged)
+	merged.Prev.Next = merged
+	merged.Next.Prev = merged
+	s.Vertices = append(s.Vertices, merged)
+	s.checkCollapse(merged)
+}
+
+func (s *skeletonSimulation) splitEvent(v, u *skeletonVertex) {
+	w := u.Next
+	p := s.snap(v.Pos(s.Now))
+	s.finish(v, p)
+	v1 := &skeletonVertex{
+		Origin: p,
+		Time:   s.Now,
+		In:     v.In,
+		Out:    u.Out,
+		Prev:   v.Prev,
+		Next:   w,
+		Active: true,
+	}
+	v2 := &skeletonVertex{
+		Origin: p,
+		Time:   s.Now,
+		In:     u.Out,
+		Out:    v.Out,
+		Prev:   u,
+		Next:   v.Next,
+		Active: true,
+	}
+	for _, x := range []*skeletonVertex{v1, v2} {
+		s.initVertex(x)
+		x.Prev.Next = x
+		x.Next.Prev = x
+		s.Vertices = append(s.Vertices, x)
+	}
+	s.checkCollapse(v1)
+	if v2.Active {
+		s.checkCollapse(v2)
+	}
+}
+
+// checkCollapse finishes a loop of the wavefront if it has
+// fewer than three vertices.
+func (s *skeletonSimulation) checkCollapse(v *skeletonVertex) {
+	if v.Next.Next != v {
+		return
+	}
+	other := v.Next
+	if other == v {
+		s.finish(v, v.Pos(s.Now))
+		return
+	}
+	p1 := s.snap(v.Pos(s.Now))
+	p2 := s.snap(other.Pos(s.Now))
+	s.finish(v, p1)
+	s.finish(other, p2)
+	s.addArc(p1, p2, s.Now, s.Now, [2]int{v.Out, other.Out})
+}
+
+// finish deactivates a vertex, adding the arc it traced
+// out to the skeleton.
+func (s *skeletonSimulation) finish(v *skeletonVertex, p Coord) {
+	v.Active = false
+	s.addArc(v.Origin, p, v.Time, s.Now, [2]int{v.In, v.Out})
+}
+
+func (s *skeletonSimulation) addArc(p1, p2 Coord, h1, h2 float64, faces [2]int) {
+	if p1 == p2 {
+		return
+	}
+	s.Arcs = append(s.Arcs, &StraightSkeletonArc{
+		Segment: Segment{p1, p2},
+		Heights: [2]float64{h1, h2},
+	})
+	s.ArcFaces = append(s.ArcFaces, faces)
+}
+
+// snap finds an existing node of the skeleton near p, so
+// that events at nearly the same point share endpoints.
+func (s *skeletonSimulation) snap(p Coord) Coord {
+	for _, n := range s.nodes {
+		if n.Dist(p) < 100*s.Eps {
+			return n
+		}
+	}
+	s.nodes = append(s.nodes, p)
+	return p
+}
+
+// Faces traces the boundary of each segment's face using
+// the arcs adjacent to the face.
+func (s *skeletonSimulation) Faces() []*StraightSkeletonFace {
+	faceArcs := make([][]*StraightSkeletonArc, len(s.Segments))
+	for i, arc := range s.Arcs {
+		faces := s.ArcFaces[i]
+		faceArcs[faces[0]] = append(faceArcs[faces[0]], arc)
+		if faces[1] != faces[0] {
+			faceArcs[faces[1]] = append(faceArcs[faces[1]], arc)
+		}
+	}
+
+	var result []*StraightSkeletonFace
+	for i, seg := range s.Segments {
+		arcs := faceArcs[i]
+		used := make([]bool, len(arcs))
+		polygon := []Coord{seg[0], seg[1]}
+		cur := seg[1]
+		for cur != seg[0] && len(polygon) <= len(arcs)+2 {
+			found := false
+			for j, arc := range arcs {
+				if used[j] {
+					continue
+				}
+				if arc.Segment[0] == cur {
+					cur = arc.Segment[1]
+				} else if arc.Segment[1] == cur {
+					cur = arc.Segment[0]
+				} else {
+					continue
+				}
+				used[j] = true
+				found = true
+				break
+			}
+			if !found {
+				break
+			}
+			if cur != seg[0] {
+				polygon = append(polygon, cur)
+			}
+		}
+		if cur == seg[0] {
+			result = append(result, &StraightSkeletonFace{Segment: seg, Polygon: polygon})
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package model2d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewStraightSkeletonRect(t *testing.T) {
+	mesh := NewMeshRect(XY(0, 0), XY(4, 2))
+	skeleton := NewStraightSkeleton(mesh)
+	if len(skeleton.Arcs) != 5 {
+		t.Fatalf("expected 5 arcs but got %d", len(skeleton.Arcs))
+	}
+	interior := skeleton.Mesh(true).SegmentSlice()
+	if len(interior) != 1 {
+		t.Fatalf("expected 1 interior arc but got %d", len(interior))
+	}
+	seg := interior[0]
+	if math.Abs(seg.Length()-2) > 1e-8 || seg.Dist(XY(2, 1)) > 1e-8 {
+		t.Errorf("unexpected interior arc: %v", seg)
+	}
+	checkStraightSkeletonFaces(t, mesh, skeleton)
+
+	for _, c := range []Coord{XY(2, 0.5), XY(0.3, 1), XY(2, 1.9)} {
+		h, ok := skeleton.Height(c)
+		if !ok {
+			t.Errorf("point %v not in any face", c)
+		} else if expected := MeshToSDF(mesh).SDF(c); math.Abs(h-expected) > 1e-8 {
+			t.Errorf("point %v: expected height %f but got %f", c, expected, h)
+		}
+	}
+}
+
+func TestNewStraightSkeletonReflex(t *testing.T) {
+	mesh := NewMesh()
+	points := []Coord{XY(0, 0), XY(0, 2), XY(1, 2), XY(1, 1), XY(3, 1), XY(3, 0)}
+	for i, p := range points {
+		mesh.Add(&Segment{p, points[(i+1)%len(points)]})
+	}
+	skeleton := NewStraightSkeleton(mesh)
+	checkStraightSkeletonFaces(t, mesh, skeleton)
+
+	// Near the reflex vertex, the roof is lower than the
+	// distance to the boundary.
+	c := XY(0.9, 0.7)
+	h, ok := skeleton.Height(c)
+	if !ok {
+		t.Fatal("point not in any face")
+	} else if math.Abs(h-0.3) > 1e-8 {
+		t.Errorf("expected height 0.3 but got %f", h)
+	} else if MeshToSDF(mesh).SDF(c) <= h {
+		t.Error("height should be less than distance to boundary")
+	}
+}
+
+func TestNewStraightSkeletonHole(t *testing.T) {
+	mesh := NewMeshRect(XY(0, 0), XY(4, 4))
+	mesh.AddMesh(NewMeshRect(XY(1.5, 1.2), XY(2.5, 2.5)).Invert())
+	skeleton := NewStraightSkeleton(mesh)
+	checkStraightSkeletonFaces(t, mesh, skeleton)
+}
+
+func TestNewStraightSkeletonRandom(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		rng := rand.New(rand.NewSource(int64(i)))
+		mesh := NewMeshPolar(func(theta float64) float64 {
+			return 1 + 0.3*rng.Float64()
+		}, 20)
+		skeleton := NewStraightSkeleton(mesh)
+		checkStraightSkeletonFaces(t, mesh, skeleton)
+	}
+}
+
+func checkStraightSkeletonFaces(t *testing.T, mesh *Mesh, skeleton *StraightSkeleton) {
+	if len(skeleton.Faces) != mesh.NumSegments() {
+		t.Errorf("expected %d faces but got %d", mesh.NumSegments(), len(skeleton.Faces))
+		return
+	}
+	var totalArea float64
+	for _, f := range skeleton.Faces {
+		var area float64
+		for i, p := range f.Polygon {
+			area -= det(p, f.Polygon[(i+1)%len(f.Polygon)]) / 2
+		}
+		if area <= 0 {
+			t.Errorf("face has non-positive area %f", area)
+		}
+		totalArea += area
+
+		// Every vertex of the face should be at the height
+		// of the face's line.
+		for _, arc := range skeleton.Arcs {
+			for j, p := range arc.Segment {
+				for _, p1 := range f.Polygon {
+					if p1 == p && math.Abs(f.Height(p)-arc.Heights[j]) > 1e-8 {
+						t.Errorf("arc height %f does not match face height %f",
+							arc.Heights[j], f.Height(p))
+						return
+					}
+				}
+			}
+		}
+	}
+	if math.Abs(totalArea-mesh.Area()) > 1e-8 {
+		t.Errorf("expected total area %f but got %f", mesh.Area(), totalArea)
+	}
+}
@@ -0,0 +1,254 @@
+package model2d
+
+import "math"
+
+// A CapStyle determines how a Stroker ends an open curve.
+type CapStyle int
+
+const (
+	// ButtCap ends the stroke flush with the endpoint.
+	ButtCap CapStyle = iota
+
+	// RoundCap ends the stroke with a half circle around
+	// the endpoint.
+	RoundCap
+
+	// SquareCap extends the stroke past the endpoint by
+	// half of the width.
+	SquareCap
+)
+
+const (
+	DefaultStrokerSegments   = 128
+	DefaultStrokerMiterLimit = 4.0
+)
+
+// A Stroker converts curves into solid regions by sweeping
+// a line segment perpendicular to the curve.
+//
+// The width of the stroke may vary along the curve, making
+// it possible to create calligraphic strokes.
+type Stroker struct {
+	// Width is the total width of the stroke.
+	Width float64
+
+	// WidthFunc, if non-nil, overrides Width by computing
+	// the width at a given arc length along the curve.
+	WidthFunc func(arcLen float64) float64
+
+	Cap  CapStyle
+	Join JoinStyle
+
+	// MiterLimit is the maximum distance of a miter from
+	// its corner, as a multiple of half the width.
+	//
+	// If 0, DefaultStrokerMiterLimit is used.
+	MiterLimit float64
+
+	// Segments is the number of line segments used to
+	// approximate smooth curves.
+	// Piecewise curves such as JoinedCurve and SegmentCurve
+	// are split at their corners, and the segments are
+	// divided among the pieces.
+	//
+	// If 0, DefaultStrokerSegments is used.
+	Segments int
+
+	// ArcTolerance is the maximum distance between the
+	// round joins and caps and true circular arcs.
+	//
+	// If 0, half the width divided by 1000 is used.
+	ArcTolerance float64
+}
+
+// Mesh creates a manifold mesh enclosing the stroke.
+//
+// If the curve starts and ends at the same point, it is
+// treated as a closed loop without caps.
+func (s *Stroker) Mesh(c Curve) *Mesh {
+	points, arcLens := s.sample(c)
+	closed := len(points) > 2 && points[0] == points[len(points)-1]
+	if closed {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 2 {
+		return NewMesh()
+	}
+	halfWidths := make([]float64, len(points))
+	for i, l := range arcLens[:len(points)] {
+		if s.WidthFunc != nil {
+			halfWidths[i] = math.Max(0, s.WidthFunc(l)/2)
+		} else {
+			halfWidths[i] = math.Max(0, s.Width/2)
+		}
+	}
+
+	raw := NewMesh()
+	numSegs := len(points) - 1
+	if closed {
+		numSegs++
+	}
+	for i := 0; i < numSegs; i++ {
+		j := (i + 1) % len(points)
+		p1, p2 := points[i], points[j]
+		n := (&Segment{p1, p2}).Normal()
+		addStrokePolygon(raw, []Coord{
+			p1.Add(n.Scale(halfWidths[i])),
+			p2.Add(n.Scale(halfWidths[j])),
+			p2.Sub(n.Scale(halfWidths[j])),
+			p1.Sub(n.Scale(halfWidths[i])),
+		})
+	}
+
+	for i := range points {
+		if !closed && (i == 0 || i == len(points)-1) {
+			continue
+		}
+		prev := points[(i+len(points)-1)%len(points)]
+		next := points[(i+1)%len(points)]
+		s.addJoin(raw, s.Join, points[i], points[i].Sub(prev).Normalize(),
+			next.Sub(points[i]).Normalize(), halfWidths[i])
+	}
+
+	if !closed && s.Cap != ButtCap {
+		join := RoundJoin
+		if s.Cap == SquareCap {
+			join = SquareJoin
+		}
+		last := len(points) - 1
+		startDir := points[1].Sub(points[0]).Normalize()
+		endDir := points[last].Sub(points[last-1]).Normalize()
+		s.addJoin(raw, join, points[0], startDir.Scale(-1), startDir, halfWidths[0])
+		s.addJoin(raw, join, points[last], endDir, endDir.Scale(-1), halfWidths[last])
+	}
+
+	return MeshUnion(raw, NewMesh(), NonZeroFill)
+}
+
+// SDF creates an SDF for the stroke.
+func (s *Stroker) SDF(c Curve) FaceSDF {
+	return MeshToSDF(s.Mesh(c))
+}
+
+// sample creates a polyline approximating the curve, along
+// with the arc length at each point.
+func (s *Stroker) sample(c Curve) ([]Coord, []float64) {
+	numSegments := s.Segments
+	if numSegments == 0 {
+		numSegments = DefaultStrokerSegments
+	}
+
+	pieces := strokeCurvePieces(c)
+	points := []Coord{c.Eval(0)}
+	addPoint := func(p Coord) {
+		if p != points[len(points)-1] {
+			points = append(points, p)
+		}
+	}
+	for _, piece := range pieces {
+		if sc, ok := piece.(*SegmentCurve); ok {
+			for _, seg := range sc.segments {
+				n := int(math.Ceil(float64(numSegments) * seg.Length() / sc.totalLength))
+				for i := 1; i <= n; i++ {
+					addPoint(seg[0].Add(seg[1].Sub(seg[0]).Scale(float64(i) / float64(n))))
+				}
+			}
+			continue
+		}
+		n := numSegments / len(pieces)
+		if n < 1 {
+			n = 1
+		}
+		for i := 1; i <= n; i++ {
+			addPoint(piece.Eval(float64(i) / float64(n)))
+		}
+	}
+
+	first, last := points[0], points[len(points)-1]
+	scale := 1.0
+	for _, p := range points {
+		scale = math.Max(scale, p.Sub(first).Norm())
+	}
+	if len(points) > 2 && first.Dist(last) < 1e-8*scale {
+		points[len(points)-1] = first
+	}
+
+	arcLens := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		arcLens[i] = arcLens[i-1] + points[i].Dist(points[i-1])
+	}
+	if alc, ok := c.(ArcLenCurve); ok {
+		if total := arcLens[len(arcLens)-1]; total > 0 {
+			ratio := alc.ArcLen() / total
+			for i := range arcLens {
+				arcLens[i] *= ratio
+			}
+		}
+	}
+	return points, arcLens
+}
+
+// addJoin adds a piece around the corner v on the outer
+// side of the turn from dir1 to dir2.
+//
+// A reversal of direction produces a cap.
+func (s *Stroker) addJoin(m *Mesh, join JoinStyle, v, dir1, dir2 Coord, halfWidth float64) {
+	if halfWidth == 0 {
+		return
+	}
+	limit := s.MiterLimit
+	if limit == 0 {
+		limit = DefaultStrokerMiterLimit
+	}
+	o := &MeshOffsetter{Join: join, MiterLimit: limit, ArcTolerance: s.ArcTolerance}
+	n1 := XY(-dir1.Y, dir1.X)
+	n2 := XY(-dir2.Y, dir2.X)
+	delta := halfWidth
+	if det(dir1, dir2) > 0 {
+		delta = -halfWidth
+	}
+	path := o.join(v, dir1, dir2, n1, n2, delta)
+	addStrokePolygon(m, append([]Coord{v}, path...))
+}
+
+// addStrokePolygon adds a polygon to m with the same
+// orientation as other meshes in this package.
+func addStrokePolygon(m *Mesh, poly []Coord) {
+	var area float64
+	for i, p := range poly {
+		area += det(p, poly[(i+1)%len(poly)])
+	}
+	if area > 0 {
+		for i := 0; i < len(poly)/2; i++ {
+			poly[i], poly[len(poly)-1-i] = poly[len(poly)-1-i], poly[i]
+		}
+	} else if area == 0 {
+		return
+	}
+	for i, p := range poly {
+		next := poly[(i+1)%len(poly)]
+		if p != next {
+			m.Add(&Segment{p, next})
+		}
+	}
+}
+
+// strokeCurvePieces splits a piecewise curve into pieces
+// which are each sampled separately.
+func strokeCurvePieces(c Curve) []Curve {
+	switch c := c.(type) {
+	case JoinedCurve:
+		var res []Curve
+		for _, sub := range c {
+			res = append(res, strokeCurvePieces(sub)...)
+		}
+		return res
+	case *JoinedArcLenCurve:
+		var res []Curve
+		for _, sub := range c.Subcurves() {
+			res = append(res, strokeCurvePieces(sub)...)
+		}
+		return res
+	}
+	return []Curve{c}
+}
@@ -0,0 +1,98 @@
+package model2d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStrokerCaps(t *testing.T) {
+	line := NewSegmentCurve([]*Segment{{XY(0, 0), XY(10, 0)}})
+	for _, c := range []struct {
+		Cap  CapStyle
+		Area float64
+	}{
+		{ButtCap, 20},
+		{SquareCap, 24},
+		{RoundCap, 20 + math.Pi},
+	} {
+		s := &Stroker{Width: 2, Cap: c.Cap}
+		mesh := s.Mesh(line)
+		checkStrokeMesh(t, mesh, c.Area, 1e-2)
+	}
+}
+
+func TestStrokerJoins(t *testing.T) {
+	corner := NewSegmentCurve([]*Segment{
+		{XY(0, 0), XY(10, 0)},
+		{XY(10, 0), XY(10, 10)},
+	})
+	for _, c := range []struct {
+		Join JoinStyle
+		Area float64
+	}{
+		{MiterJoin, 40},
+		{BevelJoin, 39.5},
+		{SquareJoin, 40 - (math.Sqrt2-1)*(math.Sqrt2-1)},
+		{RoundJoin, 39 + math.Pi/4},
+	} {
+		s := &Stroker{Width: 2, Join: c.Join}
+		mesh := s.Mesh(corner)
+		checkStrokeMesh(t, mesh, c.Area, 1e-2)
+	}
+
+	// A sharp corner should exceed the miter limit.
+	spike := NewSegmentCurve([]*Segment{
+		{XY(0, 0), XY(10, 0)},
+		{XY(10, 0), XY(0, 1)},
+	})
+	s := &Stroker{Width: 2, Join: MiterJoin}
+	if max := s.Mesh(spike).Max(); max.X > 10+DefaultStrokerMiterLimit {
+		t.Errorf("miter extends too far: %v", max)
+	}
+}
+
+func TestStrokerVariableWidth(t *testing.T) {
+	line := BezierCurve{XY(0, 0), XY(5, 0), XY(10, 0)}
+	s := &Stroker{
+		WidthFunc: func(arcLen float64) float64 {
+			return 1 + arcLen/10
+		},
+	}
+	checkStrokeMesh(t, s.Mesh(line), 15, 1e-3)
+}
+
+func TestStrokerClosed(t *testing.T) {
+	circle := FuncCurve(func(t float64) Coord {
+		return NewMatrix2Rotation(2 * math.Pi * t).MulColumn(XY(5, 0))
+	})
+	s := &Stroker{Width: 1, Segments: 1000}
+	mesh := s.Mesh(circle)
+	checkStrokeMesh(t, mesh, math.Pi*(5.5*5.5-4.5*4.5), 1e-2)
+
+	sdf := s.SDF(circle)
+	for _, c := range []struct {
+		Point Coord
+		SDF   float64
+	}{
+		{XY(5, 0), 0.5},
+		{XY(0, 0), -4.5},
+		{XY(0, 7), -1.5},
+	} {
+		if actual := sdf.SDF(c.Point); math.Abs(actual-c.SDF) > 1e-2 {
+			t.Errorf("point %v: expected SDF %f but got %f", c.Point, c.SDF, actual)
+		}
+	}
+}
+
+func checkStrokeMesh(t *testing.T, m *Mesh, area, tol float64) {
+	t.Helper()
+	if !m.Manifold() {
+		t.Error("mesh is not manifold")
+	}
+	if _, n := m.RepairNormals(1e-8); n != 0 {
+		t.Errorf("expected no inverted normals but got %d", n)
+	}
+	if a := m.Area(); math.Abs(a-area) > tol {
+		t.Errorf("expected area %f but got %f", area, a)
+	}
+}
@@ -0,0 +1,670 @@
+package model2d
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/numerical"
+)
+
+const (
+	DefaultImageVectorizerNumColors     = 2
+	DefaultImageVectorizerNumIters      = 20
+	DefaultImageVectorizerCurveSegments = 8
+	DefaultImageVectorizerFitTolerance  = 0.5
+)
+
+// An ImageVectorizer converts multi-color images into
+// regions, one per color, by quantizing the image with
+// K-means.
+//
+// Neighboring regions share their borders exactly, even
+// after simplification, so the regions tile the image
+// without gaps or overlaps.
+// Any simplified borders which would cross other borders
+// are restored to their original pixel boundaries.
+type ImageVectorizer struct {
+	// NumColors is the number of colors to quantize the
+	// image into.
+	// If 0, DefaultImageVectorizerNumColors is used.
+	NumColors int
+
+	// NumIters is the maximum number of K-means iterations.
+	// If 0, DefaultImageVectorizerNumIters is used.
+	NumIters int
+
+	// Fitter, if non-nil, is used to fit Bezier curves to
+	// each border between regions.
+	// The curves are then converted back into segments.
+	//
+	// Curves are fit to the midpoints of the pixel edges
+	// along each border, and any curve which strays more
+	// than FitTolerance from these midpoints is replaced by
+	// them.
+	// Borders with four or fewer vertices are not fit, and
+	// borders are left unchanged if fitting would give them
+	// more vertices.
+	Fitter *BezierFitter
+
+	// FitTolerance is the maximum distance, in pixels,
+	// between fitted curves and the midpoints they are fit
+	// to.
+	// If 0, DefaultImageVectorizerFitTolerance is used.
+	FitTolerance float64
+
+	// DecimateFraction, if non-zero, is the fraction of
+	// vertices to keep along each border between regions,
+	// after any curve fitting.
+	// Borders are simplified with Mesh.Decimate(), and the
+	// points where three or more regions meet are kept.
+	DecimateFraction float64
+
+	// CurveSegments is the number of segments to use for
+	// each Bezier curve produced by Fitter.
+	// If 0, DefaultImageVectorizerCurveSegments is used.
+	CurveSegments int
+}
+
+// A VectorImage is the result of vectorizing an image.
+//
+// Meshes are in pixel coordinates, following the same
+// conventions as Bitmap.Mesh().
+type VectorImage struct {
+	// Colors contains the color of each region.
+	Colors []color.RGBA
+
+	// Meshes contains a manifold mesh for each region.
+	Meshes []*Mesh
+}
+
+// Solids creates a solid for each region.
+func (v *VectorImage) Solids() []Solid {
+	res := make([]Solid, len(v.Meshes))
+	for i, m := range v.Meshes {
+		res[i] = NewColliderSolid(MeshToCollider(m))
+	}
+	return res
+}
+
+// HexColors gets the colors of the regions as strings
+// such as "#ff0000", ignoring alpha.
+func (v *VectorImage) HexColors() []string {
+	res := make([]string, len(v.Colors))
+	for i, c := range v.Colors {
+		res[i] = fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return res
+}
+
+// EncodeSVG encodes the regions as filled SVG paths.
+func (v *VectorImage) EncodeSVG() []byte {
+	colors := v.HexColors()
+	strokes := make([]string, len(colors))
+	thicknesses := make([]float64, len(colors))
+	for i := range strokes {
+		strokes[i] = "none"
+	}
+	return EncodeCustomPathSVG(v.Meshes, colors, strokes, thicknesses, nil)
+}
+
+// Vectorize quantizes the image and converts each color
+// into a region.
+//
+// Colors which do not appear in the quantized image are
+// omitted from the result.
+func (i *ImageVectorizer) Vectorize(img image.Image) *VectorImage {
+	colors, labels := i.quantize(img)
+	b := img.Bounds()
+	grid := &vectorizeGrid{
+		Width:  b.Dx(),
+		Height: b.Dy(),
+		Labels: labels,
+	}
+
+	chains := grid.Chains()
+	simplified := make([][]Coord, len(chains))
+	for j, chain := range chains {
+		simplified[j] = i.simplify(chain)
+	}
+	vectorizeRevertCollisions(chains, simplified)
+
+	meshes := make([]*Mesh, len(colors))
+	for j := range meshes {
+		meshes[j] = NewMesh()
+	}
+	for j, chain := range chains {
+		points := simplified[j]
+		numSegs := len(points) - 1
+		if chain.Closed {
+			numSegs++
+		}
+		for k := 0; k < numSegs; k++ {
+			p1, p2 := points[k], points[(k+1)%len(points)]
+			if chain.Right != -1 {
+				meshes[chain.Right].Add(&Segment{p1, p2})
+			}
+			if chain.Left != -1 {
+				meshes[chain.Left].Add(&Segment{p2, p1})
+			}
+		}
+	}
+
+	res := &VectorImage{}
+	for j, m := range meshes {
+		if m.NumSegments() > 0 {
+			res.Colors = append(res.Colors, colors[j])
+			res.Meshes = append(res.Meshes, m)
+		}
+	}
+	return res
+}
+
+func (i *ImageVectorizer) quantize(img image.Image) ([]color.RGBA, []int) {
+	b := img.Bounds()
+	data := make([]numerical.Vec4, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			data = append(data, numerical.Vec4{
+				float64(r) / 0xffff,
+				float64(g) / 0xffff,
+				float64(b) / 0xffff,
+				float64(a) / 0xffff,
+			})
+		}
+	}
+
+	numColors := i.NumColors
+	if numColors == 0 {
+		numColors = DefaultImageVectorizerNumColors
+	}
+	numIters := i.NumIters
+	if numIters == 0 {
+		numIters = DefaultImageVectorizerNumIters
+	}
+	km := numerical.NewKMeans(data, numColors)
+	lastLoss := math.Inf(1)
+	for j := 0; j < numIters; j++ {
+		loss := km.Iterate()
+		if loss >= lastLoss {
+			break
+		}
+		lastLoss = loss
+	}
+
+	colors := make([]color.RGBA, len(km.Centers))
+	for j, c := range km.Centers {
+		var comps [4]uint8
+		for k, x := range c {
+			comps[k] = uint8(math.Round(math.Max(0, math.Min(1, x)) * 0xff))
+		}
+		colors[j] = color.RGBA{R: comps[0], G: comps[1], B: comps[2], A: comps[3]}
+	}
+	return colors, km.Assign(data)
+}
+
+func (i *ImageVectorizer) simplify(chain *vectorizeChain) []Coord {
+	// Borders of the image are straight, and fitting them
+	// would only move them off of the image's edge.
+	fit := i.Fitter != nil && chain.Left != -1 && chain.Right != -1
+	return i.simplifyPoints(chain.Points, chain.Closed, fit)
+}
+
+func (i *ImageVectorizer) simplifyPoints(points []Coord, closed, fit bool) []Coord {
+	if !closed && points[0] == points[len(points)-1] {
+		// A loop through a junction must keep its endpoint.
+		mid := len(points) / 2
+		p1 := i.simplifyPoints(points[:mid+1], false, fit)
+		p2 := i.simplifyPoints(points[mid:], false, fit)
+		return append(p1, p2[1:]...)
+	}
+	if fit {
+		points = i.fitChain(points, closed)
+	}
+	if i.DecimateFraction != 0 {
+		points = decimateChain(points, closed, i.DecimateFraction)
+	}
+	return points
+}
+
+// fitChain fits Bezier curves to a border and converts
+// them back into points.
+func (i *ImageVectorizer) fitChain(points []Coord, closed bool) []Coord {
+	if len(points) <= 4 {
+		return points
+	}
+	numSegs := i.CurveSegments
+	if numSegs == 0 {
+		numSegs = DefaultImageVectorizerCurveSegments
+	}
+	tolerance := i.FitTolerance
+	if tolerance == 0 {
+		tolerance = DefaultImageVectorizerFitTolerance
+	}
+
+	// Fitting directly to pixel staircases produces curves
+	// which overshoot the corners, so the curves are fit to
+	// the midpoints of the pixel edges instead.
+	smoothed := make([]Coord, 0, len(points)+2)
+	if !closed {
+		smoothed = append(smoothed, points[0])
+	}
+	for j := 0; j < len(points)-1; j++ {
+		smoothed = append(smoothed, points[j].Mid(points[j+1]))
+	}
+	if closed {
+		smoothed = append(smoothed, points[len(points)-1].Mid(points[0]))
+	} else {
+		smoothed = append(smoothed, points[len(points)-1])
+	}
+	curves := i.Fitter.FitChain(smoothed, closed)
+	if closed {
+		smoothed = append(smoothed, smoothed[0])
+	}
+
+	// Each curve is fit to the span of points between its
+	// endpoints, and is replaced by that span if it strays
+	// too far from it.
+	res := []Coord{smoothed[0]}
+	start := 0
+	for _, curve := range curves {
+		end := start + 1
+		for end < len(smoothed) && smoothed[end] != curve[len(curve)-1] {
+			end++
+		}
+		if end == len(smoothed) {
+			return points
+		}
+		span := smoothed[start : end+1]
+		n := essentials.MinInt(numSegs, len(span)-1)
+		fitPoints := make([]Coord, 0, n)
+		for j := 1; j < n; j++ {
+			p := curve.Eval(float64(j) / float64(n))
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) || !vectorizeNearPolyline(span, p, tolerance) {
+				fitPoints = span[1 : len(span)-1]
+				break
+			}
+			fitPoints = append(fitPoints, p)
+		}
+		res = append(res, fitPoints...)
+		res = append(res, span[len(span)-1])
+		start = end
+	}
+	if start != len(smoothed)-1 {
+		return points
+	}
+	if closed {
+		res = res[:len(res)-1]
+	}
+	if len(res) > len(points) {
+		return points
+	}
+	return res
+}
+
+// vectorizeNearPolyline checks if p is within a distance
+// of a polyline.
+func vectorizeNearPolyline(points []Coord, p Coord, dist float64) bool {
+	for j := 0; j+1 < len(points); j++ {
+		if (Segment{points[j], points[j+1]}).Dist(p) <= dist {
+			return true
+		}
+	}
+	return false
+}
+
+// decimateChain simplifies a polyline while keeping its
+// endpoints fixed.
+func decimateChain(points []Coord, closed bool, fraction float64) []Coord {
+	m := NewMesh()
+	for j := 0; j+1 < len(points); j++ {
+		m.Add(&Segment{points[j], points[j+1]})
+	}
+	if closed {
+		m.Add(&Segment{points[len(points)-1], points[0]})
+	}
+	minVertices := 2
+	if closed {
+		minVertices = 3
+	}
+	count := int(math.Ceil(float64(len(points)) * fraction))
+	if count < minVertices {
+		count = minVertices
+	}
+	m = m.Decimate(count)
+
+	start := points[0]
+	if closed {
+		// The starting point may have been removed.
+		for _, p := range points {
+			if len(m.Find(p)) > 0 {
+				start = p
+				break
+			}
+		}
+	}
+	res := []Coord{start}
+	for {
+		next := m.Find(res[len(res)-1])
+		var found bool
+		for _, seg := range next {
+			if seg[0] == res[len(res)-1] {
+				res = append(res, seg[1])
+				found = true
+				break
+			}
+		}
+		if !found || res[len(res)-1] == start {
+			break
+		}
+	}
+	if closed {
+		res = res[:len(res)-1]
+	}
+	return res
+}
+
+// vectorizeRevertCollisions restores the original points
+// of any simplified chains which cross themselves or other
+// chains, until no chains cross.
+func vectorizeRevertCollisions(chains []*vectorizeChain, simplified [][]Coord) {
+	reverted := make([]bool, len(chains))
+	for {
+		var changed bool
+		for _, idx := range vectorizeCollidingChains(chains, simplified) {
+			if !reverted[idx] {
+				reverted[idx] = true
+				simplified[idx] = chains[idx].Points
+				changed = true
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// vectorizeCollidingChains finds the indices of chains with
+// segments that cross other segments.
+func vectorizeCollidingChains(chains []*vectorizeChain, simplified [][]Coord) []int {
+	var segs []*Segment
+	var segChains []int
+	cells := map[[2]int][]int{}
+	for i, points := range simplified {
+		numSegs := len(points) - 1
+		if chains[i].Closed {
+			numSegs++
+		}
+		for j := 0; j < numSegs; j++ {
+			seg := &Segment{points[j], points[(j+1)%len(points)]}
+			vectorizeSegmentCells(seg, func(key [2]int) {
+				cells[key] = append(cells[key], len(segs))
+			})
+			segs = append(segs, seg)
+			segChains = append(segChains, i)
+		}
+	}
+
+	colliding := map[int]bool{}
+	for _, cell := range cells {
+		for j, idx1 := range cell {
+			for _, idx2 := range cell[j+1:] {
+				c1, c2 := segChains[idx1], segChains[idx2]
+				if colliding[c1] && colliding[c2] {
+					continue
+				}
+				if vectorizeSegmentsCross(segs[idx1], segs[idx2]) {
+					colliding[c1] = true
+					colliding[c2] = true
+				}
+			}
+		}
+	}
+	res := make([]int, 0, len(colliding))
+	for idx := range colliding {
+		res = append(res, idx)
+	}
+	return res
+}
+
+// vectorizeSegmentCells calls f with every unit grid cell
+// that a segment passes through, including cells which it
+// only touches along their boundaries.
+//
+// Only the cells along the segment are visited, rather
+// than every cell in its bounding box, so long diagonal
+// segments visit a number of cells proportional to their
+// length.
+func vectorizeSegmentCells(seg *Segment, f func(key [2]int)) {
+	// Pad the cells slightly so that crossing segments
+	// always share a cell, despite rounding errors.
+	const epsilon = 1e-8
+
+	min, max := seg.Min(), seg.Max()
+	d := seg[1].Sub(seg[0])
+	for x := int(math.Floor(min.X)); x <= int(math.Floor(max.X)); x++ {
+		minY, maxY := min.Y, max.Y
+		if d.X != 0 {
+			// Find the part of the segment in this column.
+			x1 := math.Max(float64(x), min.X)
+			x2 := math.Min(float64(x+1), max.X)
+			y1 := seg[0].Y + d.Y*(x1-seg[0].X)/d.X
+			y2 := seg[0].Y + d.Y*(x2-seg[0].X)/d.X
+			minY = math.Max(minY, math.Min(y1, y2)-epsilon)
+			maxY = math.Min(maxY, math.Max(y1, y2)+epsilon)
+		}
+		for y := int(math.Floor(minY)); y <= int(math.Floor(maxY)); y++ {
+			f([2]int{x, y})
+		}
+	}
+}
+
+// vectorizeSegmentsCross checks if two segments intersect
+// anywhere other than at a shared endpoint.
+func vectorizeSegmentsCross(s1, s2 *Segment) bool {
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			if s1[i] == s2[j] {
+				// Segments which share an endpoint only cross
+				// if they overlap.
+				u := s1[1-i].Sub(s1[i])
+				v := s2[1-j].Sub(s2[j])
+				return math.Abs(det(u, v)) <= 1e-12*u.Norm()*v.Norm() && u.Dot(v) > 0
+			}
+		}
+	}
+	d1 := s1[1].Sub(s1[0])
+	d2 := s2[1].Sub(s2[0])
+	o1 := det(d1, s2[0].Sub(s1[0]))
+	o2 := det(d1, s2[1].Sub(s1[0]))
+	o3 := det(d2, s1[0].Sub(s2[0]))
+	o4 := det(d2, s1[1].Sub(s2[0]))
+	if o1*o2 > 0 || o3*o4 > 0 {
+		return false
+	}
+	if o1 == 0 && o2 == 0 {
+		// The segments are co-linear.
+		t1 := s2[0].Sub(s1[0]).Dot(d1)
+		t2 := s2[1].Sub(s1[0]).Dot(d1)
+		return math.Max(t1, t2) >= 0 && math.Min(t1, t2) <= d1.Dot(d1)
+	}
+	return true
+}
+
+// A vectorizeChain is a polyline along the border between
+// two regions.
+type vectorizeChain struct {
+	Points []Coord
+	Closed bool
+
+	// Right and Left are the labels of the regions on
+	// either side of the chain, or -1 outside the image.
+	Right int
+	Left  int
+}
+
+type vectorizeEdge struct {
+	P1    Coord
+	P2    Coord
+	Right int
+	Left  int
+}
+
+type vectorizeGrid struct {
+	Width  int
+	Height int
+	Labels []int
+}
+
+func (v *vectorizeGrid) Label(x, y int) int {
+	if x < 0 || y < 0 || x >= v.Width || y >= v.Height {
+		return -1
+	}
+	return v.Labels[x+y*v.Width]
+}
+
+// Chains traces the borders between all pairs of regions,
+// splitting them at points where three or more regions
+// meet and at the corners of the image.
+func (v *vectorizeGrid) Chains() []*vectorizeChain {
+	edges := v.edges()
+	vertexEdges := map[Coord][]int{}
+	for i, e := range edges {
+		vertexEdges[e.P1] = append(vertexEdges[e.P1], i)
+		vertexEdges[e.P2] = append(vertexEdges[e.P2], i)
+	}
+	isJunction := func(c Coord) bool {
+		if len(vertexEdges[c]) != 2 {
+			return true
+		}
+		return (c.X == 0 || c.X == float64(v.Width)) && (c.Y == 0 || c.Y == float64(v.Height))
+	}
+
+	used := make([]bool, len(edges))
+	traceFrom := func(start Coord, edgeIdx int) *vectorizeChain {
+		e := edges[edgeIdx]
+		chain := &vectorizeChain{Points: []Coord{start}, Right: e.Right, Left: e.Left}
+		if e.P1 != start {
+			chain.Right, chain.Left = e.Left, e.Right
+		}
+		cur := start
+		for {
+			used[edgeIdx] = true
+			e := edges[edgeIdx]
+			if e.P1 == cur {
+				cur = e.P2
+			} else {
+				cur = e.P1
+			}
+			if cur == start && !isJunction(cur) {
+				chain.Closed = true
+				break
+			}
+			chain.Points = append(chain.Points, cur)
+			if isJunction(cur) {
+				break
+			}
+			for _, idx := range vertexEdges[cur] {
+				if !used[idx] {
+					edgeIdx = idx
+					break
+				}
+			}
+		}
+		return chain
+	}
+
+	var chains []*vectorizeChain
+	for _, e := range edges {
+		for _, p := range []Coord{e.P1, e.P2} {
+			if !isJunction(p) {
+				continue
+			}
+			for _, idx := range vertexEdges[p] {
+				if !used[idx] {
+					chains = append(chains, traceFrom(p, idx))
+				}
+			}
+		}
+	}
+	for i, e := range edges {
+		if !used[i] {
+			chains = append(chains, traceFrom(e.P1, i))
+		}
+	}
+	return chains
+}
+
+// edges finds all pixel edges between different labels.
+//
+// Each edge is oriented so that its Right label is on the
+// right side, following the winding of Bitmap.Mesh().
+func (v *vectorizeGrid) edges() []*vectorizeEdge {
+	var res []*vectorizeEdge
+	for y := 0; y <= v.Height; y++ {
+		for x := 0; x <= v.Width; x++ {
+			// Horizontal edge from (x, y) to (x+1, y).
+			if x < v.Width {
+				right, left := v.Label(x, y-1), v.Label(x, y)
+				if right != left {
+					res = append(res, &vectorizeEdge{
+						P1:    v.vertex(x, y, x, y-1, x, y),
+						P2:    v.vertex(x+1, y, x, y-1, x, y),
+						Right: right,
+						Left:  left,
+					})
+				}
+			}
+			// Vertical edge from (x, y) to (x, y+1).
+			if y < v.Height {
+				right, left := v.Label(x, y), v.Label(x-1, y)
+				if right != left {
+					res = append(res, &vectorizeEdge{
+						P1:    v.vertex(x, y, x-1, y, x, y),
+						P2:    v.vertex(x, y+1, x-1, y, x, y),
+						Right: right,
+						Left:  left,
+					})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// vertex computes the position of a grid vertex for an
+// edge between two pixels touching the vertex.
+//
+// When a label touches itself diagonally at the vertex,
+// the vertex is split in two, connecting the diagonal
+// pixels so that no region has a singular vertex.
+func (v *vectorizeGrid) vertex(x, y, px1, py1, px2, py2 int) Coord {
+	c := XY(float64(x), float64(y))
+	tl, tr := v.Label(x-1, y-1), v.Label(x, y-1)
+	bl, br := v.Label(x-1, y), v.Label(x, y)
+
+	var connected int
+	diag1 := tl == br && tr != tl && bl != tl
+	diag2 := tr == bl && tl != tr && br != tr
+	if diag1 && diag2 {
+		connected = tl
+		if tr < tl {
+			connected = tr
+		}
+	} else if diag1 {
+		connected = tl
+	} else if diag2 {
+		connected = tr
+	} else {
+		return c
+	}
+
+	px, py := px1, py1
+	if v.Label(px, py) == connected {
+		px, py = px2, py2
+	}
+	return c.Mid(XY(float64(px)+0.5, float64(py)+0.5))
+}
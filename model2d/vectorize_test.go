@@ -0,0 +1,250 @@
+package model2d
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestImageVectorizer(t *testing.T) {
+	t.Run("Exact", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		for i := 0; i < 10; i++ {
+			img, colors := testingVectorizeImage(rng)
+			v := &ImageVectorizer{NumColors: len(colors)}
+			res := v.Vectorize(img)
+			checkVectorImage(t, img, res, rng)
+
+			solids := res.Solids()
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					c := XY(float64(x)+0.5, float64(y)+0.5)
+					for i, s := range solids {
+						if s.Contains(c) && res.Colors[i] != img.RGBAAt(x, y) {
+							t.Fatalf("pixel %d,%d: expected color %v but got %v", x, y,
+								img.RGBAAt(x, y), res.Colors[i])
+						}
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Decimate", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 10; i++ {
+			img, colors := testingVectorizeImage(rng)
+			v := &ImageVectorizer{
+				NumColors:        len(colors),
+				DecimateFraction: 0.3,
+			}
+			checkVectorImage(t, img, v.Vectorize(img), rng)
+		}
+	})
+
+	t.Run("Fit", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(2))
+		for i := 0; i < 4; i++ {
+			img, colors := testingVectorizeImage(rng)
+			v := &ImageVectorizer{
+				NumColors: len(colors),
+				Fitter:    &BezierFitter{AbsTolerance: 0.05, NumIters: 10},
+			}
+			checkVectorImage(t, img, v.Vectorize(img), rng)
+		}
+	})
+
+	t.Run("FitDecimate", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(3))
+		for i := 0; i < 4; i++ {
+			img, colors := testingVectorizeImage(rng)
+			v := &ImageVectorizer{
+				NumColors:        len(colors),
+				DecimateFraction: 0.5,
+				Fitter:           &BezierFitter{AbsTolerance: 0.05, NumIters: 10},
+			}
+			checkVectorImage(t, img, v.Vectorize(img), rng)
+		}
+	})
+}
+
+func TestImageVectorizerFitSize(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	bmp := NewBitmap(64, 64)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			inside := XY(float64(x)+0.5, float64(y)+0.5).Dist(XY(32, 32)) < 24
+			bmp.Set(x, y, inside)
+			if inside {
+				img.SetRGBA(x, y, red)
+			} else {
+				img.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+	diskVertices := func(v *VectorImage) int {
+		for i, c := range v.Colors {
+			if c == red {
+				return v.Meshes[i].NumSegments()
+			}
+		}
+		t.Fatal("missing disk region")
+		return 0
+	}
+	rawVertices := diskVertices((&ImageVectorizer{}).Vectorize(img))
+
+	for i, c := range []struct {
+		Fitter      *BezierFitter
+		MaxFraction float64
+	}{
+		// A loose fit should greatly simplify the border.
+		{&BezierFitter{AbsTolerance: 0.05, NumIters: 10}, 0.6},
+
+		// A tight fit produces short curves with more
+		// vertices than the border itself.
+		{&BezierFitter{NumIters: 2}, 1},
+	} {
+		// Fitting should cost about as much as fitting the
+		// border directly.
+		start := time.Now()
+		c.Fitter.Fit(bmp.Mesh())
+		fitTime := time.Since(start)
+
+		start = time.Now()
+		res := (&ImageVectorizer{Fitter: c.Fitter}).Vectorize(img)
+		vectorizeTime := time.Since(start)
+
+		if n := diskVertices(res); float64(n) > c.MaxFraction*float64(rawVertices) {
+			t.Errorf("case %d: expected at most %d vertices but got %d", i,
+				int(c.MaxFraction*float64(rawVertices)), n)
+		}
+		if vectorizeTime > 3*fitTime+100*time.Millisecond {
+			t.Errorf("case %d: vectorizing took %v but fitting took %v", i,
+				vectorizeTime, fitTime)
+		}
+	}
+}
+
+func TestVectorizeSegmentCells(t *testing.T) {
+	cellSet := func(seg *Segment) map[[2]int]bool {
+		res := map[[2]int]bool{}
+		vectorizeSegmentCells(seg, func(key [2]int) {
+			res[key] = true
+		})
+		return res
+	}
+
+	if n := len(cellSet(&Segment{XY(0.5, 0.5), XY(1000.5, 700.5)})); n > 3*1000 {
+		t.Errorf("diagonal segment visited too many cells: %d", n)
+	}
+
+	rng := rand.New(rand.NewSource(0))
+	randCoord := func() Coord {
+		// Use a mix of grid-aligned and arbitrary points.
+		if rng.Intn(2) == 0 {
+			return XY(float64(rng.Intn(10)), float64(rng.Intn(10)))
+		}
+		return XY(rng.Float64()*10, rng.Float64()*10)
+	}
+	for i := 0; i < 10000; i++ {
+		s1 := &Segment{randCoord(), randCoord()}
+		s2 := &Segment{randCoord(), randCoord()}
+		if s1[0] == s1[1] || s2[0] == s2[1] || !vectorizeSegmentsCross(s1, s2) {
+			continue
+		}
+		cells1, cells2 := cellSet(s1), cellSet(s2)
+		var shared bool
+		for key := range cells1 {
+			if cells2[key] {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			t.Fatalf("crossing segments %v and %v share no cells", s1, s2)
+		}
+	}
+}
+
+func checkVectorImage(t *testing.T, img image.Image, v *VectorImage, rng *rand.Rand) {
+	t.Helper()
+	if len(v.Meshes) == 0 || len(v.Meshes) != len(v.Colors) {
+		t.Fatalf("unexpected number of regions: %d meshes, %d colors",
+			len(v.Meshes), len(v.Colors))
+	}
+	var totalArea float64
+	for i, m := range v.Meshes {
+		if !m.Manifold() {
+			t.Errorf("region %d: mesh is not manifold", i)
+		}
+		if _, n := m.RepairNormals(1e-8); n != 0 {
+			t.Errorf("region %d: mesh has %d flipped normals", i, n)
+		}
+		totalArea += m.Area()
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	expected := float64(width * height)
+	if math.Abs(totalArea-expected) > 1e-5 {
+		t.Errorf("expected total area %f but got %f", expected, totalArea)
+	}
+
+	// The regions should cover the image without overlaps.
+	solids := v.Solids()
+	for i := 0; i < 2000; i++ {
+		c := XY(rng.Float64()*float64(width), rng.Float64()*float64(height))
+		var count int
+		for _, s := range solids {
+			if s.Contains(c) {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Fatalf("point %v is contained in %d regions", c, count)
+		}
+	}
+}
+
+// testingVectorizeImage creates an image of random
+// overlapping blobs, including a checkerboard patch to
+// create diagonal junctions.
+func testingVectorizeImage(rng *rand.Rand) (*image.RGBA, []color.RGBA) {
+	colors := []color.RGBA{
+		{R: 255, G: 255, B: 255, A: 255},
+		{R: 255, A: 255},
+		{B: 255, A: 255},
+		{G: 255, A: 255},
+	}
+	width, height := 30+rng.Intn(20), 30+rng.Intn(20)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, colors[0])
+		}
+	}
+	for i := 0; i < 6; i++ {
+		c := colors[1+rng.Intn(len(colors)-1)]
+		center := XY(rng.Float64()*float64(width), rng.Float64()*float64(height))
+		radii := XY(2+rng.Float64()*10, 2+rng.Float64()*10)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				d := XY(float64(x)+0.5, float64(y)+0.5).Sub(center).Div(radii)
+				if d.Norm() < 1 {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+	cx, cy := rng.Intn(width-6), rng.Intn(height-6)
+	for y := cy; y < cy+6; y++ {
+		for x := cx; x < cx+6; x++ {
+			if (x+y)%2 == 0 {
+				img.SetRGBA(x, y, colors[3])
+			}
+		}
+	}
+	return img, colors
+}
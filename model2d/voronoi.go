@@ -0,0 +1,215 @@
+package model2d
+
+import "math"
+
+// DelaunayTriangulation computes the Delaunay
+// triangulation of a set of points.
+//
+// The vertices of the resulting triangles are ordered
+// clockwise, and duplicate points are ignored.
+//
+// Triangles along the convex hull with extremely large
+// circumcircles (i.e. nearly degenerate triangles) may be
+// omitted.
+func DelaunayTriangulation(points []Coord) [][3]Coord {
+	if len(points) == 0 {
+		return nil
+	}
+	cdt, _ := pointsCDT(points, points[0], points[0])
+	var result [][3]Coord
+	cdt.IterateTriangles(func(t [3]int) {
+		if t[0] < cdt.numSuper {
+			return
+		}
+		result = append(result, [3]Coord{
+			cdt.Vertices[t[0]], cdt.Vertices[t[2]], cdt.Vertices[t[1]],
+		})
+	})
+	return result
+}
+
+// A VoronoiDiagram stores the cells of the Voronoi diagram
+// of a set of points, clipped to some bounds.
+type VoronoiDiagram struct {
+	Points []Coord
+
+	// Cells contains the cell for each point, which is the
+	// region closer to the point than to any other point,
+	// intersected with the bounds.
+	//
+	// Each cell is a mesh with outward normals, and it may
+	// be empty if the point is outside of the bounds.
+	// Duplicate points share the same cell.
+	Cells []*Mesh
+}
+
+// NewVoronoiDiagram computes the Voronoi diagram of a set
+// of points, where the cells are clipped to the region
+// enclosed by a mesh.
+//
+// The bounds are determined using the non-zero fill rule,
+// as in MeshIntersection.
+func NewVoronoiDiagram(points []Coord, bounds *Mesh) *VoronoiDiagram {
+	result := &VoronoiDiagram{
+		Points: append([]Coord{}, points...),
+		Cells:  make([]*Mesh, len(points)),
+	}
+	if len(points) == 0 {
+		return result
+	}
+	min, max := points[0], points[0]
+	if bounds.NumSegments() > 0 {
+		min, max = bounds.Min(), bounds.Max()
+	}
+	cdt, ids := pointsCDT(points, min, max)
+
+	cellMeshes := map[int]*Mesh{}
+	for i, id := range ids {
+		if cell, ok := cellMeshes[id]; ok {
+			result.Cells[i] = cell
+			continue
+		}
+		var cell *Mesh
+		if id == -1 {
+			cell = NewMesh()
+		} else {
+			cell = MeshIntersection(cdt.VoronoiCell(id), bounds, NonZeroFill)
+		}
+		cellMeshes[id] = cell
+		result.Cells[i] = cell
+	}
+	return result
+}
+
+// NewVoronoiDiagramSolid is like NewVoronoiDiagram, but
+// clips the cells to a solid.
+//
+// The boundary of the solid is approximated using
+// MarchingSquaresSearch with the given delta, unless the
+// solid is a *Rect, in which case it is used exactly.
+func NewVoronoiDiagramSolid(points []Coord, bounds Solid, delta float64) *VoronoiDiagram {
+	var mesh *Mesh
+	if r, ok := bounds.(*Rect); ok {
+		mesh = NewMeshRect(r.MinVal, r.MaxVal)
+	} else {
+		mesh = MarchingSquaresSearch(bounds, delta, 8)
+	}
+	return NewVoronoiDiagram(points, mesh)
+}
+
+// LloydRelaxation repeatedly moves points to the centroids
+// of their Voronoi cells, producing more evenly spaced
+// points.
+//
+// The resulting diagram contains the final points and their
+// cells. Points whose cells are empty are not moved.
+func LloydRelaxation(points []Coord, bounds *Mesh, iters int) *VoronoiDiagram {
+	diagram := NewVoronoiDiagram(points, bounds)
+	for i := 0; i < iters; i++ {
+		diagram = NewVoronoiDiagram(diagram.Centroids(), bounds)
+	}
+	return diagram
+}
+
+// Centroids computes the centroid of each cell, or the
+// original point for empty cells.
+func (v *VoronoiDiagram) Centroids() []Coord {
+	result := make([]Coord, len(v.Points))
+	for i, cell := range v.Cells {
+		if c, ok := meshCentroid(cell); ok {
+			result[i] = c
+		} else {
+			result[i] = v.Points[i]
+		}
+	}
+	return result
+}
+
+// Mesh combines all of the unique cells into one mesh.
+func (v *VoronoiDiagram) Mesh() *Mesh {
+	result := NewMesh()
+	seen := map[*Mesh]bool{}
+	for _, cell := range v.Cells {
+		if !seen[cell] {
+			seen[cell] = true
+			result.AddMesh(cell)
+		}
+	}
+	return result
+}
+
+// meshCentroid computes the centroid of the region inside
+// a mesh with outward normals.
+func meshCentroid(m *Mesh) (Coord, bool) {
+	var area float64
+	var sum Coord
+	m.Iterate(func(s *Segment) {
+		cross := det(s[0], s[1])
+		area += cross
+		sum = sum.Add(s[0].Add(s[1]).Scale(cross))
+	})
+	if area == 0 || math.IsNaN(area) {
+		return Coord{}, false
+	}
+	return sum.Scale(1 / (3 * area)), true
+}
+
+// pointsCDT creates an unconstrained Delaunay
+// triangulation of the points, where the super-triangle
+// contains the bounds.
+//
+// Returns the vertex ID for each point, which is -1 if the
+// point could not be inserted.
+func pointsCDT(points []Coord, min, max Coord) (*cdt, []int) {
+	for _, p := range points {
+		min = min.Min(p)
+		max = max.Max(p)
+	}
+	cdt := newCDT(min, max)
+	ids := make([]int, len(points))
+	for i, p := range points {
+		ids[i] = cdt.InsertPoint(p)
+	}
+	return cdt, ids
+}
+
+// VoronoiCell computes the unclipped Voronoi cell of a
+// vertex in a triangulation that still contains the
+// super-triangle.
+//
+// Since the vertices of the super-triangle are far from
+// the other points, the cell is exact for the region near
+// the points.
+func (c *cdt) VoronoiCell(v int) *Mesh {
+	var polygon []Coord
+	first := c.vertexEdge[v]
+	u := first
+	for {
+		w, ok := c.opposite(v, u)
+		if !ok {
+			break
+		}
+		center, ok := circumcenter(c.Vertices[v], c.Vertices[u], c.Vertices[w])
+		if ok && (len(polygon) == 0 || polygon[len(polygon)-1] != center) {
+			polygon = append(polygon, center)
+		}
+		u = w
+		if u == first {
+			break
+		}
+	}
+	if len(polygon) > 1 && polygon[0] == polygon[len(polygon)-1] {
+		polygon = polygon[:len(polygon)-1]
+	}
+
+	// The circumcenters are counter-clockwise, so they are
+	// reversed to produce outward normals.
+	result := NewMesh()
+	if len(polygon) < 3 {
+		return result
+	}
+	for i, p := range polygon {
+		result.Add(&Segment{polygon[(i+1)%len(polygon)], p})
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package model2d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDelaunayTriangulation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	points := make([]Coord, 200)
+	for i := range points {
+		points[i] = XY(rng.Float64(), rng.Float64())
+	}
+	tris := DelaunayTriangulation(points)
+
+	// Euler's formula gives the triangle count from the
+	// number of points on the convex hull.
+	hull := ConvexHullMesh(points)
+	expected := 2*len(points) - len(hull.VertexSlice()) - 2
+	if len(tris) != expected {
+		t.Errorf("expected %d triangles but got %d", expected, len(tris))
+	}
+	var area float64
+	for _, tri := range tris {
+		area += triangleArea(tri)
+	}
+	if math.Abs(area-hull.Area()) > 1e-8 {
+		t.Errorf("expected area %f but got %f", hull.Area(), area)
+	}
+	for _, tri := range tris {
+		center, _ := circumcenter(tri[0], tri[1], tri[2])
+		radius := center.Dist(tri[0])
+		for _, p := range points {
+			if center.Dist(p) < radius*(1-1e-8) {
+				t.Fatalf("triangle %v contains point %v in its circumcircle", tri, p)
+			}
+		}
+	}
+}
+
+func TestVoronoiDiagram(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	points := make([]Coord, 100)
+	for i := range points {
+		points[i] = XY(rng.Float64()*2-1, rng.Float64()*2-1)
+	}
+	points = append(points, points[0], XY(5, 5))
+	bounds := NewMeshPolar(func(float64) float64 { return 1 }, 100)
+	diagram := NewVoronoiDiagram(points, bounds)
+
+	if diagram.Cells[0] != diagram.Cells[100] {
+		t.Error("duplicate points should share a cell")
+	}
+	if diagram.Cells[101].NumSegments() != 0 {
+		t.Error("expected empty cell for point outside bounds")
+	}
+	totalArea := 0.0
+	for i, cell := range diagram.Cells[:100] {
+		totalArea += cell.Area()
+		if cell.NumSegments() > 0 {
+			if _, n := cell.RepairNormals(1e-8); n != 0 {
+				t.Errorf("cell %d has %d inverted normals", i, n)
+			}
+		}
+	}
+	if math.Abs(totalArea-bounds.Area()) > 1e-8 {
+		t.Errorf("expected total area %f but got %f", bounds.Area(), totalArea)
+	}
+
+	// Every point in a cell should be closest to the
+	// cell's point.
+	for i := 0; i < 1000; i++ {
+		c := XY(rng.Float64()*2-1, rng.Float64()*2-1)
+		closest := 0
+		for j, p := range points {
+			if p.Dist(c) < points[closest].Dist(c) {
+				closest = j
+			}
+		}
+		for j, cell := range diagram.Cells {
+			if cell.NumSegments() == 0 || cell == diagram.Cells[closest] {
+				continue
+			}
+			if cell.Solid().Contains(c) && points[j].Dist(c) > points[closest].Dist(c)+1e-8 {
+				t.Fatalf("point %v should not be in cell %d", c, j)
+			}
+		}
+	}
+}
+
+func TestLloydRelaxation(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	points := make([]Coord, 50)
+	for i := range points {
+		points[i] = XY(rng.Float64(), rng.Float64()).Scale(0.5)
+	}
+	bounds := NewMeshRect(XY(0, 0), XY(1, 1))
+
+	areaVariance := func(d *VoronoiDiagram) float64 {
+		var sum, sqSum float64
+		for _, c := range d.Cells {
+			a := c.Area()
+			sum += a
+			sqSum += a * a
+		}
+		mean := sum / float64(len(d.Cells))
+		return sqSum/float64(len(d.Cells)) - mean*mean
+	}
+
+	initial := NewVoronoiDiagramSolid(points, &Rect{MaxVal: XY(1, 1)}, 0)
+	relaxed := LloydRelaxation(points, bounds, 20)
+	if v1, v2 := areaVariance(initial), areaVariance(relaxed); v2 > v1/10 {
+		t.Errorf("variance did not decrease enough: %f -> %f", v1, v2)
+	}
+	for i, c := range relaxed.Centroids() {
+		if c.Dist(relaxed.Points[i]) > 0.02 {
+			t.Errorf("point %d is far from its centroid", i)
+		}
+	}
+}
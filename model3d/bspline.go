@@ -0,0 +1,311 @@
+// Generated from templates/bspline.template
+
+package model3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/numerical"
+)
+
+// A BSplineCurve is a B-spline curve, or a NURBS curve if
+// it has weights.
+//
+// The curve is evaluated for t in [0, 1], which is mapped
+// linearly to the domain of the knot vector.
+type BSplineCurve struct {
+	Degree int
+
+	// Knots is a non-decreasing knot vector with
+	// len(ControlPoints)+Degree+1 entries.
+	Knots []float64
+
+	ControlPoints []Coord3D
+
+	// Weights is nil for non-rational curves, or otherwise
+	// contains a positive weight for each control point.
+	Weights []float64
+}
+
+// NewBSplineCurve creates a clamped B-spline curve with
+// uniformly spaced knots, which starts at the first
+// control point and ends at the last one.
+//
+// There must be more than degree control points.
+func NewBSplineCurve(degree int, points []Coord3D) *BSplineCurve {
+	return &BSplineCurve{
+		Degree:        degree,
+		Knots:         numerical.ClampedBSplineKnots(degree, len(points)),
+		ControlPoints: append([]Coord3D{}, points...),
+	}
+}
+
+// NewNURBSCurve is like NewBSplineCurve, but creates a
+// rational curve with a weight per control point.
+func NewNURBSCurve(degree int, points []Coord3D, weights []float64) *BSplineCurve {
+	if len(weights) != len(points) {
+		panic("mismatched number of weights")
+	}
+	res := NewBSplineCurve(degree, points)
+	res.Weights = append([]float64{}, weights...)
+	return res
+}
+
+// Rational returns true if the curve has weights.
+func (b *BSplineCurve) Rational() bool {
+	return b.Weights != nil
+}
+
+// Domain gets the range of knot values which correspond to
+// t=0 and t=1.
+func (b *BSplineCurve) Domain() (float64, float64) {
+	return b.Knots[b.Degree], b.Knots[len(b.ControlPoints)]
+}
+
+// Eval evaluates the curve for t in [0, 1].
+func (b *BSplineCurve) Eval(t float64) Coord3D {
+	return b.EvalKnot(b.knotForT(t))
+}
+
+// EvalKnot evaluates the curve at a knot value u within
+// the curve's domain.
+func (b *BSplineCurve) EvalKnot(u float64) Coord3D {
+	h := numerical.BSplineEval(b.Knots, b.Degree, b.homogeneous(), u)
+	p, w := bsplineSplit(h)
+	return p.Scale(1 / w)
+}
+
+// Derivative computes the derivative of the curve with
+// respect to t, for t in [0, 1].
+func (b *BSplineCurve) Derivative(t float64) Coord3D {
+	points := b.homogeneous()
+	dKnots, dPoints := numerical.BSplineDerivative(b.Knots, b.Degree, points)
+	start, end := b.Domain()
+	u := b.knotForT(t)
+	return b.derivative(points, dKnots, dPoints, u).Scale(end - start)
+}
+
+func (b *BSplineCurve) derivative(points []bsplineVec, dKnots []float64, dPoints []bsplineVec,
+	u float64) Coord3D {
+	// For a rational curve C = P/w, the quotient rule gives
+	// C' = (P' - C*w') / w.
+	p, w := bsplineSplit(numerical.BSplineEval(b.Knots, b.Degree, points, u))
+	dp, dw := bsplineSplit(numerical.BSplineEval(dKnots, b.Degree-1, dPoints, u))
+	return dp.Sub(p.Scale(dw / w)).Scale(1 / w)
+}
+
+// ArcLen approximates the length of the curve using
+// Gauss-Legendre quadrature on each knot span.
+func (b *BSplineCurve) ArcLen() float64 {
+	points := b.homogeneous()
+	dKnots, dPoints := numerical.BSplineDerivative(b.Knots, b.Degree, points)
+
+	// 5-point Gauss-Legendre coefficients.
+	weightsAndXs := [5][2]float64{
+		{0.5688888888888889, 0},
+		{0.4786286704993665, -0.5384693101056831},
+		{0.4786286704993665, 0.5384693101056831},
+		{0.2369268850561891, -0.9061798459386640},
+		{0.2369268850561891, 0.9061798459386640},
+	}
+	const subdivisions = 4
+
+	var sum float64
+	for i := b.Degree; i < len(b.ControlPoints); i++ {
+		a, c := b.Knots[i], b.Knots[i+1]
+		if a >= c {
+			continue
+		}
+		size := (c - a) / subdivisions
+		for j := 0; j < subdivisions; j++ {
+			mid := a + size*(float64(j)+0.5)
+			for _, wx := range weightsAndXs {
+				u := mid + wx[1]*size/2
+				sum += wx[0] * size / 2 * b.derivative(points, dKnots, dPoints, u).Norm()
+			}
+		}
+	}
+	return sum
+}
+
+// InsertKnot creates an equivalent curve with an extra
+// knot at u, which must be within the curve's domain.
+//
+// This adds a control point without changing the shape of
+// the curve, which can be used to add local control.
+func (b *BSplineCurve) InsertKnot(u float64) *BSplineCurve {
+	knots, points := numerical.BSplineInsertKnot(b.Knots, b.Degree, b.homogeneous(), u)
+	return b.fromHomogeneous(knots, points)
+}
+
+// BezierPoints splits the curve into Bezier curves, one per
+// non-empty knot span, and returns the control points of
+// each Bezier curve.
+//
+// If the curve is rational, then weights are also returned
+// for each Bezier control point. Otherwise, the weights
+// are nil.
+func (b *BSplineCurve) BezierPoints() ([][]Coord3D, [][]float64) {
+	beziers := numerical.BSplineBezierPoints(b.Knots, b.Degree, b.homogeneous())
+	points := make([][]Coord3D, len(beziers))
+	var weights [][]float64
+	if b.Rational() {
+		weights = make([][]float64, len(beziers))
+	}
+	for i, bezier := range beziers {
+		points[i] = make([]Coord3D, len(bezier))
+		if weights != nil {
+			weights[i] = make([]float64, len(bezier))
+		}
+		for j, h := range bezier {
+			p, w := bsplineSplit(h)
+			points[i][j] = p.Scale(1 / w)
+			if weights != nil {
+				weights[i][j] = w
+			}
+		}
+	}
+	return points, weights
+}
+
+func (b *BSplineCurve) knotForT(t float64) float64 {
+	start, end := b.Domain()
+	return start + math.Max(0, math.Min(1, t))*(end-start)
+}
+
+func (b *BSplineCurve) homogeneous() []bsplineVec {
+	res := make([]bsplineVec, len(b.ControlPoints))
+	for i, c := range b.ControlPoints {
+		w := 1.0
+		if b.Weights != nil {
+			w = b.Weights[i]
+		}
+		res[i] = bsplineJoin(c.Scale(w), w)
+	}
+	return res
+}
+
+func (b *BSplineCurve) fromHomogeneous(knots []float64, points []bsplineVec) *BSplineCurve {
+	res := &BSplineCurve{
+		Degree:        b.Degree,
+		Knots:         knots,
+		ControlPoints: make([]Coord3D, len(points)),
+	}
+	if b.Rational() {
+		res.Weights = make([]float64, len(points))
+	}
+	for i, h := range points {
+		p, w := bsplineSplit(h)
+		res.ControlPoints[i] = p.Scale(1 / w)
+		if res.Weights != nil {
+			res.Weights[i] = w
+		}
+	}
+	return res
+}
+
+// FitBSplineCurve fits a clamped, non-rational B-spline
+// curve to a sequence of points using least squares.
+//
+// The curve passes exactly through the first and last
+// points, and the points are parameterized by their
+// cumulative distance along the sequence.
+//
+// The number of control points must be more than the
+// degree, and no more than the number of points.
+func FitBSplineCurve(points []Coord3D, degree, numControl int) *BSplineCurve {
+	if numControl <= degree {
+		panic("need more than degree control points")
+	} else if numControl > len(points) {
+		panic("need at least as many points as control points")
+	}
+
+	params := make([]float64, len(points))
+	for i := 1; i < len(points); i++ {
+		params[i] = params[i-1] + points[i].Dist(points[i-1])
+	}
+	total := params[len(params)-1]
+	for i := range params {
+		if total == 0 {
+			params[i] = float64(i) / float64(len(params)-1)
+		} else {
+			params[i] /= total
+		}
+	}
+
+	// Place knots so that every knot span contains some of
+	// the parameters.
+	knots := numerical.ClampedBSplineKnots(degree, numControl)
+	d := float64(len(points)) / float64(numControl-degree)
+	for j := 1; j < numControl-degree; j++ {
+		i := int(float64(j) * d)
+		alpha := float64(j)*d - float64(i)
+		knots[degree+j] = (1-alpha)*params[i-1] + alpha*params[i]
+	}
+
+	controlPoints := make([]Coord3D, numControl)
+	controlPoints[0] = points[0]
+	controlPoints[numControl-1] = points[len(points)-1]
+	numFree := numControl - 2
+	if numFree > 0 {
+		// Solve the normal equations for the interior
+		// control points.
+		matrix := make([][]float64, numFree)
+		for i := range matrix {
+			matrix[i] = make([]float64, numFree)
+		}
+		rhs := make([]numerical.Vec3, numFree)
+		for k, u := range params {
+			span := numerical.BSplineKnotSpan(knots, degree, u)
+			basis := numerical.BSplineBasis(knots, degree, span, u)
+			residual := points[k]
+			for j, b := range basis {
+				idx := span - degree + j
+				if idx == 0 || idx == numControl-1 {
+					residual = residual.Sub(controlPoints[idx].Scale(b))
+				}
+			}
+			for j1, b1 := range basis {
+				idx1 := span - degree + j1 - 1
+				if idx1 < 0 || idx1 >= numFree {
+					continue
+				}
+				rhs[idx1] = rhs[idx1].Add(residual.Scale(b1).Array())
+				for j2, b2 := range basis {
+					idx2 := span - degree + j2 - 1
+					if idx2 >= 0 && idx2 < numFree {
+						matrix[idx1][idx2] += b1 * b2
+					}
+				}
+			}
+		}
+		sparse := numerical.NewSparseMatrix(numFree)
+		for i, row := range matrix {
+			for j, x := range row {
+				if x != 0 {
+					sparse.Set(i, j, x)
+				}
+			}
+		}
+		solution := numerical.NewSparseCholesky(sparse).ApplyInverseVec3(rhs)
+		for i, x := range solution {
+			controlPoints[i+1] = NewCoord3DArray(x)
+		}
+	}
+
+	return &BSplineCurve{
+		Degree:        degree,
+		Knots:         knots,
+		ControlPoints: controlPoints,
+	}
+}
+
+type bsplineVec = numerical.Vec4
+
+func bsplineJoin(c Coord3D, w float64) bsplineVec {
+	return bsplineVec{c.X, c.Y, c.Z, w}
+}
+
+func bsplineSplit(v bsplineVec) (Coord3D, float64) {
+	return XYZ(v[0], v[1], v[2]), v[3]
+}
@@ -0,0 +1,218 @@
+package model3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/numerical"
+)
+
+// A BSplineSurface is a tensor-product B-spline surface,
+// or a NURBS surface if it has weights.
+//
+// The surface is evaluated for u and v in [0, 1], which are
+// mapped linearly to the domains of the knot vectors.
+type BSplineSurface struct {
+	DegreeU int
+	DegreeV int
+
+	// KnotsU and KnotsV are non-decreasing knot vectors,
+	// where KnotsU has len(ControlPoints)+DegreeU+1
+	// entries and KnotsV has len(ControlPoints[0])+DegreeV+1
+	// entries.
+	KnotsU []float64
+	KnotsV []float64
+
+	// ControlPoints is a grid of points, where the first
+	// index is along the u axis and the second index is
+	// along the v axis.
+	ControlPoints [][]Coord3D
+
+	// Weights is nil for non-rational surfaces, or
+	// otherwise contains a positive weight for each
+	// control point.
+	Weights [][]float64
+}
+
+// NewBSplineSurface creates a clamped B-spline surface
+// with uniformly spaced knots, which interpolates the
+// corners of the control grid.
+func NewBSplineSurface(degreeU, degreeV int, points [][]Coord3D) *BSplineSurface {
+	grid := make([][]Coord3D, len(points))
+	for i, row := range points {
+		if len(row) != len(points[0]) {
+			panic("control grid must be rectangular")
+		}
+		grid[i] = append([]Coord3D{}, row...)
+	}
+	return &BSplineSurface{
+		DegreeU:       degreeU,
+		DegreeV:       degreeV,
+		KnotsU:        numerical.ClampedBSplineKnots(degreeU, len(points)),
+		KnotsV:        numerical.ClampedBSplineKnots(degreeV, len(points[0])),
+		ControlPoints: grid,
+	}
+}
+
+// NewNURBSSurface is like NewBSplineSurface, but creates a
+// rational surface with a weight per control point.
+func NewNURBSSurface(degreeU, degreeV int, points [][]Coord3D,
+	weights [][]float64) *BSplineSurface {
+	res := NewBSplineSurface(degreeU, degreeV, points)
+	res.Weights = make([][]float64, len(weights))
+	for i, row := range weights {
+		if len(row) != len(points[0]) || len(weights) != len(points) {
+			panic("mismatched number of weights")
+		}
+		res.Weights[i] = append([]float64{}, row...)
+	}
+	return res
+}
+
+// Eval evaluates the surface for u and v in [0, 1].
+func (b *BSplineSurface) Eval(u, v float64) Coord3D {
+	ku := bsplineSurfaceKnot(b.KnotsU, b.DegreeU, len(b.ControlPoints), u)
+	kv := bsplineSurfaceKnot(b.KnotsV, b.DegreeV, len(b.ControlPoints[0]), v)
+	spanU := numerical.BSplineKnotSpan(b.KnotsU, b.DegreeU, ku)
+	spanV := numerical.BSplineKnotSpan(b.KnotsV, b.DegreeV, kv)
+	basisU := numerical.BSplineBasis(b.KnotsU, b.DegreeU, spanU, ku)
+	basisV := numerical.BSplineBasis(b.KnotsV, b.DegreeV, spanV, kv)
+
+	var sum Coord3D
+	var weightSum float64
+	for i, bu := range basisU {
+		row := spanU - b.DegreeU + i
+		for j, bv := range basisV {
+			col := spanV - b.DegreeV + j
+			w := bu * bv
+			if b.Weights != nil {
+				w *= b.Weights[row][col]
+			}
+			sum = sum.Add(b.ControlPoints[row][col].Scale(w))
+			weightSum += w
+		}
+	}
+	return sum.Scale(1 / weightSum)
+}
+
+// Mesh tessellates the surface into a grid of triangles
+// with numU by numV cells.
+//
+// Triangles face in the direction of the cross product of
+// the partial derivatives along u and v.
+//
+// Points on opposite edges of the parameter domain which
+// coincide, as on closed surfaces, are merged, as are
+// edges of the domain which collapse to a point.
+// Degenerate triangles are removed.
+func (b *BSplineSurface) Mesh(numU, numV int) *Mesh {
+	grid := make([][]Coord3D, numU+1)
+	min, max := b.ControlPoints[0][0], b.ControlPoints[0][0]
+	for i := range grid {
+		grid[i] = make([]Coord3D, numV+1)
+		for j := range grid[i] {
+			grid[i][j] = b.Eval(float64(i)/float64(numU), float64(j)/float64(numV))
+			min = min.Min(grid[i][j])
+			max = max.Max(grid[i][j])
+		}
+	}
+	eps := 1e-8 * max.Sub(min).Norm()
+	bsplineMergeGrid(grid, eps)
+
+	mesh := NewMesh()
+	addTriangle := func(t *Triangle) {
+		if t[0] != t[1] && t[1] != t[2] && t[2] != t[0] && t.Area() > eps*eps {
+			mesh.Add(t)
+		}
+	}
+	for i := 0; i < numU; i++ {
+		for j := 0; j < numV; j++ {
+			p00, p10 := grid[i][j], grid[i+1][j]
+			p01, p11 := grid[i][j+1], grid[i+1][j+1]
+			addTriangle(&Triangle{p00, p10, p11})
+			addTriangle(&Triangle{p00, p11, p01})
+		}
+	}
+	return mesh
+}
+
+// SDF approximates the signed distance function of a
+// closed surface using a tessellation with numU by numV
+// cells.
+//
+// The surface's normals, as described in Mesh, should
+// point outward.
+func (b *BSplineSurface) SDF(numU, numV int) FaceSDF {
+	return MeshToSDF(b.Mesh(numU, numV))
+}
+
+// ThickSDF approximates the SDF of a shell around the
+// surface with the given thickness, using a tessellation
+// with numU by numV cells.
+//
+// Unlike SDF, this can be used for open surfaces.
+func (b *BSplineSurface) ThickSDF(numU, numV int, thickness float64) SDF {
+	mesh := b.Mesh(numU, numV)
+	meshSDF := MeshToSDF(mesh)
+	offset := XYZ(1, 1, 1).Scale(thickness / 2)
+	return FuncSDF(mesh.Min().Sub(offset), mesh.Max().Add(offset), func(c Coord3D) float64 {
+		return thickness/2 - math.Abs(meshSDF.SDF(c))
+	})
+}
+
+func bsplineSurfaceKnot(knots []float64, degree, numControl int, t float64) float64 {
+	start, end := knots[degree], knots[numControl]
+	return start + math.Max(0, math.Min(1, t))*(end-start)
+}
+
+// bsplineMergeGrid makes nearly equal points along the
+// boundary of a grid exactly equal.
+func bsplineMergeGrid(grid [][]Coord3D, eps float64) {
+	numU, numV := len(grid)-1, len(grid[0])-1
+	closeTogether := func(points []Coord3D, others []Coord3D) bool {
+		for i, p := range points {
+			if p.Dist(others[i]) > eps {
+				return false
+			}
+		}
+		return true
+	}
+	column := func(j int) []Coord3D {
+		res := make([]Coord3D, len(grid))
+		for i, row := range grid {
+			res[i] = row[j]
+		}
+		return res
+	}
+	repeat := func(p Coord3D, n int) []Coord3D {
+		res := make([]Coord3D, n)
+		for i := range res {
+			res[i] = p
+		}
+		return res
+	}
+
+	// Collapsed edges, such as the poles of a sphere.
+	for _, i := range []int{0, numU} {
+		if closeTogether(grid[i], repeat(grid[i][0], numV+1)) {
+			copy(grid[i], repeat(grid[i][0], numV+1))
+		}
+	}
+	for _, j := range []int{0, numV} {
+		col := column(j)
+		if closeTogether(col, repeat(col[0], numU+1)) {
+			for _, row := range grid {
+				row[j] = col[0]
+			}
+		}
+	}
+
+	// Seams of closed surfaces.
+	if closeTogether(grid[0], grid[numU]) {
+		copy(grid[numU], grid[0])
+	}
+	if closeTogether(column(0), column(numV)) {
+		for _, row := range grid {
+			row[numV] = row[0]
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package model3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBSplineSurfaceSphere(t *testing.T) {
+	surface := testingNURBSSphere()
+
+	for i := 0; i <= 20; i++ {
+		for j := 0; j <= 20; j++ {
+			p := surface.Eval(float64(i)/20, float64(j)/20)
+			if r := p.Norm(); math.Abs(r-1) > 1e-8 {
+				t.Fatalf("unexpected radius %f at point %v", r, p)
+			}
+		}
+	}
+
+	mesh := surface.Mesh(64, 32)
+	if mesh.NeedsRepair() {
+		t.Error("mesh needs repair")
+	}
+	if n := len(mesh.SingularVertices()); n != 0 {
+		t.Errorf("mesh has %d singular vertices", n)
+	}
+	if _, n := mesh.RepairNormals(1e-8); n != 0 {
+		t.Errorf("mesh has %d flipped normals", n)
+	}
+	expected := 4 * math.Pi / 3
+	if v := mesh.Volume(); math.Abs(v-expected) > 0.02 {
+		t.Errorf("expected volume %f but got %f", expected, v)
+	}
+
+	sdf := surface.SDF(64, 32)
+	if d := sdf.SDF(Origin); math.Abs(d-1) > 1e-2 {
+		t.Errorf("expected SDF 1 at origin but got %f", d)
+	}
+	if d := sdf.SDF(XYZ(2, 0, 0)); math.Abs(d+1) > 1e-2 {
+		t.Errorf("expected SDF -1 outside but got %f", d)
+	}
+}
+
+func TestBSplineSurfaceThickSDF(t *testing.T) {
+	surface := NewBSplineSurface(2, 1, [][]Coord3D{
+		{XYZ(0, 0, 0), XYZ(0, 1, 0)},
+		{XYZ(1, 0, 1), XYZ(1, 1, 1)},
+		{XYZ(2, 0, 0), XYZ(2, 1, 0)},
+	})
+	if p := surface.Eval(0.5, 0.5); p.Dist(XYZ(1, 0.5, 0.5)) > 1e-8 {
+		t.Errorf("unexpected midpoint: %v", p)
+	}
+	sdf := surface.ThickSDF(32, 4, 0.2)
+	for _, c := range []Coord3D{XYZ(1, 0.5, 0.45), XYZ(1, 0.5, 0.55), XYZ(0, 0.2, 0.05)} {
+		if d := sdf.SDF(c); d <= 0 {
+			t.Errorf("expected %v to be inside but got SDF %f", c, d)
+		}
+	}
+	for _, c := range []Coord3D{XYZ(1, 0.5, 0.35), XYZ(1, 0.5, 0.65), XYZ(1, 1.2, 0.5)} {
+		if d := sdf.SDF(c); d >= 0 {
+			t.Errorf("expected %v to be outside but got SDF %f", c, d)
+		}
+	}
+}
+
+func testingNURBSSphere() *BSplineSurface {
+	r2 := math.Sqrt2 / 2
+	circle := [][2]float64{
+		{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0},
+		{-1, -1}, {0, -1}, {1, -1}, {1, 0},
+	}
+	circleWeights := []float64{1, r2, 1, r2, 1, r2, 1, r2, 1}
+
+	// Semicircle from the south pole to the north pole,
+	// with (radius, z) coordinates.
+	arc := [][2]float64{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}}
+	arcWeights := []float64{1, r2, 1, r2, 1}
+
+	points := make([][]Coord3D, len(circle))
+	weights := make([][]float64, len(circle))
+	for i, c := range circle {
+		points[i] = make([]Coord3D, len(arc))
+		weights[i] = make([]float64, len(arc))
+		for j, a := range arc {
+			points[i][j] = XYZ(c[0]*a[0], c[1]*a[0], a[1])
+			weights[i][j] = circleWeights[i] * arcWeights[j]
+		}
+	}
+	surface := NewNURBSSurface(2, 2, points, weights)
+	surface.KnotsU = []float64{0, 0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1, 1}
+	surface.KnotsV = []float64{0, 0, 0, 0.5, 0.5, 1, 1, 1}
+	return surface
+}
@@ -0,0 +1,149 @@
+// Generated from templates/bspline_test.template
+
+package model3d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBSplineCurveEndpoints(t *testing.T) {
+	points := make([]Coord3D, 7)
+	for i := range points {
+		points[i] = NewCoord3DRandNorm()
+	}
+	for degree := 1; degree < 5; degree++ {
+		curve := NewBSplineCurve(degree, points)
+		if p := curve.Eval(0); p.Dist(points[0]) > 1e-8 {
+			t.Errorf("degree %d: expected start %v but got %v", degree, points[0], p)
+		}
+		if p := curve.Eval(1); p.Dist(points[6]) > 1e-8 {
+			t.Errorf("degree %d: expected end %v but got %v", degree, points[6], p)
+		}
+	}
+}
+
+func TestBSplineCurveCircle(t *testing.T) {
+	curve := NewNURBSCurve(
+		2,
+		[]Coord3D{XYZ(1, 0, 0), XYZ(1, 1, 0), XYZ(0, 1, 0)},
+		[]float64{1, math.Sqrt2 / 2, 1},
+	)
+	for i := 0; i <= 100; i++ {
+		p := curve.Eval(float64(i) / 100)
+		if r := p.Norm(); math.Abs(r-1) > 1e-8 {
+			t.Fatalf("unexpected radius %f at point %v", r, p)
+		}
+	}
+	if l := curve.ArcLen(); math.Abs(l-math.Pi/2) > 1e-5 {
+		t.Errorf("expected arc length %f but got %f", math.Pi/2, l)
+	}
+}
+
+func TestBSplineCurveDerivative(t *testing.T) {
+	points := make([]Coord3D, 6)
+	weights := make([]float64, 6)
+	for i := range points {
+		points[i] = NewCoord3DRandNorm()
+		weights[i] = rand.Float64() + 0.5
+	}
+	for _, curve := range []*BSplineCurve{
+		NewBSplineCurve(3, points),
+		NewNURBSCurve(3, points, weights),
+	} {
+		for i := 0; i < 10; i++ {
+			x := rand.Float64()*0.9 + 0.05
+			const epsilon = 1e-5
+			expected := curve.Eval(x + epsilon).Sub(curve.Eval(x - epsilon)).Scale(0.5 / epsilon)
+			actual := curve.Derivative(x)
+			if actual.Dist(expected) > 1e-4*(1+expected.Norm()) {
+				t.Errorf("rational=%v t=%f: expected %v but got %v", curve.Rational(), x,
+					expected, actual)
+			}
+		}
+	}
+}
+
+func TestBSplineCurveInsertKnot(t *testing.T) {
+	points := make([]Coord3D, 6)
+	weights := make([]float64, 6)
+	for i := range points {
+		points[i] = NewCoord3DRandNorm()
+		weights[i] = rand.Float64() + 0.5
+	}
+	curve := NewNURBSCurve(3, points, weights)
+	inserted := curve.InsertKnot(0.3).InsertKnot(0.3).InsertKnot(0.71)
+	if len(inserted.ControlPoints) != 9 {
+		t.Fatalf("unexpected number of control points: %d", len(inserted.ControlPoints))
+	}
+	for i := 0; i <= 100; i++ {
+		x := float64(i) / 100
+		if p1, p2 := curve.Eval(x), inserted.Eval(x); p1.Dist(p2) > 1e-8 {
+			t.Fatalf("t=%f: expected %v but got %v", x, p1, p2)
+		}
+	}
+}
+
+func TestBSplineCurveBezierPoints(t *testing.T) {
+	points := make([]Coord3D, 7)
+	for i := range points {
+		points[i] = NewCoord3DRandNorm()
+	}
+	curve := NewBSplineCurve(3, points)
+	beziers, weights := curve.BezierPoints()
+	if weights != nil {
+		t.Error("expected nil weights")
+	}
+	if len(beziers) != 4 {
+		t.Fatalf("expected 4 segments but got %d", len(beziers))
+	}
+	for i, bezier := range beziers {
+		for j := 0; j <= 10; j++ {
+			x := float64(j) / 10
+			expected := curve.Eval((float64(i) + x) / 4)
+			actual := evalBezierPoints(bezier, x)
+			if actual.Dist(expected) > 1e-8 {
+				t.Errorf("segment %d t=%f: expected %v but got %v", i, x, expected, actual)
+			}
+		}
+	}
+}
+
+func TestFitBSplineCurve(t *testing.T) {
+	target := func(t float64) Coord3D {
+		return XYZ(math.Cos(t*3), math.Sin(t*2)+t, t*t)
+	}
+	points := make([]Coord3D, 200)
+	for i := range points {
+		points[i] = target(float64(i) / float64(len(points)-1))
+	}
+	curve := FitBSplineCurve(points, 3, 12)
+	if p := curve.Eval(0); p != points[0] {
+		t.Errorf("expected start %v but got %v", points[0], p)
+	}
+	if p := curve.Eval(1); p != points[len(points)-1] {
+		t.Errorf("expected end %v but got %v", points[len(points)-1], p)
+	}
+	for i := 0; i <= 100; i++ {
+		p := curve.Eval(float64(i) / 100)
+		minDist := math.Inf(1)
+		for _, x := range points {
+			minDist = math.Min(minDist, x.Dist(p))
+		}
+		if minDist > 1e-2 {
+			t.Errorf("point %v is %f away from the target", p, minDist)
+		}
+	}
+}
+
+func evalBezierPoints(points []Coord3D, t float64) Coord3D {
+	points = append([]Coord3D{}, points...)
+	for len(points) > 1 {
+		for i := 0; i < len(points)-1; i++ {
+			points[i] = points[i].Scale(1 - t).Add(points[i+1].Scale(t))
+		}
+		points = points[:len(points)-1]
+	}
+	return points[0]
+}
@@ -117,6 +117,41 @@ func WritePLY(w io.Writer, triangles []*Triangle, colorFunc func(Coord3D) [3]uin
 	return nil
 }
 
+// WriteOFF encodes a 3D model as an OFF file.
+func WriteOFF(w io.Writer, triangles []*Triangle) error {
+	coords := [][3]float64{}
+	coordToIdx := NewCoordMap[int]()
+	for _, t := range triangles {
+		for _, p := range t {
+			if _, ok := coordToIdx.Load(p); !ok {
+				coordToIdx.Store(p, len(coords))
+				coords = append(coords, p.Array())
+			}
+		}
+	}
+
+	o, err := fileformats.NewOFFWriter(w, len(coords), len(triangles))
+	if err != nil {
+		return err
+	}
+	for _, c := range coords {
+		if err := o.WriteCoord(c); err != nil {
+			return err
+		}
+	}
+	for _, t := range triangles {
+		idxs := [3]int{
+			coordToIdx.Value(t[0]),
+			coordToIdx.Value(t[1]),
+			coordToIdx.Value(t[2]),
+		}
+		if err := o.WriteTriangle(idxs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteVertexColorOBJ encodes a 3D model as an OBJ file
 // with vertex colors.
 //
@@ -528,3 +563,23 @@ func Write3MFMulti(w io.Writer, unit fileformats.ThreeMFUnit, allMeshes [][]*Tri
 	}
 	return fileformats.Write3MFMeshMulti(w, unit, allCoords, allIndices)
 }
+
+// EncodeDXF encodes the triangles as 3DFACE entities in a
+// DXF file.
+func EncodeDXF(triangles []*Triangle) []byte {
+	var buf bytes.Buffer
+	WriteDXF(&buf, triangles)
+	return buf.Bytes()
+}
+
+// WriteDXF writes the triangles as 3DFACE entities in a
+// DXF file.
+func WriteDXF(w io.Writer, triangles []*Triangle) error {
+	f := &fileformats.DXFFile{Faces: make([]*fileformats.DXF3DFace, len(triangles))}
+	for i, t := range triangles {
+		f.Faces[i] = &fileformats.DXF3DFace{
+			Corners: [4][3]float64{t[0].Array(), t[1].Array(), t[2].Array(), t[2].Array()},
+		}
+	}
+	return f.Write(w)
+}
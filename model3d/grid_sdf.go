@@ -0,0 +1,180 @@
+// Generated from templates/grid_sdf.template
+
+package model3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/numerical"
+)
+
+// A VoxelGridSDF is an SDF computed from a VoxelGrid using
+// an exact Euclidean distance transform.
+//
+// Distances are computed between voxel centers, where a
+// true voxel is half a voxel inside the boundary if it
+// touches a false voxel, and SDF values are trilinearly
+// interpolated between voxel centers.
+//
+// Like VoxelGridToSolid, the grid occupies the
+// box from the origin to (Width, Height, Depth).
+// Voxels outside of the grid are false.
+type VoxelGridSDF struct {
+	// Size of the grid along each axis.
+	size [3]int
+
+	// Values and surface points at voxel centers, padded
+	// by one voxel on each side.
+	values  []float64
+	surface []Coord3D
+}
+
+// VoxelGridToSDF computes the SDF of a grid.
+func VoxelGridToSDF(g *VoxelGrid) *VoxelGridSDF {
+	res := &VoxelGridSDF{
+		size: [3]int{g.Width, g.Height, g.Depth},
+	}
+	padded := res.paddedSize()
+	total := 1
+	for _, s := range padded {
+		total *= s
+	}
+
+	inside := make([]bool, total)
+	outside := make([]bool, total)
+	for i := range inside {
+		p := res.paddedCoords(i)
+		inside[i] = g.Get(p[0]-1, p[1]-1, p[2]-1)
+		outside[i] = !inside[i]
+	}
+	shape := padded[:]
+	outsideDists, outsideNearest := numerical.DistanceTransform(shape, outside)
+	insideDists, insideNearest := numerical.DistanceTransform(shape, inside)
+
+	res.values = make([]float64, total)
+	res.surface = make([]Coord3D, total)
+	center := func(idx int) Coord3D {
+		var arr [3]float64
+		for axis, x := range res.paddedCoords(idx) {
+			arr[axis] = float64(x) - 0.5
+		}
+		return NewCoord3DArray(arr)
+	}
+	for i, in := range inside {
+		sign := 1.0
+		dist, nearest := outsideDists[i], outsideNearest[i]
+		if !in {
+			sign = -1
+			dist, nearest = insideDists[i], insideNearest[i]
+		}
+		c := center(i)
+		if nearest == -1 {
+			// The grid is empty.
+			var sizeArr [3]float64
+			for axis, s := range padded {
+				sizeArr[axis] = float64(s)
+			}
+			res.values[i] = -NewCoord3DArray(sizeArr).Norm()
+			res.surface[i] = c
+			continue
+		}
+		dist = math.Sqrt(dist)
+		res.values[i] = sign * (dist - 0.5)
+		res.surface[i] = center(nearest).Add(c.Sub(center(nearest)).Scale(0.5 / dist))
+	}
+	return res
+}
+
+// Min gets the minimum of the grid's bounds.
+func (g *VoxelGridSDF) Min() Coord3D {
+	return Coord3D{}
+}
+
+// Max gets the maximum of the grid's bounds.
+func (g *VoxelGridSDF) Max() Coord3D {
+	var arr [3]float64
+	for axis, s := range g.size {
+		arr[axis] = float64(s)
+	}
+	return NewCoord3DArray(arr)
+}
+
+// SDF computes the interpolated SDF at a point.
+//
+// Outside of the grid, the SDF is extrapolated as a
+// lower bound on the true SDF.
+func (g *VoxelGridSDF) SDF(c Coord3D) float64 {
+	_, res := g.interp(c, false)
+	return res
+}
+
+// PointSDF computes the interpolated SDF at a point, along
+// with the nearest surface point of the surrounding voxel
+// centers.
+func (g *VoxelGridSDF) PointSDF(c Coord3D) (Coord3D, float64) {
+	return g.interp(c, true)
+}
+
+func (g *VoxelGridSDF) paddedSize() [3]int {
+	var res [3]int
+	for axis, s := range g.size {
+		res[axis] = s + 2
+	}
+	return res
+}
+
+// paddedCoords converts a flat index into the padded grid
+// into coordinates, where the first axis varies fastest.
+func (g *VoxelGridSDF) paddedCoords(idx int) [3]int {
+	var res [3]int
+	for axis, s := range g.paddedSize() {
+		res[axis] = idx % s
+		idx /= s
+	}
+	return res
+}
+
+func (g *VoxelGridSDF) interp(c Coord3D, needPoint bool) (Coord3D, float64) {
+	padded := g.paddedSize()
+	var maxArr [3]float64
+	for axis, s := range padded {
+		maxArr[axis] = float64(s - 1)
+	}
+	gridPoint := c.AddScalar(0.5)
+	clamped := gridPoint.Max(Coord3D{}).Min(NewCoord3DArray(maxArr))
+	extra := gridPoint.Dist(clamped)
+
+	var start [3]int
+	var frac [3]float64
+	for axis, x := range clamped.Array() {
+		start[axis] = int(math.Min(math.Floor(x), float64(padded[axis]-2)))
+		frac[axis] = x - float64(start[axis])
+	}
+
+	var value float64
+	var point Coord3D
+	closest := math.Inf(1)
+	for corner := 0; corner < 1<<3; corner++ {
+		weight := 1.0
+		idx := 0
+		stride := 1
+		for axis := range start {
+			offset := (corner >> axis) & 1
+			if offset == 0 {
+				weight *= 1 - frac[axis]
+			} else {
+				weight *= frac[axis]
+			}
+			idx += (start[axis] + offset) * stride
+			stride *= padded[axis]
+		}
+		value += weight * g.values[idx]
+		if needPoint {
+			if d := g.surface[idx].Dist(c); d < closest {
+				closest = d
+				point = g.surface[idx]
+			}
+		}
+	}
+	return point, value - extra
+}
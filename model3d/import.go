@@ -64,6 +64,26 @@ func ReadOFF(r io.Reader) ([]*Triangle, error) {
 	return triangles, nil
 }
 
+// ReadOBJ decodes the faces of a Wavefront obj file,
+// ignoring materials, texture coordinates, and normals.
+func ReadOBJ(r io.Reader) ([]*Triangle, error) {
+	obj, err := fileformats.ReadOBJFile(r)
+	if err != nil {
+		return nil, err
+	}
+	var triangles []*Triangle
+	for _, group := range obj.FaceGroups {
+		for _, face := range group.Faces {
+			t := &Triangle{}
+			for i, v := range face {
+				t[i] = NewCoord3DArray(obj.Vertices[v[0]-1])
+			}
+			triangles = append(triangles, t)
+		}
+	}
+	return triangles, nil
+}
+
 // ReadColorPLY decodes a PLY file with vertex colors.
 func ReadColorPLY(r io.Reader) ([]*Triangle, *CoordMap[[3]uint8], error) {
 	tris, colors, err := readColorPLY(r)
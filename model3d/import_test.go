@@ -58,3 +58,39 @@ func TestImportOFF(t *testing.T) {
 		t.Errorf("incorrect area: %f", area)
 	}
 }
+
+func TestImportOBJ(t *testing.T) {
+	mesh := NewMeshRect(XYZ(1, 2, 3), XYZ(2, 4, 5))
+	var buf bytes.Buffer
+	obj := BuildVertexColorOBJ(mesh.TriangleSlice(), func(c Coord3D) [3]float64 {
+		return [3]float64{1, 0, 0}
+	})
+	if err := obj.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadOBJ(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(mesh.TriangleSlice()) {
+		t.Fatalf("expected %d triangles but got %d", len(mesh.TriangleSlice()), len(decoded))
+	}
+	decodedMesh := NewMeshTriangles(decoded)
+	if math.Abs(decodedMesh.Volume()-4) > 1e-5 {
+		t.Errorf("incorrect volume: %f", decodedMesh.Volume())
+	}
+
+	quads := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\n# comment\nf 1/1/1 2 3 -1\n"
+	decoded, err = ReadOBJ(bytes.NewReader([]byte(quads)))
+	if err == nil {
+		t.Error("expected error for out-of-bounds texture index")
+	}
+	quads = "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\n# comment\nf 1 2 3 -1\n"
+	decoded, err = ReadOBJ(bytes.NewReader([]byte(quads)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area := NewMeshTriangles(decoded).Area(); len(decoded) != 2 || math.Abs(area-1) > 1e-8 {
+		t.Errorf("unexpected quad triangulation: %d triangles with area %f", len(decoded), area)
+	}
+}
@@ -0,0 +1,257 @@
+package model3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/essentials"
+)
+
+// MassProperties describes the distribution of mass in a
+// rigid body.
+type MassProperties struct {
+	Mass   float64
+	Volume float64
+
+	CenterOfMass Coord3D
+
+	// Inertia is the inertia tensor about the center of
+	// mass.
+	Inertia Matrix3
+}
+
+// MassProperties computes the exact mass properties of a
+// closed mesh with uniform density, using the divergence
+// theorem.
+//
+// This assumes that the mesh is manifold and the normals
+// are consistent.
+func (m *Mesh) MassProperties(density float64) *MassProperties {
+	var volume float64
+	var firstMoment Coord3D
+	var secondMoment Matrix3
+	m.Iterate(func(t *Triangle) {
+		// Integrate over the tetrahedron between the origin
+		// and the triangle, with a signed volume.
+		mat := Matrix3{
+			t[0].X, t[0].Y, t[0].Z,
+			t[1].X, t[1].Y, t[1].Z,
+			t[2].X, t[2].Y, t[2].Z,
+		}
+		v := mat.Det() / 6
+		sum := t[0].Add(t[1]).Add(t[2])
+		volume += v
+		firstMoment = firstMoment.Add(sum.Scale(v / 4))
+		outer := NewMatrix3Outer(sum)
+		for _, p := range t {
+			outer = outer.Add(NewMatrix3Outer(p))
+		}
+		for i := range secondMoment {
+			secondMoment[i] += outer[i] * v / 20
+		}
+	})
+	if volume < 0 {
+		// The normals point inward.
+		volume = -volume
+		firstMoment = firstMoment.Scale(-1)
+		for i := range secondMoment {
+			secondMoment[i] = -secondMoment[i]
+		}
+	}
+	if volume == 0 {
+		return &MassProperties{}
+	}
+	return newMassProperties(density, volume, firstMoment, &secondMoment)
+}
+
+// MonteCarloMassProperties estimates the mass properties
+// of a solid with uniform density by sampling random
+// points in its bounding box.
+func MonteCarloMassProperties(s Solid, density float64, numSamples int) *MassProperties {
+	min, max := s.Min(), s.Max()
+	boxVolume := max.Sub(min).X * max.Sub(min).Y * max.Sub(min).Z
+	if boxVolume == 0 || numSamples == 0 {
+		return &MassProperties{}
+	}
+
+	var count int
+	var firstMoment Coord3D
+	var secondMoment Matrix3
+	for i := 0; i < numSamples; i++ {
+		c := NewCoord3DRandBounds(min, max)
+		if s.Contains(c) {
+			count++
+			firstMoment = firstMoment.Add(c)
+			secondMoment = *secondMoment.Add(NewMatrix3Outer(c))
+		}
+	}
+	if count == 0 {
+		return &MassProperties{}
+	}
+	sampleVolume := boxVolume / float64(numSamples)
+	secondMoment.Scale(sampleVolume)
+	return newMassProperties(density, sampleVolume*float64(count),
+		firstMoment.Scale(sampleVolume), &secondMoment)
+}
+
+// VoxelMassProperties estimates the mass properties of a
+// solid with uniform density by checking the centers of
+// cubic voxels with side length delta.
+//
+// Each voxel inside the solid contributes the mass and
+// inertia of a solid cube.
+func VoxelMassProperties(s Solid, density, delta float64) *MassProperties {
+	min, max := s.Min(), s.Max()
+	size := max.Sub(min)
+	nx := essentials.MaxInt(1, int(math.Ceil(size.X/delta)))
+	ny := essentials.MaxInt(1, int(math.Ceil(size.Y/delta)))
+	nz := essentials.MaxInt(1, int(math.Ceil(size.Z/delta)))
+
+	// Center the voxel grid within the bounding box.
+	start := min.Add(size.Sub(XYZ(float64(nx), float64(ny), float64(nz)).Scale(delta)).Scale(0.5))
+	start = start.Add(XYZ(delta, delta, delta).Scale(0.5))
+
+	type sliceSums struct {
+		Count        int
+		FirstMoment  Coord3D
+		SecondMoment Matrix3
+	}
+	slices := make([]sliceSums, nz)
+	essentials.ConcurrentMap(0, nz, func(k int) {
+		sums := &slices[k]
+		for i := 0; i < nx; i++ {
+			for j := 0; j < ny; j++ {
+				c := start.Add(XYZ(float64(i), float64(j), float64(k)).Scale(delta))
+				if s.Contains(c) {
+					sums.Count++
+					sums.FirstMoment = sums.FirstMoment.Add(c)
+					sums.SecondMoment = *sums.SecondMoment.Add(NewMatrix3Outer(c))
+				}
+			}
+		}
+	})
+
+	var count int
+	var firstMoment Coord3D
+	var secondMoment Matrix3
+	for _, sums := range slices {
+		count += sums.Count
+		firstMoment = firstMoment.Add(sums.FirstMoment)
+		secondMoment = *secondMoment.Add(&sums.SecondMoment)
+	}
+	if count == 0 {
+		return &MassProperties{}
+	}
+
+	voxelVolume := delta * delta * delta
+	secondMoment.Scale(voxelVolume)
+
+	// Account for the extent of each voxel, since the
+	// second moment of a cube about its center is
+	// delta^2/12 times its volume along each axis.
+	selfMoment := voxelVolume * float64(count) * delta * delta / 12
+	secondMoment[0] += selfMoment
+	secondMoment[4] += selfMoment
+	secondMoment[8] += selfMoment
+
+	return newMassProperties(density, voxelVolume*float64(count),
+		firstMoment.Scale(voxelVolume), &secondMoment)
+}
+
+// CombineMassProperties computes the mass properties of
+// a body made up of several separate parts, which may each
+// have a different density.
+//
+// The parts should not overlap.
+func CombineMassProperties(parts ...*MassProperties) *MassProperties {
+	result := &MassProperties{}
+	for _, p := range parts {
+		result.Mass += p.Mass
+		result.Volume += p.Volume
+		result.CenterOfMass = result.CenterOfMass.Add(p.CenterOfMass.Scale(p.Mass))
+	}
+	if result.Mass == 0 {
+		return result
+	}
+	result.CenterOfMass = result.CenterOfMass.Scale(1 / result.Mass)
+	for _, p := range parts {
+		result.Inertia = *result.Inertia.Add(p.InertiaAbout(result.CenterOfMass))
+	}
+	return result
+}
+
+// newMassProperties creates MassProperties from the
+// integrals of 1, x, and x*x^T over the volume of a body.
+func newMassProperties(density, volume float64, firstMoment Coord3D,
+	secondMoment *Matrix3) *MassProperties {
+	center := firstMoment.Scale(1 / volume)
+
+	// Move the second moment to the center of mass.
+	central := *secondMoment.Add(NewMatrix3Outer(center).scaled(-volume))
+	central.Scale(density)
+
+	return &MassProperties{
+		Mass:         density * volume,
+		Volume:       volume,
+		CenterOfMass: center,
+		Inertia:      *secondMomentToInertia(&central),
+	}
+}
+
+// InertiaAbout computes the inertia tensor about a point
+// using the parallel axis theorem.
+func (m *MassProperties) InertiaAbout(point Coord3D) *Matrix3 {
+	d := m.CenterOfMass.Sub(point)
+	shift := secondMomentToInertia(NewMatrix3Outer(d).scaled(m.Mass))
+	return m.Inertia.Add(shift)
+}
+
+// MomentAboutAxis computes the moment of inertia for
+// rotation around an axis through a point.
+//
+// The axis need not be normalized.
+func (m *MassProperties) MomentAboutAxis(point, axis Coord3D) float64 {
+	axis = axis.Normalize()
+	return axis.Dot(m.InertiaAbout(point).MulColumn(axis))
+}
+
+// PrincipalAxes computes the eigendecomposition of the
+// inertia tensor.
+//
+// The principal axes are returned as the columns of a
+// rotation matrix, and the principal moments are sorted
+// from smallest to largest, so that the first axis is the
+// easiest to spin around.
+func (m *MassProperties) PrincipalAxes() (axes *Matrix3, moments [3]float64) {
+	// The inertia tensor is symmetric and positive
+	// semi-definite, so its SVD is an eigendecomposition.
+	var u, s, v Matrix3
+	m.Inertia.SVD(&u, &s, &v)
+
+	moments = [3]float64{s[8], s[4], s[0]}
+	axes = &Matrix3{
+		u[2], u[1], u[0],
+		u[5], u[4], u[3],
+		u[8], u[7], u[6],
+	}
+	if axes.Det() < 0 {
+		axes[0], axes[3], axes[6] = -axes[0], -axes[3], -axes[6]
+	}
+	return
+}
+
+// secondMomentToInertia converts the integral of x*x^T
+// into an inertia tensor.
+func secondMomentToInertia(m *Matrix3) *Matrix3 {
+	trace := m[0] + m[4] + m[8]
+	return &Matrix3{
+		trace - m[0], -m[1], -m[2],
+		-m[3], trace - m[4], -m[5],
+		-m[6], -m[7], trace - m[8],
+	}
+}
+
+func (m *Matrix3) scaled(s float64) *Matrix3 {
+	res := *m
+	res.Scale(s)
+	return &res
+}
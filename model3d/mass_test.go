@@ -0,0 +1,123 @@
+package model3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeshMassProperties(t *testing.T) {
+	// A box rotated around an axis, so that its principal
+	// axes are not the coordinate axes.
+	size := XYZ(1, 2, 4)
+	center := XYZ(0.5, -1, 2)
+	rotation := NewMatrix3Rotation(XYZ(1, 1, 0).Normalize(), 0.7)
+	box := NewMeshRect(center.Sub(size.Scale(0.5)), center.Add(size.Scale(0.5)))
+	box = box.Transform(JoinedTransform{
+		&Translate{Offset: center.Scale(-1)},
+		&Matrix3Transform{Matrix: rotation},
+		&Translate{Offset: center},
+	})
+
+	density := 2.5
+	props := box.MassProperties(density)
+	if math.Abs(props.Volume-8) > 1e-8 || math.Abs(props.Mass-20) > 1e-8 {
+		t.Errorf("unexpected volume %f or mass %f", props.Volume, props.Mass)
+	}
+	if props.CenterOfMass.Dist(center) > 1e-8 {
+		t.Errorf("unexpected center of mass: %v", props.CenterOfMass)
+	}
+
+	expectedMoments := [3]float64{
+		props.Mass / 12 * (size.Y*size.Y + size.Z*size.Z),
+		props.Mass / 12 * (size.X*size.X + size.Z*size.Z),
+		props.Mass / 12 * (size.X*size.X + size.Y*size.Y),
+	}
+	axes, moments := props.PrincipalAxes()
+	if math.Abs(axes.Det()-1) > 1e-8 {
+		t.Errorf("axes should be a rotation, but determinant is %f", axes.Det())
+	}
+	expectedSorted := [3]float64{expectedMoments[2], expectedMoments[1], expectedMoments[0]}
+	for i, expected := range expectedSorted {
+		if math.Abs(moments[i]-expected) > 1e-6 {
+			t.Errorf("moment %d: expected %f but got %f", i, expected, moments[i])
+		}
+	}
+
+	// The axis with the smallest moment is the rotated Z
+	// axis of the box.
+	expectedAxis := rotation.MulColumn(Z(1))
+	actualAxis := XYZ(axes[0], axes[3], axes[6])
+	if math.Abs(math.Abs(actualAxis.Dot(expectedAxis))-1) > 1e-6 {
+		t.Errorf("expected axis %v but got %v", expectedAxis, actualAxis)
+	}
+
+	// Flipping the mesh should not change anything.
+	flipped := NewMesh()
+	box.Iterate(func(t *Triangle) {
+		flipped.Add(&Triangle{t[0], t[2], t[1]})
+	})
+	props1 := flipped.MassProperties(density)
+	if math.Abs(props1.Mass-props.Mass) > 1e-8 ||
+		props1.CenterOfMass.Dist(props.CenterOfMass) > 1e-8 {
+		t.Errorf("unexpected flipped properties: %+v", props1)
+	}
+	for i, x := range props.Inertia {
+		if math.Abs(x-props1.Inertia[i]) > 1e-8 {
+			t.Errorf("unexpected flipped inertia: %v", props1.Inertia)
+			break
+		}
+	}
+}
+
+func TestSolidMassProperties(t *testing.T) {
+	sphere := &Sphere{Center: XYZ(1, 2, 3), Radius: 0.5}
+	volume := 4.0 / 3.0 * math.Pi * math.Pow(sphere.Radius, 3)
+	moment := 0.4 * volume * sphere.Radius * sphere.Radius
+
+	for name, props := range map[string]*MassProperties{
+		"voxel":       VoxelMassProperties(sphere, 1, 0.01),
+		"monte-carlo": MonteCarloMassProperties(sphere, 1, 1000000),
+		"mesh":        MarchingCubesSearch(sphere, 0.01, 8).MassProperties(1),
+	} {
+		if math.Abs(props.Volume-volume)/volume > 0.01 {
+			t.Errorf("%s: expected volume %f but got %f", name, volume, props.Volume)
+		}
+		if props.CenterOfMass.Dist(sphere.Center) > 0.01 {
+			t.Errorf("%s: unexpected center of mass %v", name, props.CenterOfMass)
+		}
+		for i := 0; i < 3; i++ {
+			if math.Abs(props.Inertia[i*4]-moment)/moment > 0.02 {
+				t.Errorf("%s: expected moment %f but got %f", name, moment, props.Inertia[i*4])
+			}
+		}
+		if axisMoment := props.MomentAboutAxis(XYZ(1, 2, 4), X(2)); math.Abs(axisMoment-
+			(moment+props.Mass))/axisMoment > 0.02 {
+			t.Errorf("%s: unexpected moment about shifted axis: %f", name, axisMoment)
+		}
+	}
+}
+
+func TestCombineMassProperties(t *testing.T) {
+	box1 := NewMeshRect(XYZ(0, 0, 0), XYZ(1, 1, 1))
+	box2 := NewMeshRect(XYZ(1, 0, 0), XYZ(3, 1, 1))
+	union := NewMeshRect(XYZ(0, 0, 0), XYZ(3, 1, 1))
+
+	combined := CombineMassProperties(box1.MassProperties(2), box2.MassProperties(2))
+	expected := union.MassProperties(2)
+	if math.Abs(combined.Mass-expected.Mass) > 1e-8 ||
+		combined.CenterOfMass.Dist(expected.CenterOfMass) > 1e-8 {
+		t.Errorf("expected %+v but got %+v", expected, combined)
+	}
+	for i, x := range expected.Inertia {
+		if math.Abs(x-combined.Inertia[i]) > 1e-8 {
+			t.Errorf("expected inertia %v but got %v", expected.Inertia, combined.Inertia)
+			break
+		}
+	}
+
+	// A denser part shifts the center of mass.
+	combined = CombineMassProperties(box1.MassProperties(6), box2.MassProperties(1))
+	if math.Abs(combined.CenterOfMass.X-0.875) > 1e-8 {
+		t.Errorf("unexpected center of mass: %v", combined.CenterOfMass)
+	}
+}
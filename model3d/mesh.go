@@ -751,6 +751,20 @@ func (m *Mesh) Save3MF(path string, units fileformats.ThreeMFUnit) error {
 	return Write3MF(w, units, m.TriangleSlice())
 }
 
+// SaveDXF writes the mesh to a DXF file as 3DFACE
+// entities.
+func (m *Mesh) SaveDXF(path string) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "save DXF")
+	}
+	defer w.Close()
+	if err := WriteDXF(w, m.TriangleSlice()); err != nil {
+		return errors.Wrap(err, "save DXF")
+	}
+	return nil
+}
+
 // TriangleSlice gets a snapshot of all the triangles
 // currently in the mesh. The resulting slice is a copy,
 // and will not change as the mesh is updated.
@@ -0,0 +1,53 @@
+package model3d
+
+// A VoxelGrid is a three-dimensional grid of boolean
+// values.
+//
+// The data is stored with x varying fastest, then y, then
+// z, so that voxel (x, y, z) has index
+// x+y*Width+z*Width*Height.
+type VoxelGrid struct {
+	Data   []bool
+	Width  int
+	Height int
+	Depth  int
+}
+
+// NewVoxelGrid creates an empty voxel grid.
+func NewVoxelGrid(width, height, depth int) *VoxelGrid {
+	return &VoxelGrid{
+		Data:   make([]bool, width*height*depth),
+		Width:  width,
+		Height: height,
+		Depth:  depth,
+	}
+}
+
+// Get gets the value at the coordinate.
+//
+// If the coordinate is out of bounds, false is returned.
+func (v *VoxelGrid) Get(x, y, z int) bool {
+	if x < 0 || y < 0 || z < 0 || x >= v.Width || y >= v.Height || z >= v.Depth {
+		return false
+	}
+	return v.Data[x+v.Width*(y+v.Height*z)]
+}
+
+// Set sets the value at the coordinate.
+//
+// The coordinate must be in bounds.
+func (v *VoxelGrid) Set(x, y, z int, b bool) {
+	if x < 0 || y < 0 || z < 0 || x >= v.Width || y >= v.Height || z >= v.Depth {
+		panic("coordinate out of bounds")
+	}
+	v.Data[x+v.Width*(y+v.Height*z)] = b
+}
+
+// VoxelGridToSolid creates a solid where each true voxel
+// (x, y, z) fills the unit cube starting at (x, y, z).
+func VoxelGridToSolid(v *VoxelGrid) Solid {
+	max := XYZ(float64(v.Width), float64(v.Height), float64(v.Depth))
+	return CheckedFuncSolid(Coord3D{}, max, func(c Coord3D) bool {
+		return v.Get(int(c.X), int(c.Y), int(c.Z))
+	})
+}
@@ -0,0 +1,46 @@
+package model3d
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVoxelGridSDF(t *testing.T) {
+	sphere := &Sphere{Center: XYZ(16, 15, 17), Radius: 10}
+	grid := NewVoxelGrid(32, 30, 34)
+	for z := 0; z < grid.Depth; z++ {
+		for y := 0; y < grid.Height; y++ {
+			for x := 0; x < grid.Width; x++ {
+				c := XYZ(float64(x), float64(y), float64(z)).Add(XYZ(0.5, 0.5, 0.5))
+				grid.Set(x, y, z, sphere.Contains(c))
+			}
+		}
+	}
+	solid := VoxelGridToSolid(grid)
+	sdf := VoxelGridToSDF(grid)
+	if sdf.Min() != solid.Min() || sdf.Max() != solid.Max() {
+		t.Errorf("unexpected bounds: %v, %v", sdf.Min(), sdf.Max())
+	}
+	for i := 0; i < 1000; i++ {
+		c := NewCoord3DRandBounds(XYZ(-5, -5, -5), XYZ(37, 35, 39))
+		expected := sphere.SDF(c)
+		actual := sdf.SDF(c)
+		if math.Abs(expected) < 8 && math.Abs(actual-expected) > 1 {
+			t.Errorf("point %v: expected SDF %f but got %f", c, expected, actual)
+		}
+		if actual > expected+1 {
+			t.Errorf("point %v: SDF %f is not a lower bound of %f", c, actual, expected)
+		}
+		if math.Abs(actual) > 1 && (actual > 0) != solid.Contains(c) {
+			t.Errorf("point %v: SDF %f does not match solid", c, actual)
+		}
+
+		point, pointSDF := sdf.PointSDF(c)
+		if pointSDF != actual {
+			t.Errorf("point %v: mismatched SDFs %f and %f", c, actual, pointSDF)
+		}
+		if math.Abs(expected) < 8 && math.Abs(sphere.SDF(point)) > 1 {
+			t.Errorf("point %v: nearest point %v is not on the surface", c, point)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package numerical
+
+// ClampedBSplineKnots creates a uniform knot vector in the
+// range [0, 1] for a B-spline with the given degree and
+// number of control points.
+//
+// The first and last knots are repeated degree+1 times, so
+// that the curve starts and ends at the first and last
+// control points.
+func ClampedBSplineKnots(degree, numControl int) []float64 {
+	if numControl <= degree {
+		panic("need more than degree control points")
+	}
+	knots := make([]float64, numControl+degree+1)
+	numSpans := numControl - degree
+	for i := range knots {
+		if i <= degree {
+			knots[i] = 0
+		} else if i >= numControl {
+			knots[i] = 1
+		} else {
+			knots[i] = float64(i-degree) / float64(numSpans)
+		}
+	}
+	return knots
+}
+
+// BSplineKnotSpan finds the index i of the knot span such
+// that knots[i] <= u < knots[i+1], where the span is within
+// the valid domain of a B-spline with the given degree.
+//
+// Values of u outside of the domain are clamped to the
+// first or last non-empty span.
+func BSplineKnotSpan(knots []float64, degree int, u float64) int {
+	n := len(knots) - degree - 1
+	if u >= knots[n] {
+		// Use the last non-empty span.
+		i := n - 1
+		for i > degree && knots[i] == knots[n] {
+			i--
+		}
+		return i
+	} else if u <= knots[degree] {
+		i := degree
+		for i < n-1 && knots[i+1] == knots[degree] {
+			i++
+		}
+		return i
+	}
+	low, high := degree, n
+	for high-low > 1 {
+		mid := (low + high) / 2
+		if u < knots[mid] {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return low
+}
+
+// BSplineBasis computes the degree+1 basis functions which
+// are non-zero within a knot span, as computed by
+// BSplineKnotSpan.
+//
+// The i-th result corresponds to control point
+// span-degree+i.
+func BSplineBasis(knots []float64, degree, span int, u float64) []float64 {
+	result := make([]float64, degree+1)
+	left := make([]float64, degree+1)
+	right := make([]float64, degree+1)
+	result[0] = 1
+	for j := 1; j <= degree; j++ {
+		left[j] = u - knots[span+1-j]
+		right[j] = knots[span+j] - u
+		var saved float64
+		for r := 0; r < j; r++ {
+			temp := result[r] / (right[r+1] + left[j-r])
+			result[r] = saved + right[r+1]*temp
+			saved = left[j-r] * temp
+		}
+		result[j] = saved
+	}
+	return result
+}
+
+// BSplineEval evaluates a B-spline at a point u in the
+// domain of the knot vector.
+func BSplineEval[T Vector[T]](knots []float64, degree int, points []T, u float64) T {
+	span := BSplineKnotSpan(knots, degree, u)
+	basis := BSplineBasis(knots, degree, span, u)
+	result := points[0].Zeros()
+	for i, b := range basis {
+		result = result.Add(points[span-degree+i].Scale(b))
+	}
+	return result
+}
+
+// BSplineDerivative computes the knots and control points
+// of the derivative of a B-spline, which is a B-spline of
+// one lower degree.
+//
+// The degree must be at least 1.
+func BSplineDerivative[T Vector[T]](knots []float64, degree int, points []T) ([]float64, []T) {
+	if degree < 1 {
+		panic("cannot differentiate a degree 0 B-spline")
+	}
+	newPoints := make([]T, len(points)-1)
+	for i := range newPoints {
+		denom := knots[i+degree+1] - knots[i+1]
+		if denom == 0 {
+			newPoints[i] = points[i].Zeros()
+		} else {
+			newPoints[i] = points[i+1].Sub(points[i]).Scale(float64(degree) / denom)
+		}
+	}
+	return append([]float64{}, knots[1:len(knots)-1]...), newPoints
+}
+
+// BSplineInsertKnot inserts a knot into a B-spline without
+// changing its shape, returning a new knot vector and new
+// control points.
+//
+// The knot u must be within the domain of the B-spline.
+func BSplineInsertKnot[T Vector[T]](knots []float64, degree int, points []T,
+	u float64) ([]float64, []T) {
+	span := BSplineKnotSpan(knots, degree, u)
+	newKnots := make([]float64, 0, len(knots)+1)
+	newKnots = append(newKnots, knots[:span+1]...)
+	newKnots = append(newKnots, u)
+	newKnots = append(newKnots, knots[span+1:]...)
+
+	newPoints := make([]T, len(points)+1)
+	for i := range newPoints {
+		if i <= span-degree {
+			newPoints[i] = points[i]
+		} else if i > span {
+			newPoints[i] = points[i-1]
+		} else {
+			alpha := (u - knots[i]) / (knots[i+degree] - knots[i])
+			newPoints[i] = points[i-1].Scale(1 - alpha).Add(points[i].Scale(alpha))
+		}
+	}
+	return newKnots, newPoints
+}
+
+// BSplineBezierPoints splits a B-spline into Bezier curves,
+// one per non-empty knot span in its domain.
+//
+// Each Bezier curve has degree+1 control points.
+func BSplineBezierPoints[T Vector[T]](knots []float64, degree int, points []T) [][]T {
+	// Insert every knot in the domain until it has
+	// multiplicity degree, and clamp the ends.
+	n := len(points)
+	var inserts []float64
+	start, end := knots[degree], knots[n]
+	for i := degree; i <= n; i++ {
+		if i > degree && knots[i] == knots[i-1] {
+			continue
+		}
+		mult := 0
+		for j := 0; j < len(knots); j++ {
+			if knots[j] == knots[i] {
+				mult++
+			}
+		}
+		target := degree
+		if knots[i] == start || knots[i] == end {
+			target = degree + 1
+		}
+		for j := mult; j < target; j++ {
+			inserts = append(inserts, knots[i])
+		}
+	}
+	for _, u := range inserts {
+		knots, points = BSplineInsertKnot(knots, degree, points, u)
+	}
+
+	first := 0
+	for knots[first] < start {
+		first++
+	}
+	var result [][]T
+	for i := first; i+degree < len(points) && knots[i+degree] < end; i += degree {
+		result = append(result, append([]T{}, points[i:i+degree+1]...))
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package numerical
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBSplineBasis(t *testing.T) {
+	knots := []float64{0, 0, 0, 0.2, 0.5, 0.5, 0.9, 1, 1, 1}
+	degree := 2
+	numControl := len(knots) - degree - 1
+	for i := 0; i < 100; i++ {
+		u := rand.Float64()
+		span := BSplineKnotSpan(knots, degree, u)
+		if knots[span] > u || knots[span+1] <= u {
+			t.Fatalf("bad span %d for %f", span, u)
+		}
+		basis := BSplineBasis(knots, degree, span, u)
+		var sum float64
+		for j, b := range basis {
+			expected := coxDeBoor(knots, span-degree+j, degree, u)
+			if math.Abs(b-expected) > 1e-8 {
+				t.Errorf("basis %d: expected %f but got %f", j, expected, b)
+			}
+			sum += b
+		}
+		if math.Abs(sum-1) > 1e-8 {
+			t.Errorf("basis does not sum to 1: %f", sum)
+		}
+	}
+	if span := BSplineKnotSpan(knots, degree, 1); span != numControl-1 {
+		t.Errorf("unexpected span at end: %d", span)
+	}
+}
+
+func TestBSplineInsertKnot(t *testing.T) {
+	knots := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	degree := 3
+	points := make([]Vec2, len(knots)-degree-1)
+	for i := range points {
+		points[i] = NewVec2RandomNormal()
+	}
+	newKnots, newPoints := BSplineInsertKnot(knots, degree, points, 4.3)
+	newKnots, newPoints = BSplineInsertKnot(newKnots, degree, newPoints, 4.3)
+	newKnots, newPoints = BSplineInsertKnot(newKnots, degree, newPoints, 3)
+	if len(newKnots) != len(knots)+3 || len(newPoints) != len(points)+3 {
+		t.Fatal("unexpected lengths")
+	}
+	for u := 3.0; u <= 5.0; u += 0.1 {
+		expected := BSplineEval(knots, degree, points, u)
+		actual := BSplineEval(newKnots, degree, newPoints, u)
+		if actual.Dist(expected) > 1e-8 {
+			t.Errorf("at %f: expected %v but got %v", u, expected, actual)
+		}
+	}
+}
+
+func TestBSplineBezierPoints(t *testing.T) {
+	for _, knots := range [][]float64{
+		ClampedBSplineKnots(3, 7),
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{0, 0, 0, 0, 0.3, 0.3, 0.6, 1, 1, 1, 1},
+	} {
+		degree := 3
+		points := make([]Vec3, len(knots)-degree-1)
+		for i := range points {
+			points[i] = NewVec3RandomNormal()
+		}
+		var spans []float64
+		for i := degree; i < len(points); i++ {
+			if knots[i+1] > knots[i] {
+				spans = append(spans, knots[i], knots[i+1])
+			}
+		}
+		beziers := BSplineBezierPoints(knots, degree, points)
+		if len(beziers) != len(spans)/2 {
+			t.Fatalf("expected %d curves but got %d", len(spans)/2, len(beziers))
+		}
+		for i, bezier := range beziers {
+			a, b := spans[2*i], spans[2*i+1]
+			for _, frac := range []float64{0, 0.3, 0.7, 1} {
+				u := a + (b-a)*frac
+				expected := BSplineEval(knots, degree, points, u)
+				actual := evalBezier(bezier, frac)
+				if actual.Dist(expected) > 1e-8 {
+					t.Errorf("curve %d at %f: expected %v but got %v", i, frac, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestBSplineDerivative(t *testing.T) {
+	knots := []float64{0, 0, 0, 0.4, 0.5, 1, 1, 1}
+	degree := 2
+	points := make([]Vec2, len(knots)-degree-1)
+	for i := range points {
+		points[i] = NewVec2RandomNormal()
+	}
+	dKnots, dPoints := BSplineDerivative(knots, degree, points)
+	for _, u := range []float64{0.1, 0.45, 0.7} {
+		eps := 1e-5
+		expected := BSplineEval(knots, degree, points, u+eps).Sub(
+			BSplineEval(knots, degree, points, u-eps),
+		).Scale(1 / (2 * eps))
+		actual := BSplineEval(dKnots, degree-1, dPoints, u)
+		if actual.Dist(expected) > 1e-5 {
+			t.Errorf("at %f: expected %v but got %v", u, expected, actual)
+		}
+	}
+}
+
+func coxDeBoor(knots []float64, i, p int, u float64) float64 {
+	if p == 0 {
+		if knots[i] <= u && u < knots[i+1] {
+			return 1
+		}
+		return 0
+	}
+	var res float64
+	if knots[i+p] > knots[i] {
+		res += (u - knots[i]) / (knots[i+p] - knots[i]) * coxDeBoor(knots, i, p-1, u)
+	}
+	if knots[i+p+1] > knots[i+1] {
+		res += (knots[i+p+1] - u) / (knots[i+p+1] - knots[i+1]) * coxDeBoor(knots, i+1, p-1, u)
+	}
+	return res
+}
+
+func evalBezier(points []Vec3, t float64) Vec3 {
+	d := append([]Vec3{}, points...)
+	for r := 1; r < len(d); r++ {
+		for j := 0; j < len(d)-r; j++ {
+			d[j] = d[j].Scale(1 - t).Add(d[j+1].Scale(t))
+		}
+	}
+	return d[0]
+}
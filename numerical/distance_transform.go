@@ -0,0 +1,115 @@
+package numerical
+
+import "math"
+
+// DistanceTransform computes the exact squared Euclidean
+// distance from every cell of an N-dimensional grid to the
+// nearest feature cell.
+//
+// The grid has the given shape and is stored with the
+// first dimension varying fastest, so a 2D grid is indexed
+// as x+y*shape[0].
+//
+// For each cell, the squared distance and the flat index of
+// the nearest feature are returned.
+// If there are no features, every distance is infinite and
+// every index is -1.
+//
+// This uses the separable algorithm of Felzenszwalb and
+// Huttenlocher, which runs in linear time.
+func DistanceTransform(shape []int, features []bool) ([]float64, []int) {
+	size := 1
+	for _, s := range shape {
+		size *= s
+	}
+	if len(features) != size {
+		panic("features do not match shape")
+	}
+	dists := make([]float64, size)
+	nearest := make([]int, size)
+	for i, f := range features {
+		if f {
+			nearest[i] = i
+		} else {
+			dists[i] = math.Inf(1)
+			nearest[i] = -1
+		}
+	}
+
+	stride := 1
+	for _, n := range shape {
+		lineDists := make([]float64, n)
+		lineNearest := make([]int, n)
+		for start := 0; start < size; start++ {
+			if (start/stride)%n != 0 {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				lineDists[i] = dists[start+i*stride]
+				lineNearest[i] = nearest[start+i*stride]
+			}
+			d, idx := DistanceTransform1D(lineDists)
+			for i := 0; i < n; i++ {
+				dists[start+i*stride] = d[i]
+				if idx[i] == -1 {
+					nearest[start+i*stride] = -1
+				} else {
+					nearest[start+i*stride] = lineNearest[idx[i]]
+				}
+			}
+		}
+		stride *= n
+	}
+	return dists, nearest
+}
+
+// DistanceTransform1D computes the lower envelope of
+// parabolas rooted at each index, so that the i-th output
+// is the minimum over j of (i-j)^2 + f[j].
+//
+// The index j of the minimum is also returned for each i.
+// Infinite values of f are ignored, and if every value is
+// infinite, the result is infinite with indices of -1.
+func DistanceTransform1D(f []float64) ([]float64, []int) {
+	// Locations of the parabolas in the lower envelope,
+	// and the left boundary of each parabola's range.
+	var locs []int
+	var bounds []float64
+	for q, fq := range f {
+		if math.IsInf(fq, 1) {
+			continue
+		}
+		s := math.Inf(-1)
+		for len(locs) > 0 {
+			p := locs[len(locs)-1]
+			s = ((fq + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*(q-p))
+			if s <= bounds[len(bounds)-1] {
+				locs = locs[:len(locs)-1]
+				bounds = bounds[:len(bounds)-1]
+				s = math.Inf(-1)
+			} else {
+				break
+			}
+		}
+		locs = append(locs, q)
+		bounds = append(bounds, s)
+	}
+
+	dists := make([]float64, len(f))
+	indices := make([]int, len(f))
+	var k int
+	for i := range f {
+		if len(locs) == 0 {
+			dists[i] = math.Inf(1)
+			indices[i] = -1
+			continue
+		}
+		for k+1 < len(locs) && bounds[k+1] < float64(i) {
+			k++
+		}
+		d := float64(i - locs[k])
+		dists[i] = d*d + f[locs[k]]
+		indices[i] = locs[k]
+	}
+	return dists, indices
+}
@@ -0,0 +1,62 @@
+package numerical
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDistanceTransform(t *testing.T) {
+	for _, shape := range [][]int{{17}, {13, 9}, {7, 5, 6}} {
+		size := 1
+		for _, s := range shape {
+			size *= s
+		}
+		features := make([]bool, size)
+		for i := range features {
+			features[i] = rand.Intn(10) == 0
+		}
+		features[rand.Intn(size)] = true
+		dists, nearest := DistanceTransform(shape, features)
+
+		coords := func(idx int) []int {
+			res := make([]int, len(shape))
+			for i, s := range shape {
+				res[i] = idx % s
+				idx /= s
+			}
+			return res
+		}
+		sqDist := func(i1, i2 int) float64 {
+			c1, c2 := coords(i1), coords(i2)
+			var res float64
+			for i, x := range c1 {
+				res += float64((x - c2[i]) * (x - c2[i]))
+			}
+			return res
+		}
+		for i := range features {
+			expected := math.Inf(1)
+			for j, f := range features {
+				if f {
+					expected = math.Min(expected, sqDist(i, j))
+				}
+			}
+			if dists[i] != expected {
+				t.Fatalf("shape %v cell %d: expected %f but got %f", shape, i, expected, dists[i])
+			}
+			if !features[nearest[i]] || sqDist(i, nearest[i]) != expected {
+				t.Fatalf("shape %v cell %d: bad nearest index %d", shape, i, nearest[i])
+			}
+		}
+	}
+}
+
+func TestDistanceTransformEmpty(t *testing.T) {
+	dists, nearest := DistanceTransform([]int{3, 4}, make([]bool, 12))
+	for i, d := range dists {
+		if !math.IsInf(d, 1) || nearest[i] != -1 {
+			t.Fatalf("unexpected result at %d: %f, %d", i, d, nearest[i])
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package render3d
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/model3d/fileformats"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// A Renderer renders objects to images.
+//
+// This is implemented by RayCaster, RecursiveRayTracer,
+// BidirPathTracer, and PhotonMapper.
+type Renderer interface {
+	Render(img *Image, obj Object)
+}
+
+// A RendererFunc creates a Renderer for a single frame of
+// an animation.
+//
+// The camera is nil if the animation has no camera track,
+// in which case the renderer should use a fixed camera.
+// The seed should be used as the renderer's Seed so that
+// every frame gets different random numbers.
+type RendererFunc func(camera *Camera, seed int64) Renderer
+
+// A TimeObject is an Object that changes over time.
+type TimeObject interface {
+	// ObjectAt gets the state of the object at time t.
+	//
+	// With motion blur, this is called concurrently for
+	// every sample of every pixel, so it should be cheap.
+	ObjectAt(t float64) Object
+}
+
+type staticObject struct {
+	Object Object
+}
+
+// StaticObject creates a TimeObject that never changes.
+func StaticObject(obj Object) TimeObject {
+	return staticObject{Object: obj}
+}
+
+func (s staticObject) ObjectAt(t float64) Object {
+	return s.Object
+}
+
+// An AnimatedObject is a TimeObject that applies a
+// keyframed rigid transformation and scale to an Object.
+//
+// At time t, the object is scaled and rotated around
+// Pivot, and then translated by Translation.
+type AnimatedObject struct {
+	Object Object
+	Pivot  model3d.Coord3D
+
+	Translation CoordTrack
+	Rotation    RotationTrack
+
+	// Scale is the scale factor over time.
+	// If empty, a scale of 1 is used.
+	Scale ScalarTrack
+}
+
+// ObjectAt gets the transformed object at time t.
+func (a *AnimatedObject) ObjectAt(t float64) Object {
+	// Copy the matrix, since it may be a keyframe value.
+	matrix := *a.Rotation.At(t)
+	if len(a.Scale) > 0 {
+		matrix.Scale(a.Scale.At(t))
+	}
+	obj := Translate(a.Object, a.Pivot.Scale(-1))
+	obj = MatrixMultiply(obj, &matrix)
+	return Translate(obj, a.Pivot.Add(a.Translation.At(t)))
+}
+
+// An ARAPHandle is a set of mesh vertices which are moved
+// rigidly over time during an ARAP deformation.
+//
+// At time t, the vertices are rotated around Pivot and
+// then translated by Translation.
+type ARAPHandle struct {
+	Vertices []model3d.Coord3D
+	Pivot    model3d.Coord3D
+
+	Translation CoordTrack
+	Rotation    RotationTrack
+}
+
+// Constraints adds the handle's target vertex positions
+// at time t to a set of constraints.
+func (a *ARAPHandle) Constraints(t float64, c model3d.ARAPConstraints) {
+	rotation := a.Rotation.At(t)
+	offset := a.Pivot.Add(a.Translation.At(t))
+	for _, v := range a.Vertices {
+		c[v] = rotation.MulColumn(v.Sub(a.Pivot)).Add(offset)
+	}
+}
+
+// An ARAPObject is a TimeObject that deforms a mesh over
+// time by moving handles and using ARAP to deform the
+// rest of the mesh.
+type ARAPObject struct {
+	ARAP    *model3d.ARAP
+	Handles []*ARAPHandle
+
+	// ColorFunc is passed to Objectify() to convert
+	// deformed meshes to Objects.
+	ColorFunc ColorFunc
+
+	lock     sync.Mutex
+	deformer func(model3d.ARAPConstraints) *model3d.Mesh
+}
+
+// NewARAPObject creates an ARAPObject for the mesh.
+func NewARAPObject(mesh *model3d.Mesh, colorFunc ColorFunc, handles ...*ARAPHandle) *ARAPObject {
+	return &ARAPObject{
+		ARAP:      model3d.NewARAP(mesh),
+		Handles:   handles,
+		ColorFunc: colorFunc,
+	}
+}
+
+// MeshAt computes the deformed mesh at time t.
+//
+// Deformations are warm-started from the previous call,
+// so sequential times are cheaper to compute.
+func (a *ARAPObject) MeshAt(t float64) *model3d.Mesh {
+	constraints := model3d.ARAPConstraints{}
+	for _, h := range a.Handles {
+		h.Constraints(t, constraints)
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.deformer == nil {
+		a.deformer = a.ARAP.SeqDeformer(false)
+	}
+	return a.deformer(constraints)
+}
+
+// ObjectAt gets the deformed object at time t.
+func (a *ARAPObject) ObjectAt(t float64) Object {
+	return Objectify(a.MeshAt(t), a.ColorFunc)
+}
+
+// A CameraTrack moves a camera over time.
+type CameraTrack struct {
+	Position CoordTrack
+	Target   CoordTrack
+
+	// FieldOfView is the camera's field of view over time.
+	// If empty, DefaultFieldOfView is used.
+	FieldOfView ScalarTrack
+}
+
+// NewTurntableCameraTrack creates a CameraTrack which
+// orbits around a center point, making the given number
+// of full turns over a period of time.
+//
+// The start argument is the initial camera position.
+func NewTurntableCameraTrack(center, start, axis model3d.Coord3D, duration float64,
+	turns int) *CameraTrack {
+	rotations := NewTurntableTrack(axis, duration, turns)
+	res := &CameraTrack{
+		Target: CoordTrack{{Value: center}},
+	}
+	offset := start.Sub(center)
+	for _, r := range rotations {
+		// Bezier curves through the orbit approximate a
+		// circle much better than line segments.
+		res.Position = append(res.Position, Keyframe[model3d.Coord3D]{
+			Time:   r.Time,
+			Value:  center.Add(r.Value.MulColumn(offset)),
+			Interp: BezierInterpolation,
+		})
+	}
+	return res
+}
+
+// At gets the camera at time t.
+func (c *CameraTrack) At(t float64) *Camera {
+	return NewCameraAt(c.Position.At(t), c.Target.At(t), c.FieldOfView.At(t))
+}
+
+// An Animation describes a scene over time.
+type Animation struct {
+	// Camera, if non-nil, moves the camera over time.
+	Camera *CameraTrack
+
+	Objects []TimeObject
+
+	// Duration is the length of the animation in seconds.
+	Duration float64
+
+	// FPS is the number of frames per second.
+	FPS float64
+
+	// MotionBlur, if true, gives every sample of every
+	// pixel its own random time within the shutter
+	// interval, blurring moving objects.
+	//
+	// The renderer should take many samples per pixel for
+	// the blur to look smooth.
+	// The camera is not blurred, and stays at its position
+	// at the start of the frame.
+	MotionBlur bool
+
+	// Shutter is the fraction of the time between frames
+	// during which the shutter is open, for motion blur.
+	// If 0, 0.5 is used.
+	Shutter float64
+
+	// Seed is combined with the frame index to seed the
+	// renderer for each frame.
+	Seed int64
+}
+
+// NumFrames gets the number of frames in the animation.
+func (a *Animation) NumFrames() int {
+	return int(math.Ceil(a.Duration*a.FPS - 1e-8))
+}
+
+// FrameTime gets the time at the start of a frame.
+func (a *Animation) FrameTime(frame int) float64 {
+	return float64(frame) / a.FPS
+}
+
+// SceneAt gets the objects in the scene at time t.
+func (a *Animation) SceneAt(t float64) Object {
+	res := make(JoinedObject, len(a.Objects))
+	for i, obj := range a.Objects {
+		res[i] = obj.ObjectAt(t)
+	}
+	return res
+}
+
+// RenderFrame renders a frame of the animation.
+func (a *Animation) RenderFrame(img *Image, r RendererFunc, frame int) {
+	t := a.FrameTime(frame)
+	var camera *Camera
+	if a.Camera != nil {
+		camera = a.Camera.At(t)
+	}
+	var scene Object
+	if a.MotionBlur {
+		shutter := a.Shutter
+		if shutter == 0 {
+			shutter = 0.5
+		}
+		scene = newMotionBlurScene(a, t, shutter/a.FPS)
+	} else {
+		scene = a.SceneAt(t)
+	}
+	r(camera, int64(mixHash(uint64(a.Seed), uint64(frame)))).Render(img, scene)
+}
+
+// SaveFrames renders every frame of the animation to a
+// numbered image file.
+//
+// The pattern is a format string for the frame index,
+// such as "frame_%04d.png".
+func (a *Animation) SaveFrames(pattern string, r RendererFunc, width, height int) error {
+	img := NewImage(width, height)
+	for i := 0; i < a.NumFrames(); i++ {
+		a.RenderFrame(img, r, i)
+		if err := img.Save(fmt.Sprintf(pattern, i)); err != nil {
+			return errors.Wrap(err, "save frames")
+		}
+	}
+	return nil
+}
+
+// SaveAPNG renders the animation to an animated PNG file.
+func (a *Animation) SaveAPNG(path string, r RendererFunc, width, height int) error {
+	if !(a.FPS > 0) {
+		return errors.New("save APNG: FPS must be positive")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "save APNG")
+	}
+	defer f.Close()
+
+	numFrames := a.NumFrames()
+	w := fileformats.NewAPNGWriter(f, numFrames, 0)
+	delayNum, delayDen := apngFrameDelay(a.FPS)
+	img := NewImage(width, height)
+	for i := 0; i < numFrames; i++ {
+		a.RenderFrame(img, r, i)
+		if err := w.WriteFrame(img.RGBA(), delayNum, delayDen); err != nil {
+			return errors.Wrap(err, "save APNG")
+		}
+	}
+	return nil
+}
+
+// apngFrameDelay approximates 1/fps as a fraction with
+// 16-bit numerator and denominator.
+//
+// The fps must be positive.
+func apngFrameDelay(fps float64) (uint16, uint16) {
+	if fps == math.Round(fps) && fps <= math.MaxUint16 {
+		return 1, uint16(fps)
+	}
+	// Use the largest denominator that keeps the numerator
+	// in range, for the best precision.
+	den := math.Max(1, math.Min(math.MaxUint16, math.Floor(math.MaxUint16*fps)))
+	num := math.Max(1, math.Min(math.MaxUint16, math.Round(den/fps)))
+	return uint16(num), uint16(den)
+}
+
+// motionBlurScene is a MotionObject for the objects of an
+// Animation during a shutter interval.
+type motionBlurScene struct {
+	Object
+
+	anim     *Animation
+	start    float64
+	duration float64
+	min      model3d.Coord3D
+	max      model3d.Coord3D
+}
+
+func newMotionBlurScene(a *Animation, start, duration float64) *motionBlurScene {
+	first := a.SceneAt(start)
+	last := a.SceneAt(start + duration)
+	return &motionBlurScene{
+		Object:   first,
+		anim:     a,
+		start:    start,
+		duration: duration,
+
+		// Objects may move outside of these bounds during
+		// the shutter interval, but these are only an
+		// estimate for renderers that need one.
+		min: first.Min().Min(last.Min()),
+		max: first.Max().Max(last.Max()),
+	}
+}
+
+func (m *motionBlurScene) Min() model3d.Coord3D {
+	return m.min
+}
+
+func (m *motionBlurScene) Max() model3d.Coord3D {
+	return m.max
+}
+
+func (m *motionBlurScene) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	return RandomCast(m.Object, gen, r)
+}
+
+func (m *motionBlurScene) ObjectAtShutter(t float64) Object {
+	return m.anim.SceneAt(m.start + t*m.duration)
+}
@@ -60,6 +60,8 @@ type BidirPathTracer struct {
 	Antialias float64
 	Epsilon   float64
 	LogFunc   func(frac float64, sampleRate float64)
+	Seed      int64
+	Sequence  SampleSequence
 }
 
 // Render renders the object to an image.
@@ -95,6 +97,8 @@ func (b *BidirPathTracer) rayRenderer() *rayRenderer {
 		Convergence:          b.Convergence,
 		Antialias:            b.Antialias,
 		LogFunc:              b.LogFunc,
+		Seed:                 b.Seed,
+		Sequence:             b.Sequence,
 	}
 }
 
@@ -147,7 +151,7 @@ func (b *BidirPathTracer) rayColor(g *goInfo, obj Object, ray *model3d.Ray) Colo
 					eps = DefaultEpsilon
 				}
 				maxDist := p2.Dist(p1) - 2*eps
-				if coll, _, ok := obj.Cast(ray); ok && coll.Scale < maxDist {
+				if coll, _, ok := RandomCast(obj, g.Gen, ray); ok && coll.Scale < maxDist {
 					return
 				}
 			}
@@ -163,7 +167,7 @@ func (b *BidirPathTracer) sampleEyePath(gen *rand.Rand, obj Object, ray *model3d
 	out.Clear()
 	pathEnder := newBptPathEnder(b.MinDepth, b.Cutoff)
 	for i := 0; i < b.MaxDepth; i++ {
-		coll, mat, ok := obj.Cast(ray)
+		coll, mat, ok := RandomCast(obj, gen, ray)
 		if !ok {
 			break
 		}
@@ -208,7 +212,7 @@ func (b *BidirPathTracer) sampleLightPath(gen *rand.Rand, obj Object, out *bptLi
 
 	pathEnder := newBptPathEnder(b.MinDepth, b.Cutoff)
 	for i := 0; i < b.maxLightDepth()-1; i++ {
-		coll, mat, ok := obj.Cast(ray)
+		coll, mat, ok := RandomCast(obj, gen, ray)
 		if !ok {
 			break
 		}
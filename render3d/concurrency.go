@@ -9,12 +9,47 @@ import (
 type goInfo struct {
 	Gen   *rand.Rand
 	Extra any
+
+	source *sampleSource
+}
+
+func newGoInfo(seed int64, sequence SampleSequence) *goInfo {
+	source := newSampleSource(seed, sequence)
+	return &goInfo{
+		Gen:    rand.New(source),
+		source: source,
+	}
+}
+
+// StartSample resets Gen to a deterministic random stream
+// for a given sample of a given pixel (or work item).
+func (g *goInfo) StartSample(idx, sample int) {
+	g.source.Reset(idx, sample)
+}
+
+// SampleObject gets the object to use for the current
+// sample, resolving a MotionObject at the sample's shutter
+// time.
+//
+// The shutter time does not consume random numbers from
+// Gen, so it does not disturb low-discrepancy sequences.
+func (g *goInfo) SampleObject(obj Object) Object {
+	if mo, ok := obj.(MotionObject); ok {
+		return mo.ObjectAtShutter(g.source.ShutterTime())
+	}
+	return obj
 }
 
 // mapCoordinates calls f with every coordinate in an
 // image, along with a per-goroutine random number
 // generator and the pixel index.
-func mapCoordinates(width, height int, f func(g *goInfo, x, y, idx int)) {
+//
+// The random number generator is reset to the stream for
+// the first sample of each pixel before f is called, so
+// the results do not depend on the number of goroutines,
+// which is determined by GOMAXPROCS.
+func mapCoordinates(width, height int, seed int64, sequence SampleSequence,
+	f func(g *goInfo, x, y, idx int)) {
 	coords := make(chan [3]int, width*height)
 	var idx int
 	for y := 0; y < height; y++ {
@@ -26,14 +61,13 @@ func mapCoordinates(width, height int, f func(g *goInfo, x, y, idx int)) {
 	close(coords)
 
 	var wg sync.WaitGroup
-	for i := 0; i < runtime.NumCPU(); i++ {
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			g := &goInfo{
-				Gen: rand.New(rand.NewSource(rand.Int63())),
-			}
+			g := newGoInfo(seed, sequence)
 			for c := range coords {
+				g.StartSample(c[2], 0)
 				f(g, c[0], c[1], c[2])
 			}
 		}()
@@ -41,3 +75,31 @@ func mapCoordinates(width, height int, f func(g *goInfo, x, y, idx int)) {
 
 	wg.Wait()
 }
+
+// mapIndices calls f with every index in [0, n), along
+// with a per-goroutine random number generator.
+//
+// Like mapCoordinates, the random number generator is
+// reset to a deterministic stream for each index.
+func mapIndices(n int, seed int64, f func(g *goInfo, idx int)) {
+	indices := make(chan int, n)
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := newGoInfo(seed, PseudoRandomSequence)
+			for idx := range indices {
+				g.StartSample(idx, 0)
+				f(g, idx)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
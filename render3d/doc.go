@@ -4,4 +4,9 @@
 // The RayCaster API can be used to render scenes quickly.
 // The RecursiveRayTracer API can be used to render very
 // realistic scenes with accurate lighting.
+// The PhotonMapper API can be used to render caustics
+// through refractive and reflective objects.
+//
+// The Animation API can be used to render keyframed
+// scenes to image sequences or animated PNGs.
 package render3d
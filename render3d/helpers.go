@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"image/gif"
 	"math"
+	"math/rand"
 	"os"
 
 	"github.com/unixpickle/model3d/model3d"
@@ -42,7 +43,12 @@ type colorFuncObject struct {
 }
 
 func (c *colorFuncObject) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
-	rc, mat, ok := c.Object.Cast(r)
+	return c.RandomCast(nil, r)
+}
+
+func (c *colorFuncObject) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	rc, mat, ok := RandomCast(c.Object, gen, r)
 	if ok && c.ColorFunc != nil {
 		p := r.Origin.Add(r.Direction.Scale(rc.Scale))
 		color := c.ColorFunc(p, rc)
@@ -132,6 +138,19 @@ func SaveRendering(path string, obj any, origin model3d.Coord3D, width, height i
 // color for the visible parts of the model.
 func SaveRandomGrid(path string, obj any, rows, cols, imgSize int,
 	colorFunc ColorFunc) error {
+	return saveRandomGrid(path, obj, rows, cols, imgSize, colorFunc)
+}
+
+// SaveRandomGridSeed is like SaveRandomGrid, but chooses
+// the angles deterministically from a seed.
+func SaveRandomGridSeed(path string, obj any, rows, cols, imgSize int, seed int64,
+	colorFunc ColorFunc) error {
+	return saveRandomGrid(path, obj, rows, cols, imgSize, colorFunc,
+		rand.New(rand.NewSource(seed)))
+}
+
+func saveRandomGrid(path string, obj any, rows, cols, imgSize int, colorFunc ColorFunc,
+	rng ...*rand.Rand) error {
 	object := Objectify(obj, colorFunc)
 	fullOutput := NewImage(cols*imgSize, rows*imgSize)
 
@@ -140,7 +159,7 @@ func SaveRandomGrid(path string, obj any, rows, cols, imgSize int,
 
 	for i := 0; i < rows; i++ {
 		for j := 0; j < cols; j++ {
-			direction := model3d.NewCoord3DRandUnit()
+			direction := model3d.NewCoord3DRandUnit(rng...)
 			caster := &RayCaster{
 				Camera: DirectionalCamera(object, direction, helperFieldOfView),
 				Lights: []*PointLight{
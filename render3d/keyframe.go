@@ -0,0 +1,257 @@
+package render3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// Interpolation determines how values are interpolated
+// between one keyframe and the next.
+type Interpolation int
+
+const (
+	// LinearInterpolation interpolates linearly between
+	// keyframes.
+	// For rotations, this uses spherical linear
+	// interpolation (slerp).
+	LinearInterpolation Interpolation = iota
+
+	// BezierInterpolation uses cubic Bézier curves through
+	// the keyframes, with tangents determined by the
+	// neighboring keyframes (as in a Catmull-Rom spline).
+	// For rotations, this eases slerp in and out of the
+	// keyframes.
+	BezierInterpolation
+
+	// StepInterpolation holds the value of a keyframe
+	// until the next keyframe.
+	StepInterpolation
+)
+
+// A Keyframe specifies a value at a point in time.
+type Keyframe[T any] struct {
+	Time  float64
+	Value T
+
+	// Interp determines how to interpolate from this
+	// keyframe to the next one.
+	Interp Interpolation
+}
+
+// A CoordTrack is a sequence of keyframes for a
+// coordinate, sorted by time.
+type CoordTrack []Keyframe[model3d.Coord3D]
+
+// At computes the interpolated coordinate at time t.
+//
+// Before the first keyframe or after the last one, the
+// value of the nearest keyframe is used.
+// An empty track always returns the origin.
+func (c CoordTrack) At(t float64) model3d.Coord3D {
+	if len(c) == 0 {
+		return model3d.Coord3D{}
+	}
+	i, frac := keyframeSegment(len(c), func(i int) float64 { return c[i].Time }, t)
+	if frac == 0 {
+		return c[i].Value
+	}
+	p0, p1 := c[i].Value, c[i+1].Value
+	switch c[i].Interp {
+	case StepInterpolation:
+		return p0
+	case BezierInterpolation:
+		dt := c[i+1].Time - c[i].Time
+		c1 := p0.Add(c.tangent(i).Scale(dt / 3))
+		c2 := p1.Sub(c.tangent(i + 1).Scale(dt / 3))
+		return cubicBezier(p0, c1, c2, p1, frac)
+	default:
+		return p0.Scale(1 - frac).Add(p1.Scale(frac))
+	}
+}
+
+// tangent computes the derivative of the curve at the
+// i-th keyframe using finite differences.
+func (c CoordTrack) tangent(i int) model3d.Coord3D {
+	prev, next := essentials.MaxInt(0, i-1), essentials.MinInt(len(c)-1, i+1)
+	dt := c[next].Time - c[prev].Time
+	if dt == 0 {
+		return model3d.Coord3D{}
+	}
+	return c[next].Value.Sub(c[prev].Value).Scale(1 / dt)
+}
+
+// A ScalarTrack is a sequence of keyframes for a number,
+// sorted by time.
+type ScalarTrack []Keyframe[float64]
+
+// At computes the interpolated value at time t.
+//
+// See CoordTrack.At for details.
+func (s ScalarTrack) At(t float64) float64 {
+	coords := make(CoordTrack, len(s))
+	for i, k := range s {
+		coords[i] = Keyframe[model3d.Coord3D]{
+			Time:   k.Time,
+			Value:  model3d.X(k.Value),
+			Interp: k.Interp,
+		}
+	}
+	return coords.At(t).X
+}
+
+// A RotationTrack is a sequence of keyframes for a
+// rotation matrix, sorted by time.
+//
+// Rotations are interpolated along the shortest arc, so
+// consecutive keyframes should differ by less than 180
+// degrees.
+type RotationTrack []Keyframe[*model3d.Matrix3]
+
+// At computes the interpolated rotation at time t.
+//
+// An empty track always returns the identity.
+func (r RotationTrack) At(t float64) *model3d.Matrix3 {
+	if len(r) == 0 {
+		return model3d.NewMatrix3Identity()
+	}
+	i, frac := keyframeSegment(len(r), func(i int) float64 { return r[i].Time }, t)
+	if frac == 0 {
+		return r[i].Value
+	}
+	switch r[i].Interp {
+	case StepInterpolation:
+		return r[i].Value
+	case BezierInterpolation:
+		frac = frac * frac * (3 - 2*frac)
+	}
+	q0 := newQuaternionMatrix(r[i].Value)
+	q1 := newQuaternionMatrix(r[i+1].Value)
+	return q0.Slerp(q1, frac).Matrix()
+}
+
+// NewTurntableTrack creates a RotationTrack which makes
+// the given number of full turns around an axis over a
+// period of time.
+//
+// If turns is 0, the track is a constant identity
+// rotation.
+func NewTurntableTrack(axis model3d.Coord3D, duration float64, turns int) RotationTrack {
+	if turns == 0 {
+		return RotationTrack{{Value: model3d.NewMatrix3Rotation(axis, 0)}}
+	}
+
+	// Use three keyframes per turn, since slerp can only
+	// interpolate along arcs of less than 180 degrees.
+	numSteps := 3 * turns
+	if numSteps < 0 {
+		numSteps = -numSteps
+	}
+	res := make(RotationTrack, numSteps+1)
+	for i := range res {
+		frac := float64(i) / float64(numSteps)
+		res[i] = Keyframe[*model3d.Matrix3]{
+			Time:  frac * duration,
+			Value: model3d.NewMatrix3Rotation(axis, frac*2*math.Pi*float64(turns)),
+		}
+	}
+	return res
+}
+
+// keyframeSegment finds the keyframe index i and fraction
+// of the way from keyframe i to i+1 at time t.
+//
+// If the fraction is 0, i may be the final keyframe.
+func keyframeSegment(n int, times func(i int) float64, t float64) (int, float64) {
+	if t <= times(0) {
+		return 0, 0
+	} else if t >= times(n-1) {
+		return n - 1, 0
+	}
+	idx := sort.Search(n, func(i int) bool {
+		return times(i) > t
+	}) - 1
+	return idx, (t - times(idx)) / (times(idx+1) - times(idx))
+}
+
+func cubicBezier(p0, p1, p2, p3 model3d.Coord3D, t float64) model3d.Coord3D {
+	s := 1 - t
+	return p0.Scale(s * s * s).Add(p1.Scale(3 * s * s * t)).Add(p2.Scale(3 * s * t * t)).
+		Add(p3.Scale(t * t * t))
+}
+
+// quaternion is a unit quaternion (W, X, Y, Z)
+// representing a rotation.
+type quaternion [4]float64
+
+func newQuaternionMatrix(m *model3d.Matrix3) quaternion {
+	// https://www.euclideanspace.com/maths/geometry/rotations/conversions/matrixToQuaternion/
+	var q quaternion
+	trace := m[0] + m[4] + m[8]
+	if trace > 0 {
+		s := 0.5 / math.Sqrt(trace+1)
+		q = quaternion{0.25 / s, (m[7] - m[5]) * s, (m[2] - m[6]) * s, (m[3] - m[1]) * s}
+	} else if m[0] > m[4] && m[0] > m[8] {
+		s := 2 * math.Sqrt(1+m[0]-m[4]-m[8])
+		q = quaternion{(m[7] - m[5]) / s, 0.25 * s, (m[1] + m[3]) / s, (m[2] + m[6]) / s}
+	} else if m[4] > m[8] {
+		s := 2 * math.Sqrt(1+m[4]-m[0]-m[8])
+		q = quaternion{(m[2] - m[6]) / s, (m[1] + m[3]) / s, 0.25 * s, (m[5] + m[7]) / s}
+	} else {
+		s := 2 * math.Sqrt(1+m[8]-m[0]-m[4])
+		q = quaternion{(m[3] - m[1]) / s, (m[2] + m[6]) / s, (m[5] + m[7]) / s, 0.25 * s}
+	}
+	return q.normalize()
+}
+
+func (q quaternion) normalize() quaternion {
+	var norm float64
+	for _, x := range q {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	for i := range q {
+		q[i] /= norm
+	}
+	return q
+}
+
+func (q quaternion) Matrix() *model3d.Matrix3 {
+	w, x, y, z := q[0], q[1], q[2], q[3]
+	return &model3d.Matrix3{
+		1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w),
+		2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w),
+		2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y),
+	}
+}
+
+// Slerp performs spherical linear interpolation along the
+// shortest arc from q to q1.
+func (q quaternion) Slerp(q1 quaternion, t float64) quaternion {
+	var dot float64
+	for i := range q {
+		dot += q[i] * q1[i]
+	}
+	if dot < 0 {
+		dot = -dot
+		for i := range q1 {
+			q1[i] = -q1[i]
+		}
+	}
+	var s0, s1 float64
+	if dot > 1-1e-8 {
+		s0, s1 = 1-t, t
+	} else {
+		theta := math.Acos(dot)
+		sin := math.Sin(theta)
+		s0 = math.Sin((1-t)*theta) / sin
+		s1 = math.Sin(t*theta) / sin
+	}
+	var res quaternion
+	for i := range res {
+		res[i] = s0*q[i] + s1*q1[i]
+	}
+	return res.normalize()
+}
@@ -0,0 +1,167 @@
+package render3d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestCoordTrack(t *testing.T) {
+	for _, interp := range []Interpolation{LinearInterpolation, BezierInterpolation} {
+		track := CoordTrack{
+			{Time: 1, Value: model3d.XYZ(1, 2, 3), Interp: interp},
+			{Time: 2, Value: model3d.XYZ(2, 3, 4), Interp: interp},
+			{Time: 4, Value: model3d.XYZ(4, 5, 6), Interp: interp},
+		}
+		for _, tc := range []struct {
+			Time     float64
+			Expected model3d.Coord3D
+		}{
+			{0, model3d.XYZ(1, 2, 3)},
+			{1, model3d.XYZ(1, 2, 3)},
+			{1.5, model3d.XYZ(1.5, 2.5, 3.5)},
+			{2, model3d.XYZ(2, 3, 4)},
+			{3, model3d.XYZ(3, 4, 5)},
+			{5, model3d.XYZ(4, 5, 6)},
+		} {
+			// Bezier curves through collinear points with
+			// constant velocity should be linear.
+			actual := track.At(tc.Time)
+			if actual.Dist(tc.Expected) > 1e-8 {
+				t.Errorf("interp %d time %f: expected %v but got %v", interp, tc.Time,
+					tc.Expected, actual)
+			}
+		}
+	}
+
+	step := ScalarTrack{
+		{Time: 0, Value: 1, Interp: StepInterpolation},
+		{Time: 1, Value: 2},
+	}
+	if v := step.At(0.99); v != 1 {
+		t.Errorf("unexpected step value: %f", v)
+	}
+	if v := step.At(1); v != 2 {
+		t.Errorf("unexpected step value: %f", v)
+	}
+}
+
+func TestQuaternionMatrix(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		axis := model3d.NewCoord3DRandUnit()
+		angle := (rand.Float64()*2 - 1) * math.Pi
+		m := model3d.NewMatrix3Rotation(axis, angle)
+		actual := newQuaternionMatrix(m).Matrix()
+		for j, x := range actual {
+			if math.Abs(x-m[j]) > 1e-8 {
+				t.Fatalf("expected %v but got %v", m, actual)
+			}
+		}
+	}
+}
+
+func TestRotationTrack(t *testing.T) {
+	axis := model3d.XYZ(1, 2, 3).Normalize()
+	track := RotationTrack{
+		{Time: 0, Value: model3d.NewMatrix3Rotation(axis, 0.1)},
+		{Time: 2, Value: model3d.NewMatrix3Rotation(axis, 2.1)},
+	}
+	expected := model3d.NewMatrix3Rotation(axis, 0.6)
+	actual := track.At(0.5)
+	for i, x := range actual {
+		if math.Abs(x-expected[i]) > 1e-8 {
+			t.Fatalf("expected %v but got %v", expected, actual)
+		}
+	}
+
+	turntable := NewTurntableTrack(axis, 3, 2)
+	for _, time := range []float64{0.1, 0.7, 1.3, 2.9} {
+		expected := model3d.NewMatrix3Rotation(axis, time/3*4*math.Pi)
+		actual := turntable.At(time)
+		for i, x := range actual {
+			if math.Abs(x-expected[i]) > 1e-8 {
+				t.Fatalf("time %f: expected %v but got %v", time, expected, actual)
+			}
+		}
+	}
+
+	still := NewTurntableTrack(axis, 3, 0)
+	identity := model3d.NewMatrix3Identity()
+	for _, time := range []float64{0, 1.5, 3} {
+		actual := still.At(time)
+		for i, x := range actual {
+			if math.Abs(x-identity[i]) > 1e-8 {
+				t.Fatalf("time %f: expected identity but got %v", time, actual)
+			}
+		}
+	}
+}
+
+func TestAnimationMotionBlur(t *testing.T) {
+	anim := &Animation{
+		Objects: []TimeObject{
+			&AnimatedObject{
+				Object: &ColliderObject{
+					Collider: &model3d.Sphere{Radius: 1},
+					Material: &LambertMaterial{EmissionColor: NewColor(1)},
+				},
+				Translation: CoordTrack{
+					{Time: 0, Value: model3d.X(-2)},
+					{Time: 1, Value: model3d.X(2)},
+				},
+			},
+		},
+		Camera: &CameraTrack{
+			Position: CoordTrack{{Value: model3d.Z(-6)}},
+		},
+		Duration: 1,
+		FPS:      2,
+	}
+	if n := anim.NumFrames(); n != 2 {
+		t.Fatalf("unexpected frame count: %d", n)
+	}
+	r := func(c *Camera, seed int64) Renderer {
+		return &RecursiveRayTracer{Camera: c, NumSamples: 64, Seed: seed}
+	}
+
+	// Count pixels that are partially covered, which do
+	// not occur without motion blur or antialiasing.
+	render := func() (partial int, total float64) {
+		img := NewImage(32, 32)
+		anim.RenderFrame(img, r, 0)
+		for _, c := range img.Data {
+			if c.X > 0.05 && c.X < 0.95 {
+				partial++
+			}
+			total += c.X
+		}
+		return
+	}
+	sharp, sharpTotal := render()
+	anim.MotionBlur = true
+	blurry, blurryTotal := render()
+	if sharp != 0 {
+		t.Errorf("expected no partial pixels without motion blur: got %d", sharp)
+	}
+	if blurry < 16 {
+		t.Errorf("expected motion blur to create partial pixels: got %d", blurry)
+	}
+	if math.Abs(blurryTotal-sharpTotal) > 0.1*sharpTotal {
+		t.Errorf("motion blur changed total brightness from %f to %f", sharpTotal, blurryTotal)
+	}
+}
+
+func TestAPNGFrameDelay(t *testing.T) {
+	for _, fps := range []float64{1, 24, 29.97, 0.5, 1e-3, 1e5} {
+		num, den := apngFrameDelay(fps)
+		if num == 0 || den == 0 {
+			t.Fatalf("fps %f: invalid delay %d/%d", fps, num, den)
+		}
+		delay := float64(num) / float64(den)
+		if fps <= 1000 && math.Abs(delay*fps-1) > 0.01 {
+			t.Errorf("fps %f: expected delay %f but got %d/%d", fps, 1/fps, num, den)
+		}
+	}
+}
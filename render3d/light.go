@@ -141,16 +141,7 @@ func NewSphereAreaLight(s *model3d.Sphere, emission Color) *SphereAreaLight {
 
 func (s *SphereAreaLight) SampleLight(gen *rand.Rand) (point, normal model3d.Coord3D,
 	emission Color) {
-	// Don't use model3d.NewCoord3DRandUnit() because it
-	// won't use our per-thread RNG.
-	for {
-		normal = model3d.XYZ(gen.NormFloat64(), gen.NormFloat64(), gen.NormFloat64())
-		n := normal.Norm()
-		if n > 0.01 && n < 100.0 {
-			normal = normal.Scale(1 / n)
-			break
-		}
-	}
+	normal = randomUnitVector(gen)
 	point = s.sphere.Center.Add(normal.Scale(s.sphere.Radius))
 	emission = s.emission
 	return
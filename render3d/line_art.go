@@ -0,0 +1,388 @@
+package render3d
+
+import (
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultLineArtCreaseAngle   = math.Pi / 6
+	DefaultLineArtSampleSpacing = 1.0
+	DefaultLineArtEpsilon       = 1e-5
+
+	lineArtBisections       = 8
+	lineArtViewDistance     = 1000.0
+	lineArtViewMargin       = 0.05
+	lineArtDefaultThickness = 1.0
+)
+
+// A LineArtRenderer creates vector line drawings of
+// meshes, as is common for technical drawings.
+//
+// Three kinds of edges are drawn: silhouette edges, where
+// a front-facing triangle meets a back-facing one;
+// crease edges, where the dihedral angle between two
+// triangles is sufficiently sharp; and boundary edges,
+// which only touch one triangle.
+//
+// Hidden lines are removed by casting rays from the camera
+// to points along each edge.
+type LineArtRenderer struct {
+	Camera *Camera
+
+	// CreaseAngle is the minimum angle, in radians, between
+	// two neighboring triangles' normals for their shared
+	// edge to be drawn as a crease.
+	//
+	// If 0, DefaultLineArtCreaseAngle is used.
+	CreaseAngle float64
+
+	// Set these flags to disable certain kinds of edges.
+	NoSilhouettes bool
+	NoCreases     bool
+	NoBoundaries  bool
+
+	// ShowHidden, if true, disables hidden-line removal.
+	ShowHidden bool
+
+	// Occluder, if non-nil, is used for hidden-line
+	// removal instead of a collider built from the mesh.
+	Occluder model3d.Collider
+
+	// SampleSpacing is the maximum distance, in image
+	// coordinates, between visibility checks along an edge.
+	//
+	// If 0, DefaultLineArtSampleSpacing is used.
+	SampleSpacing float64
+
+	// Epsilon is the distance, relative to the size of the
+	// mesh, by which an occluder must come before a point
+	// to hide it.
+	//
+	// If 0, DefaultLineArtEpsilon is used.
+	Epsilon float64
+}
+
+// Render computes the visible edges of a mesh as segments
+// in image coordinates, where x ranges from [0, width] and
+// y ranges from [0, height].
+func (l *LineArtRenderer) Render(mesh *model3d.Mesh, width, height float64) *model2d.Mesh {
+	uncaster := l.Camera.Uncaster(width, height)
+	_, _, zAxis := l.Camera.axes(width, height)
+	zAxis = zAxis.Normalize()
+
+	occluder := l.Occluder
+	if occluder == nil && !l.ShowHidden {
+		occluder = model3d.MeshToCollider(mesh)
+	}
+	epsilon := l.Epsilon
+	if epsilon == 0 {
+		epsilon = DefaultLineArtEpsilon
+	}
+	epsilon *= mesh.Min().Dist(mesh.Max())
+	spacing := l.SampleSpacing
+	if spacing == 0 {
+		spacing = DefaultLineArtSampleSpacing
+	}
+
+	visible := func(c model3d.Coord3D) bool {
+		if c.Sub(l.Camera.Origin).Dot(zAxis) <= 0 {
+			return false
+		}
+		if l.ShowHidden {
+			return true
+		}
+		ray := &model3d.Ray{
+			Origin:    l.Camera.Origin,
+			Direction: c.Sub(l.Camera.Origin),
+		}
+		dist := ray.Direction.Norm()
+		collision, ok := occluder.FirstRayCollision(ray)
+		return !ok || collision.Scale*dist >= dist-epsilon
+	}
+	project := func(c model3d.Coord3D) model2d.Coord {
+		return model2d.XY(uncaster(c))
+	}
+
+	result := model2d.NewMesh()
+	for _, seg := range l.featureEdges(mesh) {
+		if seg[0].Sub(l.Camera.Origin).Dot(zAxis) <= 0 &&
+			seg[1].Sub(l.Camera.Origin).Dot(zAxis) <= 0 {
+			continue
+		}
+		l.visibleRuns(seg, spacing, visible, project, func(s model3d.Segment) {
+			p1, p2 := project(s[0]), project(s[1])
+			if p1 != p2 {
+				result.Add(&model2d.Segment{p1, p2})
+			}
+		})
+	}
+	return result
+}
+
+// RenderSVG renders the visible edges of a mesh and
+// encodes them as an SVG image of the given dimensions.
+//
+// If thickness is 0, a default stroke width is used.
+func (l *LineArtRenderer) RenderSVG(mesh *model3d.Mesh, width, height,
+	thickness float64) []byte {
+	if thickness == 0 {
+		thickness = lineArtDefaultThickness
+	}
+	lines := l.Render(mesh, width, height)
+	return model2d.EncodeCustomSVG(
+		[]*model2d.Mesh{lines},
+		[]string{"black"},
+		[]float64{thickness},
+		model2d.NewRect(model2d.XY(0, 0), model2d.XY(width, height)),
+	)
+}
+
+// featureEdges finds all of the silhouette, crease, and
+// boundary edges that should be drawn.
+func (l *LineArtRenderer) featureEdges(mesh *model3d.Mesh) []model3d.Segment {
+	creaseAngle := l.CreaseAngle
+	if creaseAngle == 0 {
+		creaseAngle = DefaultLineArtCreaseAngle
+	}
+	minCreaseCos := math.Cos(creaseAngle)
+
+	edgeToTris := map[model3d.Segment][]*model3d.Triangle{}
+	var edges []model3d.Segment
+	mesh.IterateSorted(func(t *model3d.Triangle) {
+		for _, seg := range t.Segments() {
+			if _, ok := edgeToTris[seg]; !ok {
+				edges = append(edges, seg)
+			}
+			edgeToTris[seg] = append(edgeToTris[seg], t)
+		}
+	}, nil)
+
+	var result []model3d.Segment
+	for _, seg := range edges {
+		tris := edgeToTris[seg]
+		switch len(tris) {
+		case 1:
+			if !l.NoBoundaries {
+				result = append(result, seg)
+			}
+		case 2:
+			n1, n2 := tris[0].Normal(), tris[1].Normal()
+			if !l.NoCreases && n1.Dot(n2) < minCreaseCos {
+				result = append(result, seg)
+			} else if !l.NoSilhouettes {
+				eye := l.Camera.Origin.Sub(seg.Mid())
+				if (n1.Dot(eye) > 0) != (n2.Dot(eye) > 0) {
+					result = append(result, seg)
+				}
+			}
+		default:
+			// Non-manifold edges are always drawn if creases
+			// are enabled, since they are infinitely sharp.
+			if !l.NoCreases {
+				result = append(result, seg)
+			}
+		}
+	}
+	return result
+}
+
+// visibleRuns calls f for every visible sub-segment of
+// seg, refining the endpoints of each sub-segment with
+// bisection.
+func (l *LineArtRenderer) visibleRuns(seg model3d.Segment, spacing float64,
+	visible func(model3d.Coord3D) bool, project func(model3d.Coord3D) model2d.Coord,
+	f func(s model3d.Segment)) {
+	projLength := project(seg[0]).Dist(project(seg[1]))
+	numSamples := 2
+	if !math.IsNaN(projLength) && !math.IsInf(projLength, 0) {
+		numSamples = essentials.MaxInt(2, int(math.Ceil(projLength/spacing))+1)
+	}
+	point := func(t float64) model3d.Coord3D {
+		return seg[0].Add(seg[1].Sub(seg[0]).Scale(t))
+	}
+	ts := make([]float64, numSamples)
+	vis := make([]bool, numSamples)
+	for i := range ts {
+		ts[i] = float64(i) / float64(numSamples-1)
+		vis[i] = visible(point(ts[i]))
+	}
+	bisect := func(visT, hiddenT float64) float64 {
+		for i := 0; i < lineArtBisections; i++ {
+			mid := (visT + hiddenT) / 2
+			if visible(point(mid)) {
+				visT = mid
+			} else {
+				hiddenT = mid
+			}
+		}
+		return visT
+	}
+
+	for i := 0; i < numSamples; i++ {
+		if !vis[i] {
+			continue
+		}
+		start := i
+		for i+1 < numSamples && vis[i+1] {
+			i++
+		}
+		end := i
+		if start == end && (start == 0 || end == numSamples-1) {
+			// A single visible endpoint is typically a
+			// vertex shared with a visible edge.
+			continue
+		}
+		startT, endT := ts[start], ts[end]
+		if start > 0 {
+			startT = bisect(startT, ts[start-1])
+		}
+		if end < numSamples-1 {
+			endT = bisect(endT, ts[end+1])
+		}
+		f(model3d.Segment{point(startT), point(endT)})
+	}
+}
+
+// A LineArtView is a standard view direction for a
+// technical drawing.
+type LineArtView int
+
+const (
+	LineArtFront LineArtView = iota
+	LineArtTop
+	LineArtSide
+	LineArtIsometric
+)
+
+// Camera creates a camera looking at the object from
+// this view.
+//
+// The camera is placed far away with a narrow field of
+// view so that the resulting projection is very nearly
+// orthographic.
+func (l LineArtView) Camera(min, max model3d.Coord3D) *Camera {
+	center := min.Mid(max)
+	radius := min.Dist(max) / 2
+	if radius == 0 {
+		radius = 1
+	}
+	var screenX, screenY model3d.Coord3D
+	switch l {
+	case LineArtFront:
+		screenX, screenY = model3d.X(1), model3d.Z(-1)
+	case LineArtTop:
+		screenX, screenY = model3d.X(1), model3d.Y(-1)
+	case LineArtSide:
+		screenX, screenY = model3d.Y(1), model3d.Z(-1)
+	case LineArtIsometric:
+		screenX = model3d.XY(1, 1).Normalize()
+		screenY = model3d.XYZ(1, -1, -2).Normalize()
+	default:
+		panic("unknown view")
+	}
+	direction := screenX.Cross(screenY).Normalize()
+	distance := radius * lineArtViewDistance
+	return &Camera{
+		Origin:      center.Sub(direction.Scale(distance)),
+		ScreenX:     screenX,
+		ScreenY:     screenY,
+		FieldOfView: 2 * math.Atan(radius*(1+lineArtViewMargin)/distance),
+	}
+}
+
+// gridPosition gets the (column, row) of the view in a
+// third-angle projection layout.
+func (l LineArtView) gridPosition() (int, int) {
+	switch l {
+	case LineArtFront:
+		return 0, 1
+	case LineArtTop:
+		return 0, 0
+	case LineArtSide:
+		return 1, 1
+	case LineArtIsometric:
+		return 1, 0
+	default:
+		panic("unknown view")
+	}
+}
+
+// RenderViews renders a mesh from multiple standard views
+// and arranges them in a third-angle projection layout,
+// with the top view above the front view and the side
+// view to the right of the front view.
+//
+// Each view occupies a viewSize by viewSize cell, and the
+// returned bounds cover the entire layout.
+//
+// If views is empty, the front, top, and side views are
+// used.
+func (l *LineArtRenderer) RenderViews(mesh *model3d.Mesh, viewSize float64,
+	views ...LineArtView) (*model2d.Mesh, *model2d.Rect) {
+	if len(views) == 0 {
+		views = []LineArtView{LineArtFront, LineArtTop, LineArtSide}
+	}
+	min, max := mesh.Min(), mesh.Max()
+	result := model2d.NewMesh()
+	bounds := model2d.NewRect(model2d.XY(0, 0), model2d.XY(0, 0))
+	for _, view := range views {
+		renderer := *l
+		renderer.Camera = view.Camera(min, max)
+		lines := renderer.Render(mesh, viewSize, viewSize)
+		col, row := view.gridPosition()
+		offset := model2d.XY(float64(col), float64(row)).Scale(viewSize)
+		result.AddMesh(lines.Translate(offset))
+		bounds.MaxVal = bounds.MaxVal.Max(offset.Add(model2d.XY(viewSize, viewSize)))
+	}
+	return result, bounds
+}
+
+// SaveLineArtSVG renders a mesh from the given point and
+// saves the visible edges to an SVG file.
+//
+// The camera will automatically face the center of the
+// mesh's bounding box.
+func SaveLineArtSVG(path string, mesh *model3d.Mesh, origin model3d.Coord3D,
+	width, height float64) error {
+	center := mesh.Min().Mid(mesh.Max())
+	renderer := &LineArtRenderer{
+		Camera: NewCameraAt(origin, center, helperFieldOfView),
+	}
+	data := renderer.RenderSVG(mesh, width, height, 0)
+	return writeLineArtFile(path, data, "save line art SVG")
+}
+
+// SaveLineArtViewsSVG renders the front, top, and side
+// views of a mesh and saves them to an SVG file.
+//
+// See LineArtRenderer.RenderViews for details on the
+// layout.
+func SaveLineArtViewsSVG(path string, mesh *model3d.Mesh, viewSize float64) error {
+	renderer := &LineArtRenderer{}
+	lines, bounds := renderer.RenderViews(mesh, viewSize)
+	data := model2d.EncodeCustomSVG(
+		[]*model2d.Mesh{lines},
+		[]string{"black"},
+		[]float64{lineArtDefaultThickness},
+		bounds,
+	)
+	return writeLineArtFile(path, data, "save line art views SVG")
+}
+
+func writeLineArtFile(path string, data []byte, context string) error {
+	w, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, context)
+	}
+	defer w.Close()
+	if _, err := w.Write(data); err != nil {
+		return errors.Wrap(err, context)
+	}
+	return nil
+}
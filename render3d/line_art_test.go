@@ -0,0 +1,48 @@
+package render3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestLineArtRendererFront(t *testing.T) {
+	mesh := model3d.NewMeshRect(model3d.XYZ(-1, -1, -1), model3d.XYZ(1, 1, 1))
+	renderer := &LineArtRenderer{
+		Camera: LineArtFront.Camera(mesh.Min(), mesh.Max()),
+	}
+	lines := renderer.Render(mesh, 100, 100)
+
+	var totalLength float64
+	lines.Iterate(func(s *model2d.Segment) {
+		totalLength += s.Length()
+	})
+
+	// The cube should appear as a square spanning the cube's
+	// side length relative to the diagonal of the bounds.
+	side := 100 / (math.Sqrt(3) * (1 + lineArtViewMargin))
+	expected := side * 4
+	if math.Abs(totalLength-expected) > 1 {
+		t.Errorf("expected total length %f but got %f", expected, totalLength)
+	}
+}
+
+func TestLineArtRendererHidden(t *testing.T) {
+	mesh := model3d.NewMeshRect(model3d.XYZ(-1, -1, -1), model3d.XYZ(1, 1, 1))
+	for _, showHidden := range []bool{false, true} {
+		renderer := &LineArtRenderer{
+			Camera:     LineArtIsometric.Camera(mesh.Min(), mesh.Max()),
+			ShowHidden: showHidden,
+		}
+		lines := renderer.Render(mesh, 100, 100)
+		expected := 9
+		if showHidden {
+			expected = 12
+		}
+		if n := len(lines.SegmentsSlice()); n != expected {
+			t.Errorf("showHidden=%v: expected %d segments but got %d", showHidden, expected, n)
+		}
+	}
+}
@@ -22,6 +22,57 @@ type Object interface {
 	Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool)
 }
 
+// A RandomObject is an Object whose ray collisions are
+// random, such as a ParticipatingMedium.
+//
+// Renderers pass their per-sample random number generator
+// to RandomCast, making rendering deterministic.
+//
+// Objects which wrap other objects should implement this
+// interface to pass the generator through to their
+// children.
+type RandomObject interface {
+	Object
+
+	// RandomCast is like Cast, but uses gen for random
+	// numbers.
+	//
+	// If gen is nil, a global source of randomness may be
+	// used.
+	RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision, Material, bool)
+}
+
+// RandomCast is like obj.Cast, but uses gen for random
+// numbers.
+//
+// If obj is a RandomObject, its RandomCast method is
+// used.
+func RandomCast(obj Object, gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	if ro, ok := obj.(RandomObject); ok {
+		return ro.RandomCast(gen, r)
+	} else {
+		return obj.Cast(r)
+	}
+}
+
+// A MotionObject is an Object which moves while a
+// camera's shutter is open, producing motion blur.
+//
+// Renderers choose a random shutter time for every sample
+// of every pixel, and trace every ray of that sample
+// through the object returned by ObjectAtShutter.
+type MotionObject interface {
+	Object
+
+	// ObjectAtShutter gets the state of the object at a
+	// time t in [0, 1), relative to the shutter interval.
+	//
+	// This may be called concurrently from many
+	// goroutines.
+	ObjectAtShutter(t float64) Object
+}
+
 // A ColliderObject wraps a model3d.Collider in the Object
 // interface, using a constant material.
 type ColliderObject struct {
@@ -79,8 +130,24 @@ func (p *ParticipatingMedium) Max() model3d.Coord3D {
 }
 
 // Cast returns the first probabilistic ray collision.
+//
+// This uses the global random number generator.
+// For deterministic results, see RandomCast.
 func (p *ParticipatingMedium) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
-	t := -math.Log(rand.Float64()) / p.Lambda
+	return p.RandomCast(nil, r)
+}
+
+// RandomCast returns the first probabilistic ray
+// collision, using gen for randomness.
+func (p *ParticipatingMedium) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	var u float64
+	if gen == nil {
+		u = rand.Float64()
+	} else {
+		u = gen.Float64()
+	}
+	t := -math.Log(u) / p.Lambda
 	t /= r.Direction.Norm()
 
 	var collisions []model3d.RayCollision
@@ -104,7 +171,7 @@ func (p *ParticipatingMedium) Cast(r *model3d.Ray) (model3d.RayCollision, Materi
 					// Normal could be anything, but we randomize
 					// it so that the normal cosine term is very
 					// unlikely to be 0.
-					Normal: model3d.NewCoord3DRandUnit(),
+					Normal: randomUnitVector(gen),
 				}, p.Material, true
 			}
 		}
@@ -139,11 +206,18 @@ func (j JoinedObject) Max() model3d.Coord3D {
 // Cast casts the ray onto the objects and chooses the
 // closest ray collision.
 func (j JoinedObject) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
+	return j.RandomCast(nil, r)
+}
+
+// RandomCast is like Cast, but passes gen to any
+// RandomObjects.
+func (j JoinedObject) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
 	var coll model3d.RayCollision
 	var mat Material
 	var found bool
 	for _, o := range j {
-		if c, m, f := o.Cast(r); f && (!found || c.Scale < coll.Scale) {
+		if c, m, f := RandomCast(o, gen, r); f && (!found || c.Scale < coll.Scale) {
 			coll = c
 			mat = m
 			found = true
@@ -160,10 +234,15 @@ type FilteredObject struct {
 }
 
 func (f *FilteredObject) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
+	return f.RandomCast(nil, r)
+}
+
+func (f *FilteredObject) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
 	if _, ok := f.Bounds.FirstRayCollision(r); !ok {
 		return model3d.RayCollision{}, nil, false
 	}
-	return f.Object.Cast(r)
+	return RandomCast(f.Object, gen, r)
 }
 
 // BVHToObject creates a single object from a BVH by
@@ -0,0 +1,494 @@
+package render3d
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultPhotonMapperAlpha = 0.7
+
+	photonMapperRadiusScale = 0.01
+	photonMapperBatchSize   = 1024
+)
+
+// A PhotonMapper renders objects using progressive photon
+// mapping.
+//
+// Photon mapping is well-suited for rendering caustics,
+// where light is focused onto diffuse surfaces through
+// specular paths (e.g. a RefractMaterial), since these
+// paths are extremely hard to sample from the eye.
+//
+// Rendering proceeds by first tracing a hit point for
+// each pixel, following specular bounces until a diffuse
+// surface is reached.
+// Then, many passes of photons are emitted from the light
+// sources, and the flux arriving near each hit point is
+// accumulated with a gradually shrinking radius.
+//
+// Like BidirPathTracer, area lights should be part of the
+// scene, but should also be provided as an AreaLight.
+type PhotonMapper struct {
+	Camera *Camera
+
+	// Light is the (possibly joined) area light from which
+	// photons are emitted.
+	//
+	// This may be nil if PointLights are used instead.
+	Light AreaLight
+
+	// PointLights are additional light sources from which
+	// photons are emitted.
+	//
+	// Since photons naturally spread out, point lights are
+	// always treated as if QuadDropoff were true.
+	PointLights []*PointLight
+
+	// NumPasses is the number of photon passes.
+	NumPasses int
+
+	// PhotonsPerPass is the number of photons emitted from
+	// all of the lights during each pass.
+	PhotonsPerPass int
+
+	// MaxDepth is the maximum number of bounces for both
+	// eye paths and photon paths.
+	// Setting to 0 only simulates direct lighting.
+	MaxDepth int
+
+	// InitialRadius is the starting radius for gathering
+	// photons around each hit point.
+	//
+	// If 0, a radius is chosen based on the extent of
+	// the visible part of the scene.
+	InitialRadius float64
+
+	// Alpha is the fraction of new photons to keep after
+	// each pass, controlling how quickly the gathering
+	// radius shrinks.
+	//
+	// If 0, DefaultPhotonMapperAlpha is used.
+	Alpha float64
+
+	// NumSamples is the number of hit points to trace per
+	// pixel. If 0, a single hit point is used.
+	NumSamples int
+
+	// Antialias, if non-zero, specifies a fraction of a
+	// pixel to perturb every hit point's ray.
+	Antialias float64
+
+	// IsSpecular determines which materials are followed
+	// by eye paths rather than storing hit points.
+	//
+	// If nil, RefractMaterial (and any JoinedMaterial of
+	// RefractMaterials) is treated as specular.
+	IsSpecular func(m Material) bool
+
+	// See RecursiveRayTracer for more details.
+	Cutoff   float64
+	Epsilon  float64
+	Seed     int64
+	Sequence SampleSequence
+
+	// LogFunc, if specified, is called after every photon
+	// pass with the fraction of passes which have been
+	// completed and the mean gathering radius.
+	LogFunc func(frac float64, meanRadius float64)
+}
+
+// Render renders the object to an image.
+func (p *PhotonMapper) Render(img *Image, obj Object) {
+	if p.NumPasses == 0 || p.PhotonsPerPass == 0 {
+		panic("must set NumPasses and PhotonsPerPass to non-zero for PhotonMapper")
+	}
+	if p.Light == nil && len(p.PointLights) == 0 {
+		panic("PhotonMapper requires at least one light")
+	}
+
+	direct := make([]Color, len(img.Data))
+	hitPoints := p.traceHitPoints(img.Width, img.Height, obj, direct)
+	p.initRadii(hitPoints)
+
+	alpha := p.Alpha
+	if alpha == 0 {
+		alpha = DefaultPhotonMapperAlpha
+	}
+	for i := 0; i < p.NumPasses; i++ {
+		tree := newPhotonTree(p.emitPhotons(obj, i))
+		mapIndices(len(hitPoints), p.Seed, func(g *goInfo, idx int) {
+			hitPoints[idx].Gather(tree, alpha)
+		})
+		if p.LogFunc != nil {
+			var meanRadius float64
+			for _, h := range hitPoints {
+				meanRadius += math.Sqrt(h.Radius2)
+			}
+			meanRadius /= math.Max(1, float64(len(hitPoints)))
+			p.LogFunc(float64(i+1)/float64(p.NumPasses), meanRadius)
+		}
+	}
+
+	totalPhotons := float64(p.NumPasses * p.PhotonsPerPass)
+	copy(img.Data, direct)
+	for _, h := range hitPoints {
+		// Convert the BSDF to a standard (non-normalized)
+		// BSDF by dividing by 4*pi, and then divide by the
+		// area of the gathering disk.
+		scale := 1 / (4 * math.Pi * math.Pi * h.Radius2 * totalPhotons)
+		img.Data[h.PixelIdx] = img.Data[h.PixelIdx].Add(h.Flux.Mul(h.Weight).Scale(scale))
+	}
+}
+
+func (p *PhotonMapper) traceHitPoints(width, height int, obj Object,
+	direct []Color) []*photonHitPoint {
+	numSamples := p.NumSamples
+	if numSamples == 0 {
+		numSamples = 1
+	}
+	sampleWeight := 1 / float64(numSamples)
+
+	caster := p.Camera.Caster(float64(width)-1, float64(height)-1)
+	pixelHitPoints := make([][]*photonHitPoint, width*height)
+	mapCoordinates(width, height, p.Seed, p.Sequence, func(g *goInfo, x, y, idx int) {
+		for i := 0; i < numSamples; i++ {
+			g.StartSample(idx, i)
+			dx, dy := 0.0, 0.0
+			if p.Antialias != 0 {
+				dx = p.Antialias * (g.Gen.Float64() - 0.5)
+				dy = p.Antialias * (g.Gen.Float64() - 0.5)
+			}
+			ray := &model3d.Ray{
+				Origin:    p.Camera.Origin,
+				Direction: caster(float64(x)+dx, float64(y)+dy),
+			}
+			color, hp := p.traceHitPoint(g.Gen, g.SampleObject(obj), ray,
+				NewColor(sampleWeight))
+			direct[idx] = direct[idx].Add(color)
+			if hp != nil {
+				hp.PixelIdx = idx
+				pixelHitPoints[idx] = append(pixelHitPoints[idx], hp)
+			}
+		}
+	})
+
+	var result []*photonHitPoint
+	for _, hps := range pixelHitPoints {
+		result = append(result, hps...)
+	}
+	return result
+}
+
+func (p *PhotonMapper) traceHitPoint(gen *rand.Rand, obj Object, ray *model3d.Ray,
+	weight Color) (Color, *photonHitPoint) {
+	var color Color
+	for depth := 0; depth <= p.MaxDepth; depth++ {
+		if weight.Sum()/3 < p.Cutoff {
+			break
+		}
+		collision, material, ok := RandomCast(obj, gen, ray)
+		if !ok {
+			break
+		}
+		point := ray.Origin.Add(ray.Direction.Scale(collision.Scale))
+		dest := ray.Direction.Normalize().Scale(-1)
+
+		emission := material.Emission()
+		if depth == 0 {
+			emission = emission.Add(material.Ambient())
+		}
+		color = color.Add(emission.Mul(weight))
+
+		if !p.isSpecular(material) {
+			return color, &photonHitPoint{
+				Point:    point,
+				Normal:   collision.Normal,
+				Dest:     dest,
+				Material: material,
+				Weight:   weight,
+			}
+		}
+
+		source := material.SampleSource(gen, collision.Normal, dest)
+		density := material.SourceDensity(collision.Normal, source, dest)
+		if density == 0 {
+			break
+		}
+		bsdf := material.BSDF(collision.Normal, source, dest)
+		weight = weight.Mul(bsdf.Scale(math.Abs(source.Dot(collision.Normal)) / density))
+		ray = p.bounceRay(point, source.Scale(-1))
+	}
+	return color, nil
+}
+
+func (p *PhotonMapper) initRadii(hitPoints []*photonHitPoint) {
+	radius := p.InitialRadius
+	if radius == 0 && len(hitPoints) > 0 {
+		min, max := hitPoints[0].Point, hitPoints[0].Point
+		for _, h := range hitPoints[1:] {
+			min = min.Min(h.Point)
+			max = max.Max(h.Point)
+		}
+		radius = min.Dist(max) * photonMapperRadiusScale
+	}
+	for _, h := range hitPoints {
+		h.Radius2 = radius * radius
+	}
+}
+
+// emitPhotons traces PhotonsPerPass photons through the
+// scene and returns every diffuse interaction.
+//
+// Every photon uses its own random stream, so the result
+// is deterministic for a given pass.
+func (p *PhotonMapper) emitPhotons(obj Object, pass int) []photon {
+	sources, cumuPowers, totalPower := p.photonSources()
+
+	// Use a different seed than eye paths to avoid
+	// correlations between the two.
+	seed := int64(mixHash(uint64(p.Seed), 1))
+
+	numBatches := (p.PhotonsPerPass + photonMapperBatchSize - 1) / photonMapperBatchSize
+	batches := make([][]photon, numBatches)
+	mapIndices(numBatches, seed, func(g *goInfo, idx int) {
+		start := idx * photonMapperBatchSize
+		end := essentials.MinInt(start+photonMapperBatchSize, p.PhotonsPerPass)
+		var result []photon
+		for i := start; i < end; i++ {
+			g.StartSample(i, pass)
+			sourceIdx := sort.SearchFloat64s(cumuPowers, g.Gen.Float64()*totalPower)
+			if sourceIdx == len(cumuPowers) {
+				sourceIdx--
+			}
+			ray, power := sources[sourceIdx](g.Gen)
+			result = p.tracePhoton(g.Gen, g.SampleObject(obj), ray, power.Scale(totalPower),
+				result)
+		}
+		batches[idx] = result
+	})
+
+	var result []photon
+	for _, batch := range batches {
+		result = append(result, batch...)
+	}
+	return result
+}
+
+// photonSources gets a sampler for every light, along with
+// the cumulative power of the lights.
+//
+// Each sampler returns an emitted ray and the color of the
+// photon, normalized to have unit sum.
+func (p *PhotonMapper) photonSources() ([]func(gen *rand.Rand) (*model3d.Ray, Color),
+	[]float64, float64) {
+	var sources []func(gen *rand.Rand) (*model3d.Ray, Color)
+	var cumuPowers []float64
+	var totalPower float64
+	if p.Light != nil {
+		sources = append(sources, func(gen *rand.Rand) (*model3d.Ray, Color) {
+			point, normal, emission := p.Light.SampleLight(gen)
+			dest := sampleAngularDest(gen, normal)
+			return p.bounceRay(point, dest), emission.Scale(1 / emission.Sum())
+		})
+		// Integrating over a cosine-weighted hemisphere
+		// introduces a factor of pi.
+		totalPower += math.Pi * p.Light.TotalEmission()
+		cumuPowers = append(cumuPowers, totalPower)
+	}
+	for _, l := range p.PointLights {
+		l := l
+		sources = append(sources, func(gen *rand.Rand) (*model3d.Ray, Color) {
+			dest := model3d.XYZ(gen.NormFloat64(), gen.NormFloat64(), gen.NormFloat64())
+			return &model3d.Ray{Origin: l.Origin, Direction: dest},
+				l.Color.Scale(1 / l.Color.Sum())
+		})
+		// The intensity of a point light is pi*Color, given
+		// the shading model in PointLight.ShadeCollision.
+		totalPower += 4 * math.Pi * math.Pi * l.Color.Sum()
+		cumuPowers = append(cumuPowers, totalPower)
+	}
+	return sources, cumuPowers, totalPower
+}
+
+func (p *PhotonMapper) tracePhoton(gen *rand.Rand, obj Object, ray *model3d.Ray,
+	power Color, result []photon) []photon {
+	for depth := 0; depth <= p.MaxDepth; depth++ {
+		collision, material, ok := RandomCast(obj, gen, ray)
+		if !ok {
+			break
+		}
+		point := ray.Origin.Add(ray.Direction.Scale(collision.Scale))
+		source := ray.Direction.Normalize()
+		if !p.isSpecular(material) {
+			result = append(result, photon{
+				Point:  point,
+				Normal: collision.Normal,
+				Source: source,
+				Power:  power,
+			})
+		}
+
+		dest := SampleDest(material, gen, collision.Normal, source)
+		density := DestDensity(material, collision.Normal, source, dest)
+		if density == 0 {
+			break
+		}
+		bsdf := material.BSDF(collision.Normal, source, dest)
+		mask := bsdf.Scale(math.Abs(dest.Dot(collision.Normal)) / density)
+
+		// Russian roulette keeps photon powers roughly
+		// constant rather than letting them fade out.
+		keepProb := math.Min(1, mask.MaxCoord())
+		if keepProb <= 0 || gen.Float64() > keepProb {
+			break
+		}
+		power = power.Mul(mask.Scale(1 / keepProb))
+		ray = p.bounceRay(point, dest)
+	}
+	return result
+}
+
+func (p *PhotonMapper) isSpecular(m Material) bool {
+	if p.IsSpecular != nil {
+		return p.IsSpecular(m)
+	}
+	return defaultIsSpecular(m)
+}
+
+func (p *PhotonMapper) bounceRay(point model3d.Coord3D, dir model3d.Coord3D) *model3d.Ray {
+	eps := p.Epsilon
+	if eps == 0 {
+		eps = DefaultEpsilon
+	}
+	return &model3d.Ray{
+		// Prevent a duplicate collision from being
+		// detected when bouncing off an existing
+		// object.
+		Origin:    point.Add(dir.Normalize().Scale(eps)),
+		Direction: dir,
+	}
+}
+
+func defaultIsSpecular(m Material) bool {
+	switch m := m.(type) {
+	case *RefractMaterial:
+		return true
+	case *JoinedMaterial:
+		for _, sub := range m.Materials {
+			if !defaultIsSpecular(sub) {
+				return false
+			}
+		}
+		return len(m.Materials) > 0
+	}
+	return false
+}
+
+type photonHitPoint struct {
+	Point    model3d.Coord3D
+	Normal   model3d.Coord3D
+	Dest     model3d.Coord3D
+	Material Material
+	Weight   Color
+	PixelIdx int
+
+	Radius2 float64
+	Count   float64
+	Flux    Color
+}
+
+// Gather performs a progressive photon mapping update
+// using the photons near the hit point.
+func (h *photonHitPoint) Gather(tree *photonTree, alpha float64) {
+	var newCount float64
+	var newFlux Color
+	tree.SphereQuery(h.Point, h.Radius2, func(ph *photon) {
+		if ph.Normal.Dot(h.Normal) <= 0 {
+			return
+		}
+		newCount++
+		bsdf := h.Material.BSDF(h.Normal, ph.Source, h.Dest)
+		newFlux = newFlux.Add(bsdf.Mul(ph.Power))
+	})
+	if newCount == 0 {
+		return
+	}
+	count := h.Count + alpha*newCount
+	ratio := count / (h.Count + newCount)
+	h.Radius2 *= ratio
+	h.Flux = h.Flux.Add(newFlux).Scale(ratio)
+	h.Count = count
+}
+
+type photon struct {
+	Point  model3d.Coord3D
+	Normal model3d.Coord3D
+
+	// Source is the direction the photon was traveling.
+	Source model3d.Coord3D
+	Power  Color
+}
+
+// A photonTree is a k-d tree over photons.
+//
+// A nil *photonTree represents an empty tree.
+type photonTree struct {
+	Photon    *photon
+	SplitAxis int
+
+	LessThan     *photonTree
+	GreaterEqual *photonTree
+}
+
+func newPhotonTree(photons []photon) *photonTree {
+	ptrs := make([]*photon, len(photons))
+	for i := range photons {
+		ptrs[i] = &photons[i]
+	}
+	return newPhotonTreeAxis(ptrs, 0)
+}
+
+func newPhotonTreeAxis(photons []*photon, axis int) *photonTree {
+	if len(photons) == 0 {
+		return nil
+	}
+	sort.Slice(photons, func(i, j int) bool {
+		return photons[i].Point.Array()[axis] < photons[j].Point.Array()[axis]
+	})
+	mid := len(photons) / 2
+	// Make sure that every point in GreaterEqual is
+	// actually >= the split value.
+	for mid > 0 && photons[mid-1].Point.Array()[axis] == photons[mid].Point.Array()[axis] {
+		mid--
+	}
+	nextAxis := (axis + 1) % 3
+	return &photonTree{
+		Photon:       photons[mid],
+		SplitAxis:    axis,
+		LessThan:     newPhotonTreeAxis(photons[:mid], nextAxis),
+		GreaterEqual: newPhotonTreeAxis(photons[mid+1:], nextAxis),
+	}
+}
+
+// SphereQuery calls f for every photon within a squared
+// distance rSquared of a point c.
+func (p *photonTree) SphereQuery(c model3d.Coord3D, rSquared float64, f func(ph *photon)) {
+	if p == nil {
+		return
+	}
+	if p.Photon.Point.SquaredDist(c) <= rSquared {
+		f(p.Photon)
+	}
+	planeDist := p.Photon.Point.Array()[p.SplitAxis] - c.Array()[p.SplitAxis]
+	if planeDist > 0 || planeDist*planeDist <= rSquared {
+		p.LessThan.SphereQuery(c, rSquared, f)
+	}
+	if planeDist <= 0 || planeDist*planeDist <= rSquared {
+		p.GreaterEqual.SphereQuery(c, rSquared, f)
+	}
+}
@@ -0,0 +1,80 @@
+package render3d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestPhotonTreeSphereQuery(t *testing.T) {
+	photons := make([]photon, 1000)
+	for i := range photons {
+		photons[i].Point = model3d.NewCoord3DRandNorm()
+		if i%10 == 0 && i > 0 {
+			// Create some duplicate coordinates.
+			photons[i].Point = photons[i-1].Point
+		}
+	}
+	tree := newPhotonTree(photons)
+	for i := 0; i < 100; i++ {
+		center := model3d.NewCoord3DRandNorm()
+		radius := rand.Float64()
+		expected := map[*photon]bool{}
+		for j := range photons {
+			if photons[j].Point.Dist(center) <= radius {
+				expected[&photons[j]] = true
+			}
+		}
+		actual := map[*photon]bool{}
+		tree.SphereQuery(center, radius*radius, func(ph *photon) {
+			if actual[ph] {
+				t.Fatal("duplicate photon")
+			}
+			actual[ph] = true
+		})
+		if len(actual) != len(expected) {
+			t.Fatalf("expected %d photons but got %d", len(expected), len(actual))
+		}
+		for ph := range expected {
+			if !actual[ph] {
+				t.Fatal("missing photon")
+			}
+		}
+	}
+}
+
+func TestPhotonMapperPointLight(t *testing.T) {
+	const diffuse = 0.5
+	const lightDist = 2.0
+	obj := &ColliderObject{
+		Collider: model3d.NewRect(model3d.XYZ(-5, -5, -1), model3d.XYZ(5, 5, 0)),
+		Material: &LambertMaterial{DiffuseColor: NewColor(diffuse)},
+	}
+	mapper := &PhotonMapper{
+		Camera: NewCameraAt(model3d.Z(lightDist), model3d.Coord3D{}, 0.1),
+		PointLights: []*PointLight{
+			{
+				Origin:      model3d.Z(lightDist),
+				Color:       NewColor(1),
+				QuadDropoff: true,
+			},
+		},
+		NumPasses:      10,
+		PhotonsPerPass: 200000,
+		InitialRadius:  0.1,
+	}
+	img := NewImage(3, 3)
+	mapper.Render(img, obj)
+
+	// Compare to the shading used by the RayCaster.
+	expected := diffuse / (lightDist * lightDist)
+	actual := img.At(1, 1)
+	for _, c := range actual.Array() {
+		if math.Abs(c-expected) > expected*0.1 {
+			t.Errorf("expected brightness %f but got %v", expected, actual)
+			break
+		}
+	}
+}
@@ -20,6 +20,8 @@ type rayRenderer struct {
 	Convergence          func(mean, stddev Color) bool
 	Antialias            float64
 	LogFunc              func(frac float64, sampleRate float64)
+	Seed                 int64
+	Sequence             SampleSequence
 }
 
 func (r *rayRenderer) Render(img *Image, obj Object) {
@@ -32,8 +34,8 @@ func (r *rayRenderer) Render(img *Image, obj Object) {
 
 	progressCh := make(chan int, 1)
 	go func() {
-		mapCoordinates(img.Width, img.Height, func(g *goInfo, x, y, idx int) {
-			color, numSamples := r.estimateColor(g, obj, float64(x), float64(y), caster)
+		mapCoordinates(img.Width, img.Height, r.Seed, r.Sequence, func(g *goInfo, x, y, idx int) {
+			color, numSamples := r.estimateColor(g, obj, float64(x), float64(y), idx, caster)
 			img.Data[idx] = color
 			progressCh <- numSamples
 		})
@@ -59,8 +61,8 @@ func (r *rayRenderer) RenderVariance(img *Image, obj Object, numSamples int) {
 	maxX := float64(img.Width) - 1
 	maxY := float64(img.Height) - 1
 	caster := r.Camera.Caster(maxX, maxY)
-	mapCoordinates(img.Width, img.Height, func(g *goInfo, x, y, idx int) {
-		img.Data[idx] = r.estimateVariance(g, obj, float64(x), float64(y), caster,
+	mapCoordinates(img.Width, img.Height, r.Seed, r.Sequence, func(g *goInfo, x, y, idx int) {
+		img.Data[idx] = r.estimateVariance(g, obj, float64(x), float64(y), idx, caster,
 			numSamples)
 	})
 }
@@ -84,19 +86,20 @@ func (r *rayRenderer) RayVariance(obj Object, width, height, samples int) float6
 	return totalVariance / float64(3*width*height)
 }
 
-func (r *rayRenderer) estimateVariance(g *goInfo, obj Object, x, y float64,
+func (r *rayRenderer) estimateVariance(g *goInfo, obj Object, x, y float64, idx int,
 	caster func(x, y float64) model3d.Coord3D, numSamples int) Color {
 	ray := model3d.Ray{Origin: r.Camera.Origin}
 	ray.Direction = caster(x, y)
 	var colorSum Color
 	var colorSqSum Color
 	for i := 0; i < numSamples; i++ {
+		g.StartSample(idx, i)
 		if r.Antialias != 0 {
 			dx := r.Antialias * (g.Gen.Float64() - 0.5)
 			dy := r.Antialias * (g.Gen.Float64() - 0.5)
 			ray.Direction = caster(x+dx, y+dy)
 		}
-		sampleColor := r.RayColor(g, obj, &ray)
+		sampleColor := r.RayColor(g, g.SampleObject(obj), &ray)
 		colorSum = colorSum.Add(sampleColor)
 		colorSqSum = colorSqSum.Add(sampleColor.Mul(sampleColor))
 	}
@@ -109,7 +112,7 @@ func (r *rayRenderer) estimateVariance(g *goInfo, obj Object, x, y float64,
 	return variance.Max(Color{})
 }
 
-func (r *rayRenderer) estimateColor(g *goInfo, obj Object, x, y float64,
+func (r *rayRenderer) estimateColor(g *goInfo, obj Object, x, y float64, idx int,
 	caster func(x, y float64) model3d.Coord3D) (sampleMean Color, numSamples int) {
 	ray := model3d.Ray{Origin: r.Camera.Origin}
 	ray.Direction = caster(x, y)
@@ -117,12 +120,13 @@ func (r *rayRenderer) estimateColor(g *goInfo, obj Object, x, y float64,
 	var colorSqSum Color
 
 	for numSamples = 0; numSamples < r.NumSamples; numSamples++ {
+		g.StartSample(idx, numSamples)
 		if r.Antialias != 0 {
 			dx := r.Antialias * (g.Gen.Float64() - 0.5)
 			dy := r.Antialias * (g.Gen.Float64() - 0.5)
 			ray.Direction = caster(x+dx, y+dy)
 		}
-		sampleColor := r.RayColor(g, obj, &ray)
+		sampleColor := r.RayColor(g, g.SampleObject(obj), &ray)
 		colorSum = colorSum.Add(sampleColor)
 
 		if !r.HasConvergenceCheck() {
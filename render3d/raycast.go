@@ -9,6 +9,11 @@ import (
 type RayCaster struct {
 	Camera *Camera
 	Lights []*PointLight
+
+	// Seed determines the random numbers used by any
+	// RandomObjects in the scene, such as a
+	// ParticipatingMedium.
+	Seed int64
 }
 
 // Render renders the object to an image.
@@ -17,12 +22,12 @@ func (r *RayCaster) Render(img *Image, obj Object) {
 	maxY := float64(img.Height) - 1
 	caster := r.Camera.Caster(maxX, maxY)
 
-	mapCoordinates(img.Width, img.Height, func(g *goInfo, x, y, idx int) {
+	mapCoordinates(img.Width, img.Height, r.Seed, PseudoRandomSequence, func(g *goInfo, x, y, idx int) {
 		ray := model3d.Ray{
 			Origin:    r.Camera.Origin,
 			Direction: caster(float64(x), float64(y)),
 		}
-		collision, material, ok := obj.Cast(&ray)
+		collision, material, ok := RandomCast(g.SampleObject(obj), g.Gen, &ray)
 		if !ok {
 			return
 		}
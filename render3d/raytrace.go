@@ -92,6 +92,18 @@ type RecursiveRayTracer struct {
 	// The sampleRate argument specifies the mean number
 	// of rays traced per pixel.
 	LogFunc func(frac float64, sampleRate float64)
+
+	// Seed determines the random numbers used for
+	// rendering. Every pixel and sample derives its own
+	// random stream from the seed, so rendering is
+	// deterministic regardless of scheduling.
+	Seed int64
+
+	// Sequence, if specified, uses a low-discrepancy
+	// sequence for the first few random numbers of each
+	// sample, such as antialiasing offsets and BSDF
+	// samples.
+	Sequence SampleSequence
 }
 
 // Render renders the object to an image.
@@ -132,6 +144,8 @@ func (r *RecursiveRayTracer) rayRenderer() *rayRenderer {
 		Convergence:          r.Convergence,
 		Antialias:            r.Antialias,
 		LogFunc:              r.LogFunc,
+		Seed:                 r.Seed,
+		Sequence:             r.Sequence,
 	}
 }
 
@@ -140,7 +154,7 @@ func (r *RecursiveRayTracer) recurse(gen *rand.Rand, obj Object, ray *model3d.Ra
 	if scale.Sum()/3 < r.Cutoff {
 		return Color{}
 	}
-	collision, material, ok := obj.Cast(ray)
+	collision, material, ok := RandomCast(obj, gen, ray)
 	if !ok {
 		return Color{}
 	}
@@ -157,7 +171,7 @@ func (r *RecursiveRayTracer) recurse(gen *rand.Rand, obj Object, ray *model3d.Ra
 		lightDirection := l.Origin.Sub(point)
 
 		shadowRay := r.bounceRay(point, lightDirection)
-		shadowCollision, _, ok := obj.Cast(shadowRay)
+		shadowCollision, _, ok := RandomCast(obj, gen, shadowRay)
 		if ok && shadowCollision.Scale < 1 {
 			continue
 		}
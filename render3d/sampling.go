@@ -0,0 +1,222 @@
+package render3d
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// A SampleSequence determines how random numbers are
+// generated for the samples of each pixel.
+//
+// Regardless of the sequence, every pixel and sample is
+// given its own deterministic random stream derived from
+// a renderer's seed, so rendering results do not depend
+// on how work is scheduled across goroutines.
+type SampleSequence int
+
+const (
+	// PseudoRandomSequence uses independent pseudo-random
+	// numbers for every sample.
+	PseudoRandomSequence SampleSequence = iota
+
+	// HaltonSequence uses a randomly shifted Halton
+	// sequence for the first few random numbers of each
+	// sample, such as antialiasing offsets and the first
+	// few BSDF samples.
+	HaltonSequence
+
+	// SobolSequence is like HaltonSequence, but uses a
+	// randomly shifted Sobol sequence.
+	SobolSequence
+)
+
+// maxSequenceDims is the number of random numbers per
+// sample that come from a low-discrepancy sequence.
+// Further random numbers are pseudo-random.
+const maxSequenceDims = 16
+
+var haltonPrimes = [maxSequenceDims]uint64{
+	2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53,
+}
+
+// shutterHashKey distinguishes shutter times from other
+// hashes of the same pixel and sample.
+const shutterHashKey = 0x5348555454455221
+
+// sobolParams stores the degree, polynomial coefficients,
+// and initial direction numbers for each dimension after
+// the first, following Joe and Kuo.
+var sobolParams = [maxSequenceDims - 1]struct {
+	S int
+	A uint32
+	M []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+}
+
+// sobolDirections[d][i] is the i-th direction number for
+// dimension d, scaled to 32 bits.
+var sobolDirections = computeSobolDirections()
+
+func computeSobolDirections() [maxSequenceDims][32]uint32 {
+	var res [maxSequenceDims][32]uint32
+	for i := 0; i < 32; i++ {
+		res[0][i] = 1 << uint(31-i)
+	}
+	for d, params := range sobolParams {
+		v := &res[d+1]
+		s := params.S
+		for i := 0; i < 32 && i < s; i++ {
+			v[i] = params.M[i] << uint(31-i)
+		}
+		for i := s; i < 32; i++ {
+			v[i] = v[i-s] ^ (v[i-s] >> uint(s))
+			for k := 1; k < s; k++ {
+				if (params.A>>uint(s-1-k))&1 != 0 {
+					v[i] ^= v[i-k]
+				}
+			}
+		}
+	}
+	return res
+}
+
+// sobolSample computes the dim-th coordinate of the
+// idx-th point in the Sobol sequence.
+func sobolSample(idx uint64, dim int) float64 {
+	var res uint32
+	for i := 0; idx != 0 && i < 32; i++ {
+		if idx&1 != 0 {
+			res ^= sobolDirections[dim][i]
+		}
+		idx >>= 1
+	}
+	return float64(res) / (1 << 32)
+}
+
+// haltonSample computes the dim-th coordinate of the
+// idx-th point in the Halton sequence.
+func haltonSample(idx uint64, dim int) float64 {
+	base := haltonPrimes[dim]
+	invBase := 1 / float64(base)
+	var res float64
+	scale := invBase
+	for idx > 0 {
+		res += float64(idx%base) * scale
+		idx /= base
+		scale *= invBase
+	}
+	return res
+}
+
+// sampleSource is a rand.Source64 which can be cheaply
+// reset to a deterministic stream for any pixel sample.
+type sampleSource struct {
+	sequence SampleSequence
+	seed     uint64
+
+	state uint64
+	pixel uint64
+	index uint64
+	dim   int
+}
+
+func newSampleSource(seed int64, sequence SampleSequence) *sampleSource {
+	return &sampleSource{sequence: sequence, seed: uint64(seed)}
+}
+
+// Reset starts the stream for the given sample of the
+// given pixel (or other work item).
+func (s *sampleSource) Reset(pixel, sample int) {
+	s.pixel = uint64(pixel)
+	s.index = uint64(sample)
+	s.dim = 0
+	s.state = mixHash(s.seed, s.pixel, s.index)
+}
+
+// ShutterTime gets a random time in [0, 1) for the current
+// sample, independent of the rest of the stream.
+func (s *sampleSource) ShutterTime() float64 {
+	return float64(mixHash(s.seed, s.pixel, s.index, shutterHashKey)>>11) / (1 << 53)
+}
+
+func (s *sampleSource) Seed(seed int64) {
+	s.seed = uint64(seed)
+	s.Reset(0, 0)
+}
+
+func (s *sampleSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *sampleSource) Uint64() uint64 {
+	if s.sequence != PseudoRandomSequence && s.dim < maxSequenceDims {
+		var x float64
+		if s.sequence == SobolSequence {
+			x = sobolSample(s.index, s.dim)
+		} else {
+			x = haltonSample(s.index+1, s.dim)
+		}
+		// Cranley-Patterson rotation decorrelates pixels.
+		shift := float64(mixHash(s.seed, s.pixel, uint64(s.dim))>>11) / (1 << 53)
+		x += shift
+		if x >= 1 {
+			x -= 1
+		}
+		s.dim++
+		return uint64(x*(1<<53)) << 11
+	}
+	return splitMix64(&s.state)
+}
+
+// splitMix64 implements the SplitMix64 generator.
+func splitMix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// mixHash deterministically hashes a list of integers.
+func mixHash(values ...uint64) uint64 {
+	var state uint64
+	for _, v := range values {
+		state ^= v
+		state = splitMix64(&state)
+	}
+	return state
+}
+
+// randomUnitVector samples a uniformly random unit vector
+// using gen, or the global generator if gen is nil.
+//
+// Don't use model3d.NewCoord3DRandUnit() directly, since
+// it won't use our per-sample RNG.
+func randomUnitVector(gen *rand.Rand) model3d.Coord3D {
+	if gen == nil {
+		return model3d.NewCoord3DRandUnit()
+	}
+	for {
+		v := model3d.XYZ(gen.NormFloat64(), gen.NormFloat64(), gen.NormFloat64())
+		n := v.Norm()
+		if n > 0.01 && n < 100.0 {
+			return v.Scale(1 / n)
+		}
+	}
+}
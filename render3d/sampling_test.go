@@ -0,0 +1,153 @@
+package render3d
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestSobolStratified(t *testing.T) {
+	const logN = 8
+	for dim := 0; dim < maxSequenceDims; dim++ {
+		seen := map[int]bool{}
+		for i := 0; i < 1<<logN; i++ {
+			bucket := int(sobolSample(uint64(i), dim) * (1 << logN))
+			if seen[bucket] {
+				t.Fatalf("dim %d: duplicate bucket %d at index %d", dim, bucket, i)
+			}
+			seen[bucket] = true
+		}
+	}
+}
+
+func TestSampleSourceUniform(t *testing.T) {
+	for _, seq := range []SampleSequence{PseudoRandomSequence, HaltonSequence, SobolSequence} {
+		g := newGoInfo(1337, seq)
+		const numPixels = 100
+		const numSamples = 64
+		for dim := 0; dim < maxSequenceDims+2; dim++ {
+			var sum float64
+			for pixel := 0; pixel < numPixels; pixel++ {
+				for sample := 0; sample < numSamples; sample++ {
+					g.StartSample(pixel, sample)
+					var x float64
+					for i := 0; i <= dim; i++ {
+						x = g.Gen.Float64()
+					}
+					if x < 0 || x >= 1 {
+						t.Fatalf("sequence %d: invalid sample %f", seq, x)
+					}
+					sum += x
+				}
+			}
+			mean := sum / (numPixels * numSamples)
+			if math.Abs(mean-0.5) > 0.02 {
+				t.Errorf("sequence %d dim %d: unexpected mean %f", seq, dim, mean)
+			}
+		}
+	}
+}
+
+type testRenderer interface {
+	Render(img *Image, obj Object)
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	obj := JoinedObject{
+		&ColliderObject{
+			Collider: model3d.NewRect(model3d.XYZ(-5, -5, -1), model3d.XYZ(5, 5, 0)),
+			Material: &LambertMaterial{DiffuseColor: NewColor(0.5)},
+		},
+		&ParticipatingMedium{
+			Collider: &model3d.Sphere{Center: model3d.Z(1), Radius: 0.9},
+			Material: &HGMaterial{G: 0.5, ScatterColor: NewColor(0.8)},
+			Lambda:   1.0,
+		},
+		&ColliderObject{
+			Collider: &model3d.Sphere{Center: model3d.XYZ(2, 2, 4), Radius: 1},
+			Material: &LambertMaterial{EmissionColor: NewColor(5)},
+		},
+	}
+	camera := NewCameraAt(model3d.XYZ(0, -5, 3), model3d.Z(1), 0)
+
+	for _, seq := range []SampleSequence{PseudoRandomSequence, SobolSequence} {
+		renderers := map[string]func(seed int64) testRenderer{
+			"RecursiveRayTracer": func(seed int64) testRenderer {
+				return &RecursiveRayTracer{
+					Camera:     camera,
+					MaxDepth:   3,
+					NumSamples: 4,
+					Antialias:  1,
+					Seed:       seed,
+					Sequence:   seq,
+				}
+			},
+			"BidirPathTracer": func(seed int64) testRenderer {
+				return &BidirPathTracer{
+					Camera: camera,
+					Light: NewSphereAreaLight(
+						&model3d.Sphere{Center: model3d.XYZ(2, 2, 4), Radius: 1},
+						NewColor(5),
+					),
+					MaxDepth:   3,
+					NumSamples: 4,
+					Antialias:  1,
+					Seed:       seed,
+					Sequence:   seq,
+				}
+			},
+		}
+		for name, makeRenderer := range renderers {
+			render := func(seed int64, procs int) *Image {
+				defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+				img := NewImage(16, 16)
+				makeRenderer(seed).Render(img, obj)
+				return img
+			}
+			img1 := render(1, 1)
+			img2 := render(1, 4)
+			img3 := render(2, 4)
+			for i, c := range img1.Data {
+				if c != img2.Data[i] {
+					t.Fatalf("%s (sequence %d): pixel %d differs: %v vs %v", name, seq, i,
+						c, img2.Data[i])
+				}
+			}
+			var numDiff int
+			for i, c := range img1.Data {
+				if c != img3.Data[i] {
+					numDiff++
+				}
+			}
+			if numDiff == 0 {
+				t.Errorf("%s (sequence %d): different seeds produced identical images",
+					name, seq)
+			}
+		}
+	}
+}
+
+func TestSaveRandomGridSeedDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	obj := &model3d.Rect{MaxVal: model3d.XYZ(1, 2, 3)}
+	var outputs [][]byte
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(dir, "grid.png")
+		if err := SaveRandomGridSeed(path, obj, 2, 2, 16, 1337, nil); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Error("grids differ between calls")
+	}
+}
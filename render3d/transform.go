@@ -1,6 +1,10 @@
 package render3d
 
-import "github.com/unixpickle/model3d/model3d"
+import (
+	"math/rand"
+
+	"github.com/unixpickle/model3d/model3d"
+)
 
 // Translate moves the object by an additive offset.
 func Translate(obj Object, offset model3d.Coord3D) Object {
@@ -24,7 +28,12 @@ func (t *translatedObject) Max() model3d.Coord3D {
 }
 
 func (t *translatedObject) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
-	return t.Object.Cast(&model3d.Ray{
+	return t.RandomCast(nil, r)
+}
+
+func (t *translatedObject) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	return RandomCast(t.Object, gen, &model3d.Ray{
 		Origin:    r.Origin.Sub(t.Offset),
 		Direction: r.Direction,
 	})
@@ -74,7 +83,12 @@ func (m *matrixObject) Max() model3d.Coord3D {
 }
 
 func (m *matrixObject) Cast(r *model3d.Ray) (model3d.RayCollision, Material, bool) {
-	rc, mat, ok := m.Object.Cast(&model3d.Ray{
+	return m.RandomCast(nil, r)
+}
+
+func (m *matrixObject) RandomCast(gen *rand.Rand, r *model3d.Ray) (model3d.RayCollision,
+	Material, bool) {
+	rc, mat, ok := RandomCast(m.Object, gen, &model3d.Ray{
 		Origin:    m.Inverse.MulColumn(r.Origin),
 		Direction: m.Inverse.MulColumn(r.Direction),
 	})
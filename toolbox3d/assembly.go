@@ -0,0 +1,285 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model3d"
+	"github.com/unixpickle/model3d/render3d"
+)
+
+// An AssemblyPart is a named part in an assembly.
+type AssemblyPart struct {
+	Name string
+	Mesh *model3d.Mesh
+
+	// Transform positions the part in the assembly.
+	// If nil, the mesh is used as-is.
+	Transform model3d.Transform
+}
+
+// A PartClearance describes how close two parts in an
+// assembly are to each other.
+type PartClearance struct {
+	Part1 string
+	Part2 string
+
+	// Intersecting is true if the parts overlap, either
+	// because their surfaces intersect or because one part
+	// is inside the other.
+	Intersecting bool
+
+	// Intersections are the segments where the surfaces
+	// of the two parts intersect.
+	Intersections []model3d.Segment
+
+	// Distance is the minimum distance between the
+	// surfaces of the parts, which is 0 if the parts
+	// overlap.
+	Distance float64
+
+	// Closest1 and Closest2 are the closest pair of points
+	// on the first and second part, respectively.
+	Closest1 model3d.Coord3D
+	Closest2 model3d.Coord3D
+
+	// Close1 and Close2 are the triangles of each part
+	// (after transformation) which are closer to the other
+	// part than the tolerance.
+	Close1 []*model3d.Triangle
+	Close2 []*model3d.Triangle
+}
+
+// An AssemblyReport is the result of CheckAssembly.
+type AssemblyReport struct {
+	Tolerance float64
+
+	// Meshes are the transformed meshes for every part.
+	Meshes []*model3d.Mesh
+
+	// Pairs contains an entry for every pair of parts
+	// whose bounding boxes are within the tolerance.
+	// Pairs of parts which are further apart are omitted.
+	Pairs []*PartClearance
+
+	scores map[*model3d.Triangle]float64
+}
+
+// CheckAssembly finds intersections and insufficient
+// clearances between every pair of parts in an assembly.
+//
+// Each part's mesh should be closed and have consistent
+// normals.
+// The tolerance is the minimum clearance required between
+// parts. Triangles that are closer than this distance to
+// another part are reported, where distances are measured
+// at the vertices, edge midpoints, and centroid of each
+// triangle.
+func CheckAssembly(parts []*AssemblyPart, tolerance float64) *AssemblyReport {
+	meshes := make([]*model3d.Mesh, len(parts))
+	colliders := make([]model3d.MultiCollider, len(parts))
+	sdfs := make([]model3d.PointSDF, len(parts))
+	essentials.ConcurrentMap(0, len(parts), func(i int) {
+		mesh := parts[i].Mesh
+		if parts[i].Transform != nil {
+			mesh = mesh.Transform(parts[i].Transform)
+		}
+		meshes[i] = mesh
+		if mesh.NumTriangles() > 0 {
+			colliders[i] = model3d.MeshToCollider(mesh)
+			sdfs[i] = model3d.MeshToSDF(mesh)
+		}
+	})
+
+	report := &AssemblyReport{
+		Tolerance: tolerance,
+		Meshes:    meshes,
+		scores:    map[*model3d.Triangle]float64{},
+	}
+	for i := 0; i < len(parts); i++ {
+		for j := i + 1; j < len(parts); j++ {
+			if sdfs[i] == nil || sdfs[j] == nil {
+				continue
+			}
+			if !boundsWithin(meshes[i], meshes[j], tolerance) {
+				continue
+			}
+			pair := &PartClearance{Part1: parts[i].Name, Part2: parts[j].Name}
+			report.checkIntersection(pair, meshes[i], meshes[j], colliders[i], colliders[j])
+			report.checkDistance(pair, meshes[i], meshes[j], sdfs[i], sdfs[j])
+			pair.Close1 = report.closeTriangles(meshes[i], sdfs[j], pair.Intersecting)
+			pair.Close2 = report.closeTriangles(meshes[j], sdfs[i], pair.Intersecting)
+			report.Pairs = append(report.Pairs, pair)
+		}
+	}
+	return report
+}
+
+// Intersecting gets all of the pairs of parts which
+// overlap.
+func (a *AssemblyReport) Intersecting() []*PartClearance {
+	var result []*PartClearance
+	for _, p := range a.Pairs {
+		if p.Intersecting {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// TooClose gets all of the pairs of parts which overlap or
+// are closer than the tolerance.
+func (a *AssemblyReport) TooClose() []*PartClearance {
+	var result []*PartClearance
+	for _, p := range a.Pairs {
+		if p.Intersecting || p.Distance < a.Tolerance {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Mesh joins the transformed meshes of all the parts.
+func (a *AssemblyReport) Mesh() *model3d.Mesh {
+	result := model3d.NewMesh()
+	for _, m := range a.Meshes {
+		result.AddMesh(m)
+	}
+	return result
+}
+
+// HeatMap creates a CoordColorFunc for rendering Mesh(),
+// where regions closer than the tolerance to another part
+// are colored from yellow to red, and the rest is gray.
+func (a *AssemblyReport) HeatMap() CoordColorFunc {
+	mesh := a.Mesh()
+	if mesh.NumTriangles() == 0 {
+		return ConstantCoordColorFunc(printabilityHeatColor(0))
+	}
+	sdf := model3d.MeshToSDF(mesh)
+	return func(c model3d.Coord3D) render3d.Color {
+		t, _, _ := sdf.FaceSDF(c)
+		return printabilityHeatColor(a.scores[t])
+	}
+}
+
+func (a *AssemblyReport) checkIntersection(pair *PartClearance, m1, m2 *model3d.Mesh,
+	c1, c2 model3d.MultiCollider) {
+	m1.Iterate(func(t *model3d.Triangle) {
+		if segs := c2.TriangleCollisions(t); len(segs) > 0 {
+			pair.Intersections = append(pair.Intersections, segs...)
+			a.scores[t] = 1
+		}
+	})
+	if len(pair.Intersections) > 0 {
+		pair.Intersecting = true
+		m2.Iterate(func(t *model3d.Triangle) {
+			if len(c1.TriangleCollisions(t)) > 0 {
+				a.scores[t] = 1
+			}
+		})
+		return
+	}
+
+	// Check if one part is entirely inside the other.
+	v1 := m1.VertexSlice()[0]
+	v2 := m2.VertexSlice()[0]
+	if model3d.ColliderContains(c2, v1, 0) || model3d.ColliderContains(c1, v2, 0) {
+		pair.Intersecting = true
+	}
+}
+
+func (a *AssemblyReport) checkDistance(pair *PartClearance, m1, m2 *model3d.Mesh,
+	sdf1, sdf2 model3d.PointSDF) {
+	if len(pair.Intersections) > 0 {
+		pair.Closest1 = pair.Intersections[0][0]
+		pair.Closest2 = pair.Closest1
+		return
+	}
+
+	// Find the closest vertex on either mesh, and then
+	// refine the pair of points by alternating projections
+	// to catch edge-edge and face-face cases.
+	p1, p2, dist := closestVertexPair(m1, sdf2)
+	q2, q1, dist1 := closestVertexPair(m2, sdf1)
+	if dist1 < dist {
+		p1, p2, dist = q1, q2, dist1
+	}
+	for i := 0; i < 100; i++ {
+		next1, _ := sdf1.PointSDF(p2)
+		next2, _ := sdf2.PointSDF(next1)
+		nextDist := next1.Dist(next2)
+		if nextDist >= dist-1e-12 {
+			break
+		}
+		p1, p2, dist = next1, next2, nextDist
+	}
+	pair.Closest1 = p1
+	pair.Closest2 = p2
+	if !pair.Intersecting {
+		pair.Distance = dist
+	}
+}
+
+func closestVertexPair(m *model3d.Mesh, sdf model3d.PointSDF) (p, closest model3d.Coord3D,
+	dist float64) {
+	vertices := m.VertexSlice()
+	closests := make([]model3d.Coord3D, len(vertices))
+	dists := make([]float64, len(vertices))
+	essentials.ConcurrentMap(0, len(vertices), func(i int) {
+		var d float64
+		closests[i], d = sdf.PointSDF(vertices[i])
+		dists[i] = math.Abs(d)
+	})
+	dist = math.Inf(1)
+	for i, d := range dists {
+		if d < dist {
+			dist = d
+			p = vertices[i]
+			closest = closests[i]
+		}
+	}
+	return
+}
+
+func (a *AssemblyReport) closeTriangles(m *model3d.Mesh, other model3d.PointSDF,
+	intersecting bool) []*model3d.Triangle {
+	tris := m.TriangleSlice()
+	dists := make([]float64, len(tris))
+	essentials.ConcurrentMap(0, len(tris), func(i int) {
+		t := tris[i]
+		points := []model3d.Coord3D{
+			t[0], t[1], t[2],
+			t[0].Mid(t[1]), t[1].Mid(t[2]), t[2].Mid(t[0]),
+			t[0].Add(t[1]).Add(t[2]).Scale(1.0 / 3),
+		}
+		dists[i] = math.Inf(1)
+		for _, p := range points {
+			d := other.SDF(p)
+			if d > 0 {
+				// The point is inside the other part.
+				d = 0
+			}
+			dists[i] = math.Min(dists[i], -d)
+		}
+	})
+	var result []*model3d.Triangle
+	for i, t := range tris {
+		if dists[i] < a.Tolerance || (intersecting && dists[i] == 0) {
+			result = append(result, t)
+			score := 1.0
+			if a.Tolerance > 0 {
+				score = 1 - 0.5*dists[i]/a.Tolerance
+			}
+			a.scores[t] = math.Max(a.scores[t], score)
+		}
+	}
+	return result
+}
+
+func boundsWithin(m1, m2 *model3d.Mesh, dist float64) bool {
+	min1, max1 := m1.Min(), m1.Max()
+	min2, max2 := m2.Min(), m2.Max()
+	gap := min1.Sub(max2).Max(min2.Sub(max1))
+	return gap.X <= dist && gap.Y <= dist && gap.Z <= dist
+}
@@ -0,0 +1,117 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestCheckAssembly(t *testing.T) {
+	box := model3d.NewMeshRect(model3d.XYZ(0, 0, 0), model3d.XYZ(1, 1, 1))
+	parts := []*AssemblyPart{
+		{Name: "base", Mesh: box},
+		{
+			Name:      "near",
+			Mesh:      box,
+			Transform: &model3d.Translate{Offset: model3d.X(1.3)},
+		},
+		{
+			Name:      "overlapping",
+			Mesh:      box,
+			Transform: &model3d.Translate{Offset: model3d.XYZ(-0.6, 0.5, -0.5)},
+		},
+		{
+			Name: "nested",
+			Mesh: model3d.NewMeshRect(model3d.XYZ(0.4, 0.4, 0.6), model3d.XYZ(0.6, 0.6, 0.8)),
+		},
+		{
+			Name:      "far",
+			Mesh:      box,
+			Transform: &model3d.Translate{Offset: model3d.X(10)},
+		},
+	}
+	report := CheckAssembly(parts, 0.5)
+
+	pairs := map[[2]string]*PartClearance{}
+	for _, p := range report.Pairs {
+		pairs[[2]string{p.Part1, p.Part2}] = p
+		if p.Part1 == "far" || p.Part2 == "far" {
+			t.Errorf("unexpected pair: %s, %s", p.Part1, p.Part2)
+		}
+	}
+
+	near := pairs[[2]string{"base", "near"}]
+	if near == nil {
+		t.Fatal("missing base-near pair")
+	}
+	if near.Intersecting || math.Abs(near.Distance-0.3) > 1e-8 {
+		t.Errorf("unexpected base-near clearance: %v %f", near.Intersecting, near.Distance)
+	}
+	if math.Abs(near.Closest1.X-1) > 1e-8 || math.Abs(near.Closest2.X-1.3) > 1e-8 {
+		t.Errorf("unexpected closest points: %v %v", near.Closest1, near.Closest2)
+	}
+	for _, tri := range near.Close1 {
+		if tri.Max().X < 0.5 {
+			t.Errorf("triangle %v should not be close", tri)
+		}
+	}
+	if len(near.Close1) == 0 || len(near.Close2) == 0 {
+		t.Error("missing close triangles")
+	}
+
+	overlapping := pairs[[2]string{"base", "overlapping"}]
+	if overlapping == nil || !overlapping.Intersecting || len(overlapping.Intersections) == 0 ||
+		overlapping.Distance != 0 {
+		t.Errorf("unexpected base-overlapping clearance: %+v", overlapping)
+	}
+
+	nested := pairs[[2]string{"base", "nested"}]
+	if nested == nil || !nested.Intersecting || len(nested.Intersections) != 0 {
+		t.Errorf("unexpected base-nested clearance: %+v", nested)
+	}
+
+	if len(report.Intersecting()) != 2 {
+		t.Errorf("expected 2 intersecting pairs but got %d", len(report.Intersecting()))
+	}
+
+	heatMap := report.HeatMap()
+	if c := heatMap(model3d.XYZ(1, 0.5, 0.5)); c != printabilityHeatColor(1-0.5*0.3/0.5) {
+		t.Errorf("unexpected color near the gap: %v", c)
+	}
+	if c := heatMap(model3d.XYZ(10.5, 0.5, 1)); c != printabilityHeatColor(0) {
+		t.Errorf("unexpected color far away: %v", c)
+	}
+}
+
+func TestCheckAssemblyEdgeDistance(t *testing.T) {
+	// Two cubes whose closest features are perpendicular
+	// edges, which are further from all the vertices.
+	box := model3d.NewMeshRect(model3d.XYZ(-0.5, -0.5, -0.5), model3d.XYZ(0.5, 0.5, 0.5))
+	parts := []*AssemblyPart{
+		{
+			Name:      "a",
+			Mesh:      box,
+			Transform: model3d.Rotation(model3d.Z(1), math.Pi/4),
+		},
+		{
+			Name: "b",
+			Mesh: box,
+			Transform: model3d.JoinedTransform{
+				model3d.Rotation(model3d.Y(1), math.Pi/4),
+				&model3d.Translate{Offset: model3d.X(math.Sqrt2 + 0.1)},
+			},
+		},
+	}
+	report := CheckAssembly(parts, 1)
+	if len(report.Pairs) != 1 {
+		t.Fatal("expected one pair")
+	}
+	pair := report.Pairs[0]
+	if math.Abs(pair.Distance-0.1) > 1e-5 {
+		t.Errorf("expected distance 0.1 but got %f", pair.Distance)
+	}
+	if math.Abs(pair.Closest1.Dist(pair.Closest2)-pair.Distance) > 1e-8 {
+		t.Errorf("closest points %v, %v do not match distance", pair.Closest1, pair.Closest2)
+	}
+}
@@ -0,0 +1,320 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// FastenerTolerance specifies clearances to add to printed
+// fastener parts so that they fit together despite the
+// inaccuracy of a 3D printer.
+//
+// All values are in millimeters.
+type FastenerTolerance struct {
+	// ThreadClearance is the total radial gap between a
+	// printed external thread and a printed internal
+	// thread. Half of it is applied to each thread.
+	ThreadClearance float64
+
+	// HoleClearance is added to the radius of clearance
+	// holes, countersinks, and nut traps.
+	HoleClearance float64
+}
+
+var (
+	// ExactTolerance adds no clearance, and is useful for
+	// threads which will be tapped or cut afterwards.
+	ExactTolerance = FastenerTolerance{}
+
+	// TightTolerance is for well-calibrated printers.
+	TightTolerance = FastenerTolerance{ThreadClearance: 0.2, HoleClearance: 0.1}
+
+	// StandardTolerance works on most FDM printers.
+	StandardTolerance = FastenerTolerance{ThreadClearance: 0.4, HoleClearance: 0.2}
+
+	// LooseTolerance is for poorly calibrated printers, or
+	// for parts that should move freely.
+	LooseTolerance = FastenerTolerance{ThreadClearance: 0.6, HoleClearance: 0.3}
+)
+
+// A FastenerSpec describes the dimensions of standard
+// hardware for a given thread.
+//
+// All dimensions are in millimeters.
+type FastenerSpec struct {
+	Thread ThreadSpec
+
+	// NutWidth is the distance across the flats of a hex
+	// nut.
+	NutWidth float64
+
+	// NutThickness is the height of a hex nut.
+	NutThickness float64
+
+	// HeadWidth is the distance across the flats of a hex
+	// bolt head.
+	HeadWidth float64
+
+	// HeadHeight is the height of a hex bolt head.
+	HeadHeight float64
+
+	// CountersinkDiameter is the head diameter of a flat
+	// (90 degree) countersunk screw.
+	CountersinkDiameter float64
+
+	// ClearanceDiameter is the diameter of a hole through
+	// which the screw can pass freely.
+	ClearanceDiameter float64
+
+	// InsertDiameter is the hole diameter for a heat-set
+	// threaded insert.
+	InsertDiameter float64
+
+	// InsertLength is the length of a heat-set insert.
+	InsertLength float64
+}
+
+// ParseFastenerSpec creates a FastenerSpec from a thread
+// designation (see ParseThreadSpec).
+//
+// Dimensions come from ISO and ASME standards for common
+// sizes, and are otherwise approximated from the diameter.
+func ParseFastenerSpec(designation string) (*FastenerSpec, error) {
+	thread, size, err := parseThreadDesignation(designation)
+	if err != nil {
+		return nil, err
+	}
+	d := thread.MajorDiameter
+	res := &FastenerSpec{
+		Thread:              *thread,
+		NutWidth:            1.6*d + 0.8,
+		NutThickness:        0.8 * d,
+		HeadHeight:          0.65 * d,
+		CountersinkDiameter: 2.24 * d,
+		ClearanceDiameter:   1.1 * d,
+		InsertDiameter:      1.35 * d,
+		InsertLength:        1.6 * d,
+	}
+	if info, ok := metricFasteners[size]; ok {
+		res.NutWidth = info.NutWidth
+		res.NutThickness = info.NutThickness
+		res.HeadHeight = info.HeadHeight
+		res.CountersinkDiameter = info.CountersinkDiameter
+		res.ClearanceDiameter = info.ClearanceDiameter
+		if info.InsertDiameter != 0 {
+			res.InsertDiameter = info.InsertDiameter
+			res.InsertLength = info.InsertLength
+		}
+	} else if info, ok := unifiedFasteners[size]; ok {
+		res.NutWidth = info.NutWidth * 25.4
+		res.NutThickness = info.NutThickness * 25.4
+	}
+	res.HeadWidth = res.NutWidth
+	return res, nil
+}
+
+// MustParseFastenerSpec is like ParseFastenerSpec, but
+// panics if the designation is invalid.
+func MustParseFastenerSpec(designation string) *FastenerSpec {
+	spec, err := ParseFastenerSpec(designation)
+	if err != nil {
+		panic(errors.Wrap(err, "must parse fastener spec"))
+	}
+	return spec
+}
+
+// HexNut creates a hex nut between p1 and p2.
+//
+// Typically, p2 = p1 + axis*f.NutThickness.
+// The internal thread is enlarged according to tol.
+func (f *FastenerSpec) HexNut(p1, p2 model3d.Coord3D, tol FastenerTolerance) model3d.Solid {
+	return &model3d.SubtractedSolid{
+		Positive: hexPrism(p1, p2, f.NutWidth),
+		Negative: f.Thread.Internal(p1, p2, tol, false),
+	}
+}
+
+// Bolt creates a hex bolt whose head rests on p1 and whose
+// threaded shaft extends to p2.
+//
+// The head extends from p1 in the direction away from p2.
+func (f *FastenerSpec) Bolt(p1, p2 model3d.Coord3D, tol FastenerTolerance) model3d.Solid {
+	axis := p2.Sub(p1).Normalize()
+	return model3d.JoinedSolid{
+		hexPrism(p1.Sub(axis.Scale(f.HeadHeight)), p1, f.HeadWidth),
+		f.Thread.External(p1, p2, tol),
+	}
+}
+
+// NutTrap creates a hexagonal pocket between p1 and p2
+// for holding a nut, along with a clearance hole for the
+// bolt, which extends to boltEnd.
+//
+// The result should be subtracted from another solid.
+// The pocket is enlarged by tol.HoleClearance.
+func (f *FastenerSpec) NutTrap(p1, p2, boltEnd model3d.Coord3D,
+	tol FastenerTolerance) model3d.Solid {
+	return model3d.JoinedSolid{
+		hexPrism(p1, p2, f.NutWidth+2*tol.HoleClearance),
+		f.ClearanceHole(p1, boltEnd, tol),
+	}
+}
+
+// ClearanceHole creates a cylindrical hole between p1 and
+// p2 through which a screw can pass freely.
+//
+// The result should be subtracted from another solid.
+func (f *FastenerSpec) ClearanceHole(p1, p2 model3d.Coord3D,
+	tol FastenerTolerance) model3d.Solid {
+	return &model3d.Cylinder{
+		P1:     p1,
+		P2:     p2,
+		Radius: f.ClearanceDiameter/2 + tol.HoleClearance,
+	}
+}
+
+// CountersunkHole creates a clearance hole from p1 to p2
+// with a 90 degree countersink at p1, for a flat head
+// screw whose head will be flush with the surface at p1.
+//
+// The result should be subtracted from another solid.
+func (f *FastenerSpec) CountersunkHole(p1, p2 model3d.Coord3D,
+	tol FastenerTolerance) model3d.Solid {
+	headRadius := f.CountersinkDiameter/2 + tol.HoleClearance
+	holeRadius := f.ClearanceDiameter/2 + tol.HoleClearance
+	axis := p2.Sub(p1).Normalize()
+	return model3d.JoinedSolid{
+		&model3d.ConeSlice{
+			P1: p1,
+			P2: p1.Add(axis.Scale(headRadius - holeRadius)),
+			R1: headRadius,
+			R2: holeRadius,
+		},
+		&model3d.Cylinder{
+			P1:     p1,
+			P2:     p2,
+			Radius: holeRadius,
+		},
+	}
+}
+
+// HeatSetBoss creates a cylindrical boss from p1 to p2
+// with a hole at the p2 end for a heat-set insert.
+//
+// The wall argument specifies the thickness of the boss
+// around the hole.
+// The hole is slightly deeper than the insert so that
+// melted plastic has somewhere to go.
+func (f *FastenerSpec) HeatSetBoss(p1, p2 model3d.Coord3D, wall float64) model3d.Solid {
+	holeRadius := f.InsertDiameter / 2
+	axis := p2.Sub(p1)
+	depth := math.Min(axis.Norm(), f.InsertLength+f.Thread.Pitch*2)
+	return &model3d.SubtractedSolid{
+		Positive: &model3d.Cylinder{
+			P1:     p1,
+			P2:     p2,
+			Radius: holeRadius + wall,
+		},
+		Negative: &model3d.Cylinder{
+			P1:     p2.Sub(axis.Normalize().Scale(depth)),
+			P2:     p2,
+			Radius: holeRadius,
+		},
+	}
+}
+
+// hexPrism creates a hexagonal prism between p1 and p2
+// with the given distance across the flats.
+func hexPrism(p1, p2 model3d.Coord3D, width float64) model3d.Solid {
+	axis := p2.Sub(p1).Normalize()
+	b1, b2 := axis.OrthoBasis()
+	poly := model3d.ConvexPolytope{
+		&model3d.LinearConstraint{Normal: axis, Max: axis.Dot(p2)},
+		&model3d.LinearConstraint{Normal: axis.Scale(-1), Max: -axis.Dot(p1)},
+	}
+	for i := 0; i < 6; i++ {
+		theta := float64(i) * math.Pi / 3
+		normal := b1.Scale(math.Cos(theta)).Add(b2.Scale(math.Sin(theta)))
+		poly = append(poly, &model3d.LinearConstraint{
+			Normal: normal,
+			Max:    normal.Dot(p1) + width/2,
+		})
+	}
+	return poly.Solid()
+}
+
+type metricFastenerInfo struct {
+	Pitch               float64
+	NutWidth            float64
+	NutThickness        float64
+	HeadHeight          float64
+	CountersinkDiameter float64
+	ClearanceDiameter   float64
+	InsertDiameter      float64
+	InsertLength        float64
+}
+
+// metricFasteners contains coarse pitches (ISO 261), nut
+// dimensions (ISO 4032), hex head heights (ISO 4017),
+// countersunk heads (ISO 10642), medium clearance holes
+// (ISO 273), and common heat-set insert sizes.
+var metricFasteners = map[string]metricFastenerInfo{
+	"M2":   {0.4, 4, 1.6, 1.4, 4.4, 2.4, 3.2, 4},
+	"M2.5": {0.45, 5, 2, 1.7, 5.5, 2.9, 3.6, 5},
+	"M3":   {0.5, 5.5, 2.4, 2, 6.72, 3.4, 4, 5.7},
+	"M4":   {0.7, 7, 3.2, 2.8, 8.96, 4.5, 5.6, 8.1},
+	"M5":   {0.8, 8, 4.7, 3.5, 11.2, 5.5, 6.4, 9.5},
+	"M6":   {1, 10, 5.2, 4, 13.44, 6.6, 8, 12.7},
+	"M8":   {1.25, 13, 6.8, 5.3, 17.92, 9, 9.6, 12.7},
+	"M10":  {1.5, 16, 8.4, 6.4, 22.4, 11, 12, 12.7},
+	"M12":  {1.75, 18, 10.8, 7.5, 26.88, 13.5, 0, 0},
+	"M14":  {2, 21, 12.8, 8.8, 30.8, 15.5, 0, 0},
+	"M16":  {2, 24, 14.8, 10, 33.6, 17.5, 0, 0},
+	"M20":  {2.5, 30, 18, 12.5, 40.32, 22, 0, 0},
+}
+
+type unifiedFastenerInfo struct {
+	// Dimensions in inches.
+	Diameter     float64
+	CoarseTPI    float64
+	FineTPI      float64
+	NutWidth     float64
+	NutThickness float64
+}
+
+// unifiedFasteners contains unified thread sizes (ASME
+// B1.1) and hex nut dimensions (ASME B18.2.2, using
+// machine screw nuts for numbered sizes).
+//
+// A TPI of 0 indicates that a size has no thread in that
+// series.
+var unifiedFasteners = map[string]unifiedFastenerInfo{
+	"#0":    {0.060, 0, 80, 5.0 / 32, 3.0 / 64},
+	"#1":    {0.073, 64, 72, 5.0 / 32, 3.0 / 64},
+	"#2":    {0.086, 56, 64, 3.0 / 16, 1.0 / 16},
+	"#3":    {0.099, 48, 56, 3.0 / 16, 1.0 / 16},
+	"#4":    {0.112, 40, 48, 1.0 / 4, 3.0 / 32},
+	"#5":    {0.125, 40, 44, 5.0 / 16, 7.0 / 64},
+	"#6":    {0.138, 32, 40, 5.0 / 16, 7.0 / 64},
+	"#8":    {0.164, 32, 36, 11.0 / 32, 1.0 / 8},
+	"#10":   {0.190, 24, 32, 3.0 / 8, 1.0 / 8},
+	"#12":   {0.216, 24, 28, 7.0 / 16, 5.0 / 32},
+	"1/4":   {0.25, 20, 28, 7.0 / 16, 7.0 / 32},
+	"5/16":  {0.3125, 18, 24, 1.0 / 2, 17.0 / 64},
+	"3/8":   {0.375, 16, 24, 9.0 / 16, 21.0 / 64},
+	"7/16":  {0.4375, 14, 20, 11.0 / 16, 3.0 / 8},
+	"1/2":   {0.5, 13, 20, 3.0 / 4, 7.0 / 16},
+	"9/16":  {0.5625, 12, 18, 7.0 / 8, 31.0 / 64},
+	"5/8":   {0.625, 11, 18, 15.0 / 16, 35.0 / 64},
+	"3/4":   {0.75, 10, 16, 9.0 / 8, 41.0 / 64},
+	"7/8":   {0.875, 9, 14, 21.0 / 16, 3.0 / 4},
+	"1":     {1, 8, 12, 3.0 / 2, 55.0 / 64},
+	"1-1/8": {1.125, 7, 12, 27.0 / 16, 31.0 / 32},
+	"1-1/4": {1.25, 7, 12, 15.0 / 8, 17.0 / 16},
+	"1-3/8": {1.375, 6, 12, 33.0 / 16, 75.0 / 64},
+	"1-1/2": {1.5, 6, 12, 9.0 / 4, 41.0 / 32},
+	"1-3/4": {1.75, 5, 0, 21.0 / 8, 3.0 / 2},
+	"2":     {2, 4.5, 0, 3, 111.0 / 64},
+}
@@ -0,0 +1,115 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestParseThreadSpec(t *testing.T) {
+	for _, tc := range []struct {
+		Designation string
+		Name        string
+		Diameter    float64
+		Pitch       float64
+	}{
+		{"M6", "M6x1", 6, 1},
+		{"M6x0.75", "M6x0.75", 6, 0.75},
+		{"m3 x 0.5", "M3x0.5", 3, 0.5},
+		{"M2.5", "M2.5x0.45", 2.5, 0.45},
+		{"1/4-20", "1/4-20 UNC", 6.35, 25.4 / 20},
+		{"1/4 UNF", "1/4-28 UNF", 6.35, 25.4 / 28},
+		{"#10-32", "#10-32 UNF", 0.19 * 25.4, 25.4 / 32},
+		{"10-24 UNC", "#10-24 UNC", 0.19 * 25.4, 25.4 / 24},
+		{"3/8-18", "3/8-18", 0.375 * 25.4, 25.4 / 18},
+		{"#0", "#0-80 UNF", 0.06 * 25.4, 25.4 / 80},
+		{"1-64", "#1-64 UNC", 0.073 * 25.4, 25.4 / 64},
+		{"#3-56", "#3-56 UNF", 0.099 * 25.4, 25.4 / 56},
+		{"5-40", "#5-40 UNC", 0.125 * 25.4, 25.4 / 40},
+		{"1-8 UNC", "1-8 UNC", 25.4, 25.4 / 8},
+		{"1-12", "1-12 UNF", 25.4, 25.4 / 12},
+		{`1"`, "1-8 UNC", 25.4, 25.4 / 8},
+		{"1-1/4-7", "1-1/4-7 UNC", 1.25 * 25.4, 25.4 / 7},
+		{"1 1/2 UNF", "1-1/2-12 UNF", 1.5 * 25.4, 25.4 / 12},
+		{"2in", "2-4.5 UNC", 2 * 25.4, 25.4 / 4.5},
+	} {
+		spec, err := ParseThreadSpec(tc.Designation)
+		if err != nil {
+			t.Errorf("%s: %s", tc.Designation, err)
+			continue
+		}
+		if spec.Name != tc.Name || math.Abs(spec.MajorDiameter-tc.Diameter) > 1e-8 ||
+			math.Abs(spec.Pitch-tc.Pitch) > 1e-8 {
+			t.Errorf("%s: unexpected spec %+v", tc.Designation, spec)
+		}
+	}
+	for _, bad := range []string{"M", "M7", "Mx1", "1/3-20", "#13-24", "hello", "#0 UNC",
+		"1-3/4 UNF", "3-1/4"} {
+		if _, err := ParseThreadSpec(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestThreadSolidProfile(t *testing.T) {
+	spec := MustParseThreadSpec("M6")
+	solid := spec.External(model3d.Coord3D{}, model3d.Z(10), ExactTolerance)
+
+	// Cross-section in the XZ plane at positive X, where the
+	// crests are at integer multiples of the pitch.
+	for _, tc := range []struct {
+		Z        float64
+		Radius   float64
+		Expected bool
+	}{
+		{5, 2.99, true},
+		{5.05, 2.99, true},
+		{5.5, spec.MinorDiameter()/2 + 0.01, false},
+		{5.5, spec.MinorDiameter()/2 - 0.01, true},
+		{5.25, spec.PitchDiameter()/2 - 0.01, true},
+		{5.25, spec.PitchDiameter()/2 + 0.01, false},
+		{5, 3.01, false},
+	} {
+		actual := solid.Contains(model3d.XZ(tc.Radius, tc.Z))
+		if actual != tc.Expected {
+			t.Errorf("z=%f r=%f: expected %v but got %v", tc.Z, tc.Radius, tc.Expected, actual)
+		}
+	}
+}
+
+func TestFastenerSolids(t *testing.T) {
+	spec := MustParseFastenerSpec("M3")
+	if spec.NutWidth != 5.5 || spec.HeadWidth != 5.5 {
+		t.Errorf("unexpected nut width: %f", spec.NutWidth)
+	}
+	if inchSpec := MustParseFastenerSpec("1-8 UNC"); math.Abs(inchSpec.NutWidth-1.5*25.4) > 1e-8 {
+		t.Errorf("unexpected 1 inch nut width: %f", inchSpec.NutWidth)
+	}
+
+	nut := spec.HexNut(model3d.Coord3D{}, model3d.Z(spec.NutThickness), StandardTolerance)
+	mid := model3d.Z(spec.NutThickness / 2)
+	if nut.Contains(mid) {
+		t.Error("nut should have a hole")
+	}
+	if !nut.Contains(mid.Add(model3d.X(2.7))) || !nut.Contains(mid.Add(model3d.Y(2.7))) {
+		t.Error("nut should contain points near the flats")
+	}
+	if nut.Contains(mid.Add(model3d.X(3.3))) || nut.Contains(mid.Add(model3d.Y(3.3))) {
+		t.Error("nut should not contain points past the corners")
+	}
+
+	hole := spec.CountersunkHole(model3d.Z(5), model3d.Coord3D{}, StandardTolerance)
+	if !hole.Contains(model3d.XYZ(3.3, 0, 4.99)) || hole.Contains(model3d.XYZ(3.3, 0, 3)) {
+		t.Error("unexpected countersink shape")
+	}
+	if !hole.Contains(model3d.XYZ(1.75, 0, 0.5)) || hole.Contains(model3d.XYZ(2, 0, 0.5)) {
+		t.Error("unexpected clearance hole shape")
+	}
+
+	boss := spec.HeatSetBoss(model3d.Coord3D{}, model3d.Z(10), 2)
+	if boss.Contains(model3d.Z(9)) || !boss.Contains(model3d.Z(1)) ||
+		!boss.Contains(model3d.XYZ(3, 0, 9)) {
+		t.Error("unexpected heat-set boss shape")
+	}
+}
@@ -123,13 +123,21 @@ func InvoluteGearProfile(pressureAngle, module, clearance float64, numTeeth int)
 // InvoluteGearProfile.
 func InvoluteGearProfileSizes(pressureAngle, module, addendum, dedendum float64,
 	numTeeth int) GearProfile {
-	radius := module * float64(numTeeth) / 2
+	return newInvoluteGearProfile(pressureAngle, module, addendum, dedendum, float64(numTeeth))
+}
+
+// newInvoluteGearProfile is like InvoluteGearProfileSizes,
+// but allows a non-integer number of teeth, as is needed
+// for the virtual gears of bevel gears.
+func newInvoluteGearProfile(pressureAngle, module, addendum, dedendum float64,
+	numTeeth float64) *involuteGearProfile {
+	radius := module * numTeeth / 2
 	baseRadius := math.Cos(pressureAngle) * radius
 
 	tForR := math.Sqrt(math.Pow(radius/baseRadius, 2) - 1)
 	x, y := involuteCoords(tForR)
 
-	toothTheta := math.Pi * 2 / float64(numTeeth)
+	toothTheta := math.Pi * 2 / numTeeth
 	reflectTheta := toothTheta/2 + 2*math.Atan2(y, x)
 
 	return &involuteGearProfile{
@@ -146,6 +154,12 @@ func (i *involuteGearProfile) PitchRadius() float64 {
 	return i.pitchRadius
 }
 
+// toothCenter gets the angle of the center of the first
+// tooth.
+func (i *involuteGearProfile) toothCenter() float64 {
+	return i.reflectTheta / 2
+}
+
 func (i *involuteGearProfile) Min() model2d.Coord {
 	return model2d.Coord{X: -i.outerRadius, Y: -i.outerRadius}
 }
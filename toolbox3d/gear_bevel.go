@@ -0,0 +1,181 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// A BevelGear is a model3d.Solid implementing a straight
+// bevel gear, where the teeth taper towards the apex of a
+// pitch cone.
+//
+// Tooth shapes use Tredgold's approximation, which maps
+// points onto the involute profile of a virtual spur gear
+// on the back cone.
+type BevelGear struct {
+	// P1 is the apex of the pitch cone.
+	P1 model3d.Coord3D
+
+	// P2 is the center of the pitch circle at the outer
+	// (heel) end of the teeth.
+	P2 model3d.Coord3D
+
+	Spec GearSpec
+
+	// FaceWidth is the length of the teeth, measured along
+	// the pitch cone from the heel towards the apex.
+	FaceWidth float64
+
+	// Phase is a rotation of the teeth around the axis.
+	Phase float64
+
+	// Backlash is subtracted from the width of each tooth
+	// along the pitch circle at the heel.
+	Backlash float64
+}
+
+// PitchAngle gets the angle between the axis and the pitch
+// cone.
+func (b *BevelGear) PitchAngle() float64 {
+	return math.Atan2(b.Spec.PitchRadius(), b.P1.Dist(b.P2))
+}
+
+// ConeDistance gets the distance from the apex to the pitch
+// circle at the heel.
+func (b *BevelGear) ConeDistance() float64 {
+	return math.Hypot(b.Spec.PitchRadius(), b.P1.Dist(b.P2))
+}
+
+func (b *BevelGear) Min() model3d.Coord3D {
+	return b.boundingCylinder().Min()
+}
+
+func (b *BevelGear) Max() model3d.Coord3D {
+	return b.boundingCylinder().Max()
+}
+
+func (b *BevelGear) Contains(c model3d.Coord3D) bool {
+	if !model3d.InBounds(b, c) {
+		return false
+	}
+	b1, b2, axis := gearBasis(b.P1, b.P2)
+	offset := c.Sub(b.P1)
+	z := offset.Dot(axis)
+	x, y := offset.Dot(b1), offset.Dot(b2)
+
+	coneDist := b.ConeDistance()
+	dist := offset.Norm()
+	if dist > coneDist || dist < coneDist-b.FaceWidth || z <= 0 {
+		return false
+	}
+
+	// Scale the point to the sphere through the heel, and
+	// then unroll the back cone into a virtual spur gear.
+	pitchAngle := b.PitchAngle()
+	polar := math.Atan2(math.Hypot(x, y), z)
+	virtualPitch := coneDist * math.Tan(pitchAngle)
+	virtualRadius := virtualPitch + coneDist*(polar-pitchAngle)
+
+	toothTheta := 2 * math.Pi / float64(b.Spec.NumTeeth)
+	theta := math.Atan2(y, x) - b.Phase
+	theta -= math.Floor(theta/toothTheta) * toothTheta
+	virtualTheta := theta * math.Cos(pitchAngle)
+
+	profile := b.virtualProfile()
+	point := model2d.XY(math.Cos(virtualTheta), math.Sin(virtualTheta)).Scale(virtualRadius)
+	if b.Backlash == 0 {
+		return profile.Contains(point)
+	}
+	thinning := model2d.NewMatrix2Rotation(b.Backlash / 2 / virtualPitch)
+	return profile.Contains(thinning.MulColumn(point)) &&
+		profile.Contains(thinning.Transpose().MulColumn(point))
+}
+
+// toothCenter gets the angle of the center of the first
+// tooth, before Phase is applied.
+func (b *BevelGear) toothCenter() float64 {
+	return b.virtualProfile().toothCenter() / math.Cos(b.PitchAngle())
+}
+
+func (b *BevelGear) virtualProfile() *involuteGearProfile {
+	numTeeth := float64(b.Spec.NumTeeth) / math.Cos(b.PitchAngle())
+	return b.Spec.involuteProfile(numTeeth, b.Spec.Addendum(), b.Spec.Dedendum())
+}
+
+func (b *BevelGear) boundingCylinder() *model3d.Cylinder {
+	axis := b.P2.Sub(b.P1).Normalize()
+	coneDist := b.ConeDistance()
+	outerAngle := b.PitchAngle() + b.Spec.Addendum()/coneDist
+	return &model3d.Cylinder{
+		P1:     b.P1,
+		P2:     b.P1.Add(axis.Scale(coneDist)),
+		Radius: coneDist * math.Sin(math.Min(math.Pi/2, outerAngle)),
+	}
+}
+
+// azimuth gets the angle of a direction around the axis of
+// the gear.
+func (b *BevelGear) azimuth(dir model3d.Coord3D) float64 {
+	b1, b2, _ := gearBasis(b.P1, b.P2)
+	return math.Atan2(dir.Dot(b2), dir.Dot(b1))
+}
+
+// A BevelGearPair is a pair of meshing bevel gears with
+// perpendicular axes.
+//
+// The apexes of both gears are at the origin.
+// The first gear's axis is +Z, and the second gear's axis
+// is +X, so the teeth mesh along the line from the origin
+// in the direction of the pitch point on the XZ plane.
+type BevelGearPair struct {
+	Gear1 *BevelGear
+	Gear2 *BevelGear
+}
+
+// NewBevelGearPair creates a pair of meshing bevel gears.
+//
+// The gears must have the same module and pressure angle.
+func NewBevelGearPair(g1, g2 GearSpec, faceWidth, backlash float64) (*BevelGearPair, error) {
+	if err := checkGearsCompatible(&g1, &g2); err != nil {
+		return nil, err
+	}
+	r1, r2 := g1.PitchRadius(), g2.PitchRadius()
+	gear1 := &BevelGear{
+		P2:        model3d.Z(r2),
+		Spec:      g1,
+		FaceWidth: faceWidth,
+		Backlash:  backlash / 2,
+	}
+	gear2 := &BevelGear{
+		P2:        model3d.X(r1),
+		Spec:      g2,
+		FaceWidth: faceWidth,
+		Backlash:  backlash / 2,
+	}
+
+	// Center a tooth of gear 1 and a gap of gear 2 on the
+	// pitch point.
+	gear1.Phase = gear1.azimuth(model3d.X(1)) - gear1.toothCenter()
+	gear2.Phase = gear2.azimuth(model3d.Z(1)) - gear2.toothCenter() -
+		math.Pi/float64(g2.NumTeeth)
+	return &BevelGearPair{Gear1: gear1, Gear2: gear2}, nil
+}
+
+// Ratio gets the number of turns of the first gear per
+// turn of the second gear.
+func (b *BevelGearPair) Ratio() float64 {
+	return float64(b.Gear2.Spec.NumTeeth) / float64(b.Gear1.Spec.NumTeeth)
+}
+
+// gearBasis creates a right-handed orthonormal basis where
+// the third vector points from p1 to p2.
+func gearBasis(p1, p2 model3d.Coord3D) (b1, b2, axis model3d.Coord3D) {
+	axis = p2.Sub(p1).Normalize()
+	b1, b2 = axis.OrthoBasis()
+	if b1.Cross(b2).Dot(axis) < 0 {
+		b2, b1 = b1, b2
+	}
+	return
+}
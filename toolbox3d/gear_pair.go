@@ -0,0 +1,330 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// DefaultGearPressureAngle is the standard pressure angle
+// of 20 degrees.
+const DefaultGearPressureAngle = math.Pi / 9
+
+// A GearSpec describes a standard involute gear.
+type GearSpec struct {
+	Module   float64
+	NumTeeth int
+
+	// PressureAngle is the pressure angle in radians.
+	// If 0, DefaultGearPressureAngle is used.
+	PressureAngle float64
+
+	// Clearance is the gap between the tip of a mating
+	// tooth and the root of this gear.
+	// If 0, 0.25*Module is used.
+	Clearance float64
+}
+
+// PitchRadius gets the radius of the pitch circle.
+func (g *GearSpec) PitchRadius() float64 {
+	return g.Module * float64(g.NumTeeth) / 2
+}
+
+// Addendum gets the height of the teeth above the pitch
+// circle, which is always Module.
+func (g *GearSpec) Addendum() float64 {
+	return g.Module
+}
+
+// Dedendum gets the depth of the roots below the pitch
+// circle.
+func (g *GearSpec) Dedendum() float64 {
+	return g.Module + g.clearance()
+}
+
+// Profile creates the profile of an external gear.
+//
+// In the resulting profile, a tooth is centered at the
+// angle ToothCenter().
+func (g *GearSpec) Profile() GearProfile {
+	return g.involuteProfile(float64(g.NumTeeth), g.Addendum(), g.Dedendum())
+}
+
+// InternalProfile creates the profile of an internal (or
+// ring) gear, where the teeth point towards the center.
+//
+// The rimWidth specifies the thickness of the ring beyond
+// the roots of the teeth.
+//
+// In the resulting profile, a gap between two teeth is
+// centered at the angle ToothCenter().
+func (g *GearSpec) InternalProfile(rimWidth float64) GearProfile {
+	// The spaces of an internal gear have the same shape
+	// as the teeth of an external gear.
+	cut := g.involuteProfile(float64(g.NumTeeth), g.Dedendum(), g.Addendum())
+	return &internalGearProfile{
+		Cut:         cut,
+		OuterRadius: g.PitchRadius() + g.Dedendum() + rimWidth,
+	}
+}
+
+// ToothCenter gets the angle at which the center of the
+// first tooth in Profile() is located.
+func (g *GearSpec) ToothCenter() float64 {
+	return g.involuteProfile(float64(g.NumTeeth), g.Addendum(), g.Dedendum()).toothCenter()
+}
+
+func (g *GearSpec) pressureAngle() float64 {
+	if g.PressureAngle == 0 {
+		return DefaultGearPressureAngle
+	}
+	return g.PressureAngle
+}
+
+func (g *GearSpec) clearance() float64 {
+	if g.Clearance == 0 {
+		return 0.25 * g.Module
+	}
+	return g.Clearance
+}
+
+func (g *GearSpec) involuteProfile(numTeeth, addendum, dedendum float64) *involuteGearProfile {
+	return newInvoluteGearProfile(g.pressureAngle(), g.Module, addendum, dedendum, numTeeth)
+}
+
+// A GearPair describes two meshing gears in a plane.
+//
+// The first gear is centered at the origin, and the
+// second gear is centered at Gear2Center().
+// The profiles returned by Profile1() and Profile2() are
+// rotated so that the teeth mesh in this configuration.
+type GearPair struct {
+	Gear1 GearSpec
+	Gear2 GearSpec
+
+	// Internal is true if Gear2 is an internal gear which
+	// surrounds Gear1.
+	Internal bool
+
+	// RimWidth is the rim thickness for an internal gear.
+	// If 0, 2*Module is used.
+	RimWidth float64
+
+	// Backlash is the total gap between mating teeth along
+	// the pitch circles. It is split evenly between the
+	// two gears.
+	Backlash float64
+
+	// CenterDistance is the distance between the centers
+	// of the two gears.
+	CenterDistance float64
+
+	// Phase1 and Phase2 are the rotations applied to the
+	// profiles of the gears so that their teeth mesh.
+	Phase1 float64
+	Phase2 float64
+}
+
+// NewGearPair creates a pair of meshing external gears.
+//
+// The gears must have the same module and pressure angle.
+func NewGearPair(g1, g2 GearSpec, backlash float64) (*GearPair, error) {
+	if err := checkGearsCompatible(&g1, &g2); err != nil {
+		return nil, err
+	}
+	return &GearPair{
+		Gear1:          g1,
+		Gear2:          g2,
+		Backlash:       backlash,
+		CenterDistance: g1.PitchRadius() + g2.PitchRadius(),
+
+		// Center a tooth of gear 1 and a gap of gear 2 on
+		// the line between the centers.
+		Phase1: -g1.ToothCenter(),
+		Phase2: math.Pi - g2.ToothCenter() - math.Pi/float64(g2.NumTeeth),
+	}, nil
+}
+
+// NewGearPairRatio creates a pair of meshing external
+// gears, where the second gear has approximately ratio
+// times as many teeth as the first.
+func NewGearPairRatio(g1 GearSpec, ratio, backlash float64) (*GearPair, error) {
+	g2 := g1
+	g2.NumTeeth = int(math.Round(float64(g1.NumTeeth) * ratio))
+	if g2.NumTeeth < 1 {
+		return nil, errors.New("new gear pair: ratio results in no teeth")
+	}
+	return NewGearPair(g1, g2, backlash)
+}
+
+// NewInternalGearPair creates a pinion that meshes inside
+// of an internal ring gear.
+func NewInternalGearPair(pinion, ring GearSpec, backlash float64) (*GearPair, error) {
+	if err := checkGearsCompatible(&pinion, &ring); err != nil {
+		return nil, err
+	}
+	if ring.NumTeeth <= pinion.NumTeeth {
+		return nil, errors.New("new internal gear pair: ring must have more teeth than pinion")
+	}
+	return &GearPair{
+		Gear1:          pinion,
+		Gear2:          ring,
+		Internal:       true,
+		Backlash:       backlash,
+		CenterDistance: ring.PitchRadius() - pinion.PitchRadius(),
+
+		// The ring's center is on the opposite side of the
+		// pinion from the contact point, so a tooth of the
+		// pinion and a gap of the ring both face +X.
+		Phase1: -pinion.ToothCenter(),
+		Phase2: -ring.ToothCenter(),
+	}, nil
+}
+
+func checkGearsCompatible(g1, g2 *GearSpec) error {
+	if g1.NumTeeth < 1 || g2.NumTeeth < 1 {
+		return errors.New("gear pair: gears must have teeth")
+	}
+	if math.Abs(g1.Module-g2.Module) > 1e-8 {
+		return errors.New("gear pair: gears must have the same module")
+	}
+	if math.Abs(g1.pressureAngle()-g2.pressureAngle()) > 1e-8 {
+		return errors.New("gear pair: gears must have the same pressure angle")
+	}
+	return nil
+}
+
+// Ratio gets the number of turns of the first gear per
+// turn of the second gear.
+func (g *GearPair) Ratio() float64 {
+	return float64(g.Gear2.NumTeeth) / float64(g.Gear1.NumTeeth)
+}
+
+// Gear2Center gets the center of the second gear.
+func (g *GearPair) Gear2Center() model2d.Coord {
+	if g.Internal {
+		return model2d.X(-g.CenterDistance)
+	}
+	return model2d.X(g.CenterDistance)
+}
+
+// Gear2Angle gets the rotation of the second gear when the
+// first gear has been rotated by angle1.
+func (g *GearPair) Gear2Angle(angle1 float64) float64 {
+	if g.Internal {
+		return angle1 / g.Ratio()
+	}
+	return -angle1 / g.Ratio()
+}
+
+// Profile1 gets the rotated, backlash-adjusted profile of
+// the first gear.
+func (g *GearPair) Profile1() GearProfile {
+	return newTransformedGearProfile(g.Gear1.Profile(), g.Phase1,
+		g.Backlash/2/g.Gear1.PitchRadius())
+}
+
+// Profile2 gets the rotated, backlash-adjusted profile of
+// the second gear.
+func (g *GearPair) Profile2() GearProfile {
+	var profile GearProfile
+	if g.Internal {
+		rimWidth := g.RimWidth
+		if rimWidth == 0 {
+			rimWidth = 2 * g.Gear2.Module
+		}
+		profile = g.Gear2.InternalProfile(rimWidth)
+	} else {
+		profile = g.Gear2.Profile()
+	}
+	return newTransformedGearProfile(profile, g.Phase2, g.Backlash/2/g.Gear2.PitchRadius())
+}
+
+// SpurGears creates 3D spur gears for the pair.
+//
+// The first gear extends from p1 to p2, and the second
+// gear is offset from it perpendicular to the axis.
+func (g *GearPair) SpurGears(p1, p2 model3d.Coord3D) (model3d.Solid, model3d.Solid) {
+	axis := p2.Sub(p1)
+
+	// SpurGear uses the basis from axis.OrthoBasis() for
+	// profile coordinates, relative to the origin.
+	b1, b2 := axis.OrthoBasis()
+	center2 := g.Gear2Center()
+	offset2 := b1.Scale(center2.X).Add(b2.Scale(center2.Y))
+
+	gear1 := &SpurGear{P2: axis, Profile: g.Profile1()}
+	gear2 := &SpurGear{P2: axis, Profile: g.Profile2()}
+	return model3d.TranslateSolid(gear1, p1), model3d.TranslateSolid(gear2, p1.Add(offset2))
+}
+
+type internalGearProfile struct {
+	Cut         GearProfile
+	OuterRadius float64
+}
+
+func (i *internalGearProfile) PitchRadius() float64 {
+	return i.Cut.PitchRadius()
+}
+
+func (i *internalGearProfile) Min() model2d.Coord {
+	return model2d.XY(-i.OuterRadius, -i.OuterRadius)
+}
+
+func (i *internalGearProfile) Max() model2d.Coord {
+	return model2d.XY(i.OuterRadius, i.OuterRadius)
+}
+
+func (i *internalGearProfile) Contains(c model2d.Coord) bool {
+	return c.Norm() <= i.OuterRadius && !i.Cut.Contains(c)
+}
+
+// transformedGearProfile rotates a gear profile and thins
+// its teeth by a rotation angle to add backlash.
+type transformedGearProfile struct {
+	Profile     GearProfile
+	Rotation    *model2d.Matrix2
+	Thinning    *model2d.Matrix2
+	ThinningInv *model2d.Matrix2
+	Radius      float64
+}
+
+func newTransformedGearProfile(p GearProfile, rotation, thinning float64) GearProfile {
+	radius := math.Max(p.Min().Norm(), p.Max().Norm())
+	res := &transformedGearProfile{
+		Profile:  p,
+		Rotation: model2d.NewMatrix2Rotation(-rotation),
+		Radius:   radius,
+	}
+	if thinning != 0 {
+		res.Thinning = model2d.NewMatrix2Rotation(thinning / 2)
+		res.ThinningInv = model2d.NewMatrix2Rotation(-thinning / 2)
+	}
+	return res
+}
+
+func (t *transformedGearProfile) PitchRadius() float64 {
+	return t.Profile.PitchRadius()
+}
+
+func (t *transformedGearProfile) Min() model2d.Coord {
+	return model2d.XY(-t.Radius, -t.Radius)
+}
+
+func (t *transformedGearProfile) Max() model2d.Coord {
+	return model2d.XY(t.Radius, t.Radius)
+}
+
+func (t *transformedGearProfile) Contains(c model2d.Coord) bool {
+	if !model2d.InBounds(t, c) {
+		return false
+	}
+	c = t.Rotation.MulColumn(c)
+	if t.Thinning == nil {
+		return t.Profile.Contains(c)
+	}
+	return t.Profile.Contains(t.Thinning.MulColumn(c)) &&
+		t.Profile.Contains(t.ThinningInv.MulColumn(c))
+}
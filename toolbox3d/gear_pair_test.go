@@ -0,0 +1,156 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// countGearOverlap counts grid points near the contact
+// point of two profiles which are contained in both.
+func countGearOverlap(p1, p2 model2d.Solid, center model2d.Coord, size float64) int {
+	var count int
+	const n = 200
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			c := center.Add(model2d.XY(
+				(float64(i)/n-0.5)*size,
+				(float64(j)/n-0.5)*size,
+			))
+			if p1.Contains(c) && p2.Contains(c) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func rotatedGearProfile(p model2d.Solid, center model2d.Coord, angle float64) model2d.Solid {
+	rotation := model2d.NewMatrix2Rotation(-angle)
+	return model2d.CheckedFuncSolid(p.Min().Add(center), p.Max().Add(center),
+		func(c model2d.Coord) bool {
+			return p.Contains(rotation.MulColumn(c.Sub(center)))
+		})
+}
+
+func TestGearPairMesh(t *testing.T) {
+	g1 := GearSpec{Module: 1, NumTeeth: 13}
+	external, err := NewGearPairRatio(g1, 2, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internal, err := NewInternalGearPair(g1, GearSpec{Module: 1, NumTeeth: 40}, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, pair := range map[string]*GearPair{"external": external, "internal": internal} {
+		contact := model2d.X(g1.PitchRadius())
+		for _, angle := range []float64{0, 0.1, 0.2, 0.35} {
+			p1 := rotatedGearProfile(pair.Profile1(), model2d.Coord{}, angle)
+			p2 := rotatedGearProfile(pair.Profile2(), pair.Gear2Center(), pair.Gear2Angle(angle))
+			if n := countGearOverlap(p1, p2, contact, 8); n > 0 {
+				t.Errorf("%s: angle %f: %d overlapping points", name, angle, n)
+			}
+		}
+
+		// Rotating one gear by a quarter tooth should cause
+		// a collision.
+		p1 := rotatedGearProfile(pair.Profile1(), model2d.Coord{},
+			math.Pi/2/float64(g1.NumTeeth))
+		p2 := rotatedGearProfile(pair.Profile2(), pair.Gear2Center(), 0)
+		if n := countGearOverlap(p1, p2, contact, 8); n == 0 {
+			t.Errorf("%s: expected collision when out of phase", name)
+		}
+	}
+}
+
+func TestGearRackPairMesh(t *testing.T) {
+	pair := NewGearRackPair(GearSpec{Module: 1, NumTeeth: 15}, 9, 2, 0.1)
+	for _, angle := range []float64{0, 0.1, 0.2, 0.35} {
+		gear := rotatedGearProfile(pair.GearProfile(), pair.GearCenter(), angle)
+		shift := pair.RackShift(angle)
+		rack := model2d.CheckedFuncSolid(
+			pair.Rack.Min().Add(model2d.X(shift)),
+			pair.Rack.Max().Add(model2d.X(shift)),
+			func(c model2d.Coord) bool {
+				return pair.Rack.Contains(c.Sub(model2d.X(shift)))
+			},
+		)
+		if n := countGearOverlap(gear, rack, model2d.Coord{}, 8); n > 0 {
+			t.Errorf("angle %f: %d overlapping points", angle, n)
+		}
+	}
+	gear := rotatedGearProfile(pair.GearProfile(), pair.GearCenter(), math.Pi/2/15)
+	if n := countGearOverlap(gear, pair.Rack, model2d.Coord{}, 8); n == 0 {
+		t.Error("expected collision when out of phase")
+	}
+}
+
+func TestBevelGearPairMesh(t *testing.T) {
+	g1 := GearSpec{Module: 1, NumTeeth: 12}
+	g2 := GearSpec{Module: 1, NumTeeth: 20}
+	pair, err := NewBevelGearPair(g1, g2, 3, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	countOverlap := func() int {
+		var count int
+		const n = 40
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				for k := 0; k < n; k++ {
+					c := model3d.XYZ(
+						g1.PitchRadius()+(float64(i)/n-0.5)*4,
+						(float64(j)/n-0.5)*4,
+						g2.PitchRadius()+(float64(k)/n-0.5)*4,
+					)
+					if pair.Gear1.Contains(c) && pair.Gear2.Contains(c) {
+						count++
+					}
+				}
+			}
+		}
+		return count
+	}
+	if n := countOverlap(); n > 0 {
+		t.Errorf("%d overlapping points", n)
+	}
+	pair.Gear1.Phase += math.Pi / 2 / float64(g1.NumTeeth)
+	if n := countOverlap(); n == 0 {
+		t.Error("expected collision when out of phase")
+	}
+}
+
+func TestWormDriveMesh(t *testing.T) {
+	for _, starts := range []int{1, 2} {
+		drive := NewWormDrive(GearSpec{Module: 1, NumTeeth: 20}, starts, 4, 12, 4, 0.1)
+		countOverlap := func() int {
+			var count int
+			const n = 40
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					for k := 0; k < n; k++ {
+						c := model3d.XYZ(
+							drive.WheelSpec.PitchRadius()+(float64(i)/n-0.5)*4,
+							(float64(j)/n-0.5)*6,
+							(float64(k)/n-0.5)*4,
+						)
+						if drive.Worm.Contains(c) && drive.Wheel.Contains(c) {
+							count++
+						}
+					}
+				}
+			}
+			return count
+		}
+		if n := countOverlap(); n > 0 {
+			t.Errorf("starts %d: %d overlapping points", starts, n)
+		}
+		drive.Worm.Phase += math.Pi / 2
+		if n := countOverlap(); n == 0 {
+			t.Errorf("starts %d: expected collision when out of phase", starts)
+		}
+	}
+}
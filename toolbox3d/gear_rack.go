@@ -0,0 +1,135 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/model2d"
+)
+
+// A RackProfile is a 2D solid for a straight gear rack,
+// which meshes with involute gears of the same module.
+//
+// The pitch line is the X axis, and the teeth point in
+// the positive Y direction.
+type RackProfile struct {
+	Module   float64
+	NumTeeth int
+
+	// PressureAngle is the pressure angle in radians.
+	// If 0, DefaultGearPressureAngle is used.
+	PressureAngle float64
+
+	// Clearance is the gap between the tip of a mating
+	// tooth and the root of the rack.
+	// If 0, 0.25*Module is used.
+	Clearance float64
+
+	// BaseHeight is the thickness of the rack below the
+	// roots of the teeth.
+	BaseHeight float64
+
+	// Backlash is subtracted from the width of each tooth
+	// at the pitch line.
+	Backlash float64
+
+	// Offset is the X coordinate of the center of the
+	// first tooth. The teeth are spaced by pi*Module.
+	Offset float64
+}
+
+func (r *RackProfile) Min() model2d.Coord {
+	return model2d.XY(r.Offset-r.pitch()/2, -r.dedendum()-r.BaseHeight)
+}
+
+func (r *RackProfile) Max() model2d.Coord {
+	return model2d.XY(r.Offset+r.pitch()*(float64(r.NumTeeth)-0.5), r.Module)
+}
+
+func (r *RackProfile) Contains(c model2d.Coord) bool {
+	if !model2d.InBounds(r, c) {
+		return false
+	} else if c.Y <= -r.dedendum() {
+		return true
+	}
+	pitch := r.pitch()
+	x := c.X - r.Offset
+	x -= math.Round(x/pitch) * pitch
+	halfWidth := pitch/4 - r.Backlash/2 - c.Y*math.Tan(r.pressureAngle())
+	return math.Abs(x) <= halfWidth
+}
+
+func (r *RackProfile) pitch() float64 {
+	return math.Pi * r.Module
+}
+
+func (r *RackProfile) dedendum() float64 {
+	spec := GearSpec{Module: r.Module, Clearance: r.Clearance}
+	return spec.Dedendum()
+}
+
+func (r *RackProfile) pressureAngle() float64 {
+	spec := GearSpec{PressureAngle: r.PressureAngle}
+	return spec.pressureAngle()
+}
+
+// A GearRackPair describes a gear meshing with a rack.
+//
+// The rack's pitch line is the X axis, and the gear is
+// centered at GearCenter(), above the rack.
+type GearRackPair struct {
+	Gear GearSpec
+	Rack *RackProfile
+
+	// Backlash is the total gap between mating teeth along
+	// the pitch line. It is split evenly between the gear
+	// and the rack.
+	Backlash float64
+
+	// GearPhase is the rotation applied to the gear's
+	// profile so that it meshes with the rack.
+	GearPhase float64
+}
+
+// NewGearRackPair creates a rack with the given number of
+// teeth that meshes with a gear.
+//
+// The rack is centered horizontally below the gear.
+func NewGearRackPair(gear GearSpec, numRackTeeth int, baseHeight,
+	backlash float64) *GearRackPair {
+	pitch := math.Pi * gear.Module
+	return &GearRackPair{
+		Gear: gear,
+		Rack: &RackProfile{
+			Module:        gear.Module,
+			NumTeeth:      numRackTeeth,
+			PressureAngle: gear.PressureAngle,
+			Clearance:     gear.Clearance,
+			BaseHeight:    baseHeight,
+			Backlash:      backlash / 2,
+			Offset:        -float64((numRackTeeth-1)/2) * pitch,
+		},
+		Backlash: backlash,
+
+		// A rack tooth is centered at X=0, so the gear
+		// should have a gap pointing downward.
+		GearPhase: -math.Pi/2 - gear.ToothCenter() - math.Pi/float64(gear.NumTeeth),
+	}
+}
+
+// GearCenter gets the center of the gear.
+func (g *GearRackPair) GearCenter() model2d.Coord {
+	return model2d.Y(g.Gear.PitchRadius())
+}
+
+// GearProfile gets the rotated, backlash-adjusted profile
+// of the gear.
+func (g *GearRackPair) GearProfile() GearProfile {
+	return newTransformedGearProfile(g.Gear.Profile(), g.GearPhase,
+		g.Backlash/2/g.Gear.PitchRadius())
+}
+
+// RackShift gets the X offset of the rack when the gear has
+// been rotated by the given angle.
+func (g *GearRackPair) RackShift(gearAngle float64) float64 {
+	return gearAngle * g.Gear.PitchRadius()
+}
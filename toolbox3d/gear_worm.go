@@ -0,0 +1,188 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// A Worm is a model3d.Solid implementing the screw of a
+// worm drive.
+//
+// The thread has the straight-sided profile of a gear
+// rack in every plane through the axis.
+type Worm struct {
+	// P1 is the center of the start of the worm.
+	P1 model3d.Coord3D
+
+	// P2 is the center of the end of the worm.
+	P2 model3d.Coord3D
+
+	// Module is the axial module, such that the distance
+	// between adjacent threads is pi*Module.
+	Module float64
+
+	// PitchRadius is the radius of the pitch cylinder.
+	PitchRadius float64
+
+	// Starts is the number of separate threads.
+	// If 0, a single thread is used.
+	Starts int
+
+	// PressureAngle is the pressure angle in radians.
+	// If 0, DefaultGearPressureAngle is used.
+	PressureAngle float64
+
+	// Clearance is the gap between the tip of a mating
+	// tooth and the root of the worm.
+	// If 0, 0.25*Module is used.
+	Clearance float64
+
+	// Phase is a rotation of the thread around the axis.
+	Phase float64
+
+	// Backlash is subtracted from the axial width of the
+	// thread at the pitch cylinder.
+	Backlash float64
+}
+
+// Lead gets the distance that the thread advances along
+// the axis per turn.
+func (w *Worm) Lead() float64 {
+	return float64(w.starts()) * math.Pi * w.Module
+}
+
+// LeadAngle gets the angle between the thread and a plane
+// perpendicular to the axis, at the pitch cylinder.
+func (w *Worm) LeadAngle() float64 {
+	return math.Atan2(w.Lead(), 2*math.Pi*w.PitchRadius)
+}
+
+func (w *Worm) Min() model3d.Coord3D {
+	return w.boundingCylinder().Min()
+}
+
+func (w *Worm) Max() model3d.Coord3D {
+	return w.boundingCylinder().Max()
+}
+
+func (w *Worm) Contains(c model3d.Coord3D) bool {
+	b1, b2, axis := gearBasis(w.P1, w.P2)
+	offset := c.Sub(w.P1)
+	z := offset.Dot(axis)
+	if z < 0 || z > w.P1.Dist(w.P2) {
+		return false
+	}
+	x, y := offset.Dot(b1), offset.Dot(b2)
+	height := math.Hypot(x, y) - w.PitchRadius
+	if height > w.Module {
+		return false
+	}
+	rack := RackProfile{
+		Module:        w.Module,
+		PressureAngle: w.PressureAngle,
+		Clearance:     w.Clearance,
+	}
+	if height <= -rack.dedendum() {
+		return true
+	}
+
+	pitch := rack.pitch()
+	u := z - (math.Atan2(y, x)-w.Phase)*w.Lead()/(2*math.Pi)
+	u -= math.Round(u/pitch) * pitch
+	halfWidth := pitch/4 - w.Backlash/2 - height*math.Tan(rack.pressureAngle())
+	return math.Abs(u) <= halfWidth
+}
+
+func (w *Worm) starts() int {
+	if w.Starts == 0 {
+		return 1
+	}
+	return w.Starts
+}
+
+func (w *Worm) boundingCylinder() *model3d.Cylinder {
+	return &model3d.Cylinder{
+		P1:     w.P1,
+		P2:     w.P2,
+		Radius: w.PitchRadius + w.Module,
+	}
+}
+
+// A WormDrive is a worm meshing with a worm wheel, which is
+// a helical gear whose helix angle matches the worm's lead
+// angle.
+//
+// The wheel is centered at the origin with its axis along
+// +Z, and the worm's axis is parallel to the Y axis, offset
+// by CenterDistance along +X.
+type WormDrive struct {
+	Worm  *Worm
+	Wheel *HelicalGear
+
+	WheelSpec      GearSpec
+	CenterDistance float64
+}
+
+// NewWormDrive creates a worm drive from the spec of the
+// worm wheel, which also determines the module and
+// pressure angle of the worm.
+//
+// The worm has the given number of starts, pitch radius,
+// and length, while the wheel has the given thickness.
+// The backlash is split evenly between the worm and wheel.
+func NewWormDrive(wheel GearSpec, starts int, wormRadius, wormLength, wheelWidth,
+	backlash float64) *WormDrive {
+	centerDist := wormRadius + wheel.PitchRadius()
+	worm := &Worm{
+		P1:            model3d.XY(centerDist, -wormLength/2),
+		P2:            model3d.XY(centerDist, wormLength/2),
+		Module:        wheel.Module,
+		PitchRadius:   wormRadius,
+		Starts:        starts,
+		PressureAngle: wheel.PressureAngle,
+		Clearance:     wheel.Clearance,
+		Backlash:      backlash / 2,
+	}
+
+	// Center a thread of the worm on the pitch point, i.e.
+	// at the middle of the worm on the side facing -X.
+	azimuth := worm.azimuth(model3d.X(-1))
+	worm.Phase = azimuth - (wormLength/2)*2*math.Pi/worm.Lead()
+
+	// Center a gap of the wheel on the pitch point.
+	// HelicalGear uses axis.OrthoBasis() for its profile
+	// coordinates and twists the profile starting at P1.
+	helixAngle := worm.LeadAngle()
+	b1, b2 := model3d.Z(1).OrthoBasis()
+	twist := math.Tan(helixAngle) * (wheelWidth / 2) / wheel.PitchRadius()
+	wheelPhase := math.Atan2(b2.X, b1.X) + twist - wheel.ToothCenter() -
+		math.Pi/float64(wheel.NumTeeth)
+	profile := newTransformedGearProfile(wheel.Profile(), wheelPhase,
+		backlash/2/wheel.PitchRadius())
+
+	return &WormDrive{
+		Worm: worm,
+		Wheel: &HelicalGear{
+			P1:      model3d.Z(-wheelWidth / 2),
+			P2:      model3d.Z(wheelWidth / 2),
+			Profile: profile,
+			Angle:   helixAngle,
+		},
+		WheelSpec:      wheel,
+		CenterDistance: centerDist,
+	}
+}
+
+// Ratio gets the number of turns of the worm per turn of
+// the wheel.
+func (w *WormDrive) Ratio() float64 {
+	return float64(w.WheelSpec.NumTeeth) / float64(w.Worm.starts())
+}
+
+// azimuth gets the angle of a direction around the axis of
+// the worm, not including Phase.
+func (w *Worm) azimuth(dir model3d.Coord3D) float64 {
+	b1, b2, _ := gearBasis(w.P1, w.P2)
+	return math.Atan2(dir.Dot(b2), dir.Dot(b1))
+}
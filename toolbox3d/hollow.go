@@ -0,0 +1,254 @@
+package toolbox3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultHollowWallThickness   = 2.0
+	DefaultHollowDrainHoleRadius = 1.5
+)
+
+// A Hollower removes the interior of a shape, leaving a
+// shell of uniform thickness, as is commonly done to save
+// material when printing with resin.
+//
+// Lengths are in the units of the shape, and the defaults
+// assume millimeters.
+type Hollower struct {
+	// WallThickness is the thickness of the shell.
+	// If 0, DefaultHollowWallThickness is used.
+	WallThickness float64
+
+	// RibSpacing, if non-zero, adds internal ribs to
+	// stiffen the shell. The ribs are parallel to the print
+	// direction and are arranged in a grid with this
+	// spacing.
+	RibSpacing float64
+
+	// RibThickness is the thickness of each rib.
+	// If 0, WallThickness is used.
+	RibThickness float64
+
+	// RibDepth is the distance that ribs extend inward from
+	// the inside of the shell. Ribs do not span the whole
+	// cavity, so that they cannot trap uncured resin.
+	// If 0, twice the wall thickness is used.
+	RibDepth float64
+
+	// PrintDirection is the direction in which layers are
+	// stacked. Drain holes are placed at the lowest points
+	// of the cavity along this direction.
+	// If zero, +Z is used.
+	PrintDirection model3d.Coord3D
+
+	// NumDrainHoles is the maximum number of drain holes.
+	// If 0, no drain holes are added.
+	NumDrainHoles int
+
+	// DrainHoleRadius is the radius of each drain hole.
+	// If 0, DefaultHollowDrainHoleRadius is used.
+	DrainHoleRadius float64
+
+	// Delta is the resolution at which the cavity is
+	// sampled to find its lowest points.
+	// If 0, a fraction of the shape's size is used.
+	Delta float64
+}
+
+// A HollowResult is a hollowed shape.
+type HollowResult struct {
+	// SDF is the shell, including ribs and holes.
+	SDF model3d.SDF
+
+	// DrainHoles are the holes cut through the shell.
+	DrainHoles []*model3d.Cylinder
+}
+
+// Solid creates a solid for the shell.
+func (h *HollowResult) Solid() model3d.Solid {
+	return model3d.SDFToSolid(h.SDF, 0)
+}
+
+// Hollow creates a hollow shell from an SDF.
+//
+// Other solids can be hollowed with HollowSolid.
+func (h *Hollower) Hollow(shape model3d.SDF) *HollowResult {
+	wall := h.wallThickness()
+	cavity := model3d.InsetSDF(shape, wall)
+
+	var ribs model3d.SDF
+	if h.RibSpacing != 0 {
+		ribs = h.ribSDF(cavity)
+	}
+
+	result := &HollowResult{}
+	if h.NumDrainHoles > 0 {
+		result.DrainHoles = h.drainHoles(shape, cavity)
+	}
+
+	holes := make([]model3d.SDF, len(result.DrainHoles))
+	for i, hole := range result.DrainHoles {
+		holes[i] = hole
+	}
+	result.SDF = model3d.FuncSDF(shape.Min(), shape.Max(), func(c model3d.Coord3D) float64 {
+		outer := shape.SDF(c)
+		inner := cavity.SDF(c)
+		value := math.Min(outer, -inner)
+		if ribs != nil {
+			value = math.Max(value, math.Min(inner, ribs.SDF(c)))
+		}
+		for _, hole := range holes {
+			value = math.Min(value, -hole.SDF(c))
+		}
+		return value
+	})
+	return result
+}
+
+// HollowMesh creates a hollow shell from a closed mesh.
+func (h *Hollower) HollowMesh(mesh *model3d.Mesh) *HollowResult {
+	return h.Hollow(model3d.MeshToSDF(mesh))
+}
+
+// HollowSolid creates a hollow shell from an arbitrary
+// solid by converting it to a mesh with the given grid
+// resolution.
+//
+// The resolution should be small compared to the wall
+// thickness, since the shell follows the mesh rather than
+// the original solid.
+func (h *Hollower) HollowSolid(solid model3d.Solid, delta float64) *HollowResult {
+	return h.HollowMesh(model3d.MarchingCubesSearch(solid, delta, 8))
+}
+
+// ribSDF creates an SDF for the ribs within the cavity.
+func (h *Hollower) ribSDF(cavity model3d.SDF) model3d.SDF {
+	b1, b2 := h.printDirection().OrthoBasis()
+	thickness := h.RibThickness
+	if thickness == 0 {
+		thickness = h.wallThickness()
+	}
+	depth := h.RibDepth
+	if depth == 0 {
+		depth = 2 * h.wallThickness()
+	}
+	return model3d.FuncSDF(cavity.Min(), cavity.Max(), func(c model3d.Coord3D) float64 {
+		var planeDist float64 = math.Inf(1)
+		for _, axis := range []model3d.Coord3D{b1, b2} {
+			x := c.Dot(axis)
+			planeDist = math.Min(planeDist, math.Abs(x-math.Round(x/h.RibSpacing)*h.RibSpacing))
+		}
+		slab := thickness/2 - planeDist
+		return math.Min(slab, depth-cavity.SDF(c))
+	})
+}
+
+// drainHoles finds the lowest points of the cavity and
+// creates holes that go down through the shell.
+func (h *Hollower) drainHoles(shape, cavity model3d.SDF) []*model3d.Cylinder {
+	up := h.printDirection()
+	radius := h.drainHoleRadius()
+	min, max := cavity.Min(), cavity.Max()
+	delta := h.Delta
+	if delta == 0 {
+		delta = max.Sub(min).MaxCoord() / 64
+	}
+
+	// Find points in the cavity which have no cavity
+	// directly below them.
+	var candidates []model3d.Coord3D
+	for x := min.X + delta/2; x < max.X; x += delta {
+		for y := min.Y + delta/2; y < max.Y; y += delta {
+			for z := min.Z + delta/2; z < max.Z; z += delta {
+				c := model3d.XYZ(x, y, z)
+				if cavity.SDF(c) > 0 && cavity.SDF(c.Sub(up.Scale(delta))) <= 0 {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Dot(up) < candidates[j].Dot(up)
+	})
+
+	minSpacing := 4 * radius
+	var result []*model3d.Cylinder
+	for _, c := range candidates {
+		if len(result) == h.NumDrainHoles {
+			break
+		}
+		tooClose := false
+		for _, hole := range result {
+			if hole.P2.Dist(c) < minSpacing {
+				tooClose = true
+				break
+			}
+		}
+		if tooClose {
+			continue
+		}
+
+		exit, ok := marchToExit(shape, c, up.Scale(-1), delta/4)
+		if !ok {
+			continue
+		}
+		result = append(result, &model3d.Cylinder{
+			P1:     exit.Sub(up.Scale(delta)),
+			P2:     c,
+			Radius: radius,
+		})
+	}
+	return result
+}
+
+// marchToExit steps from c in a direction until leaving
+// the shape, or returns false if the shape's bounds are
+// left first.
+func marchToExit(shape model3d.SDF, c, direction model3d.Coord3D,
+	step float64) (model3d.Coord3D, bool) {
+	// Find the distance along the direction to the far
+	// side of the bounding box.
+	min, max := shape.Min(), shape.Max()
+	var extent float64
+	for i, d := range direction.Array() {
+		lo, hi := min.Array()[i], max.Array()[i]
+		extent += math.Max(d*lo, d*hi)
+	}
+	extent -= c.Dot(direction)
+	if !(step > 0) || math.IsNaN(extent) || math.IsInf(extent, 0) {
+		return c, false
+	}
+	numSteps := int(math.Ceil(math.Max(0, extent)/step)) + 1
+	for i := 0; i <= numSteps; i++ {
+		p := c.Add(direction.Scale(float64(i) * step))
+		if shape.SDF(p) <= 0 {
+			return p, true
+		}
+	}
+	return c, false
+}
+
+func (h *Hollower) wallThickness() float64 {
+	if h.WallThickness == 0 {
+		return DefaultHollowWallThickness
+	}
+	return h.WallThickness
+}
+
+func (h *Hollower) drainHoleRadius() float64 {
+	if h.DrainHoleRadius == 0 {
+		return DefaultHollowDrainHoleRadius
+	}
+	return h.DrainHoleRadius
+}
+
+func (h *Hollower) printDirection() model3d.Coord3D {
+	if h.PrintDirection.Norm() == 0 {
+		return model3d.Z(1)
+	}
+	return h.PrintDirection.Normalize()
+}
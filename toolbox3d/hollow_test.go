@@ -0,0 +1,101 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestHollower(t *testing.T) {
+	sphere := &model3d.Sphere{Radius: 10}
+	hollower := &Hollower{
+		WallThickness: 1,
+		RibSpacing:    5,
+		NumDrainHoles: 2,
+		Delta:         0.25,
+	}
+	result := hollower.Hollow(sphere)
+	solid := result.Solid()
+
+	if len(result.DrainHoles) != 2 {
+		t.Fatalf("expected 2 drain holes but got %d", len(result.DrainHoles))
+	}
+	for _, hole := range result.DrainHoles {
+		if hole.P2.Z > -7 || sphere.SDF(hole.P1) > 0 {
+			t.Errorf("unexpected drain hole from %v to %v", hole.P1, hole.P2)
+		}
+		if hole.P2.Norm() > 9 {
+			t.Errorf("drain hole %v should start in the cavity", hole.P2)
+		}
+		mid := hole.P1.Mid(hole.P2)
+		if d := sphere.SDF(mid); d > 0 && solid.Contains(mid) {
+			t.Errorf("drain hole should be empty at %v", mid)
+		}
+	}
+	if d := result.DrainHoles[0].P2.Dist(result.DrainHoles[1].P2); d < 4*1.5 {
+		t.Errorf("drain holes are too close: %f", d)
+	}
+
+	for _, c := range []model3d.Coord3D{
+		model3d.XYZ(9.5, 0.3, 0.2),
+		model3d.XYZ(0, -9.7, 1),
+		// Ribs.
+		model3d.XYZ(0.2, 8.5, 1),
+		model3d.XYZ(5.3, 2.5, 6.6),
+	} {
+		if !solid.Contains(c) {
+			t.Errorf("point %v should be inside", c)
+		}
+	}
+	for _, c := range []model3d.Coord3D{
+		model3d.XYZ(10.1, 0, 0),
+		model3d.XYZ(0, 0, 0),
+		model3d.XYZ(0, 3, 0),
+		model3d.XYZ(2.5, 8.5, 1),
+	} {
+		if solid.Contains(c) {
+			t.Errorf("point %v should be outside", c)
+		}
+	}
+
+	// Without ribs or holes, the volume of the shell
+	// should be the difference of two spheres.
+	plain := (&Hollower{WallThickness: 1}).Hollow(sphere)
+	mesh := model3d.MarchingCubesSearch(plain.Solid(), 0.1, 8)
+	expected := 4.0 / 3.0 * math.Pi * (1000 - 729)
+	if v := mesh.Volume(); math.Abs(v-expected)/expected > 0.02 {
+		t.Errorf("expected volume %f but got %f", expected, v)
+	}
+
+	// Solids without an SDF should be meshed first.
+	box := model3d.JoinedSolid{
+		&model3d.Rect{MaxVal: model3d.XYZ(10, 10, 10)},
+		&model3d.Rect{MinVal: model3d.XYZ(10, 0, 0), MaxVal: model3d.XYZ(20, 10, 10)},
+	}
+	boxShell := (&Hollower{WallThickness: 1}).HollowSolid(box, 0.1).Solid()
+	if !boxShell.Contains(model3d.XYZ(10, 5, 0.5)) || !boxShell.Contains(model3d.XYZ(19.5, 5, 5)) {
+		t.Error("shell should contain points near the surface")
+	}
+	if boxShell.Contains(model3d.XYZ(10, 5, 5)) || boxShell.Contains(model3d.XYZ(18, 5, 5)) {
+		t.Error("shell should be hollow")
+	}
+}
+
+func TestHollowerMarchToExit(t *testing.T) {
+	sphere := &model3d.Sphere{Radius: 10}
+	exit, ok := marchToExit(sphere, model3d.Z(-8), model3d.Z(-1), 0.1)
+	if !ok || exit.Z > -10 || exit.Z < -10.2 {
+		t.Errorf("unexpected exit: %v, %v", exit, ok)
+	}
+
+	// An SDF which is never negative should not be
+	// marched through forever.
+	open := model3d.FuncSDF(model3d.XYZ(-1, -1, -1), model3d.XYZ(1, 1, 1),
+		func(c model3d.Coord3D) float64 {
+			return 1
+		})
+	if _, ok := marchToExit(open, model3d.Coord3D{}, model3d.Z(-1), 0.1); ok {
+		t.Error("expected march to leave the bounds")
+	}
+}
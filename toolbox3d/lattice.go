@@ -0,0 +1,273 @@
+package toolbox3d
+
+import (
+	"math"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// TPMSKind is a type of triply periodic minimal surface.
+type TPMSKind int
+
+const (
+	GyroidTPMS TPMSKind = iota
+	SchwarzPTPMS
+	DiamondTPMS
+)
+
+// A TPMS is a thickened triply periodic minimal surface,
+// which is useful as an infill pattern.
+//
+// It implements both model3d.Solid and model3d.SDF.
+// The SDF is approximated from the implicit function
+// using its gradient, so it is most accurate near the
+// surface.
+type TPMS struct {
+	Kind TPMSKind
+
+	// MinVal and MaxVal bound the region to fill.
+	MinVal model3d.Coord3D
+	MaxVal model3d.Coord3D
+
+	// CellSize is the period of the surface along each
+	// axis.
+	CellSize float64
+
+	// Thickness is the thickness of the walls.
+	Thickness float64
+
+	// ThicknessFunc, if non-nil, overrides Thickness with
+	// a thickness that varies across space.
+	ThicknessFunc func(c model3d.Coord3D) float64
+}
+
+func (t *TPMS) Min() model3d.Coord3D {
+	return t.MinVal
+}
+
+func (t *TPMS) Max() model3d.Coord3D {
+	return t.MaxVal
+}
+
+func (t *TPMS) Contains(c model3d.Coord3D) bool {
+	return model3d.InBounds(t, c) && t.SDF(c) > 0
+}
+
+// SDF approximates the signed distance to the walls, which
+// is positive inside the walls.
+//
+// This does not account for the bounds.
+func (t *TPMS) SDF(c model3d.Coord3D) float64 {
+	scale := 2 * math.Pi / t.CellSize
+	value, grad := t.implicit(c.Scale(scale))
+	gradNorm := grad.Norm() * scale
+
+	// Avoid dividing by zero at singular points of the
+	// implicit function.
+	gradNorm = math.Max(gradNorm, 1e-3*scale)
+
+	thickness := t.Thickness
+	if t.ThicknessFunc != nil {
+		thickness = t.ThicknessFunc(c)
+	}
+	return thickness/2 - math.Abs(value)/gradNorm
+}
+
+// implicit evaluates the implicit function of the surface
+// and its gradient at a point with a period of 2*pi.
+func (t *TPMS) implicit(c model3d.Coord3D) (float64, model3d.Coord3D) {
+	sx, cx := math.Sincos(c.X)
+	sy, cy := math.Sincos(c.Y)
+	sz, cz := math.Sincos(c.Z)
+	switch t.Kind {
+	case GyroidTPMS:
+		value := sx*cy + sy*cz + sz*cx
+		grad := model3d.XYZ(
+			cx*cy-sz*sx,
+			-sx*sy+cy*cz,
+			-sy*sz+cz*cx,
+		)
+		return value, grad
+	case SchwarzPTPMS:
+		return cx + cy + cz, model3d.XYZ(-sx, -sy, -sz)
+	case DiamondTPMS:
+		value := sx*sy*sz + sx*cy*cz + cx*sy*cz + cx*cy*sz
+		grad := model3d.XYZ(
+			cx*sy*sz+cx*cy*cz-sx*sy*cz-sx*cy*sz,
+			sx*cy*sz-sx*sy*cz+cx*cy*cz-cx*sy*sz,
+			sx*sy*cz-sx*cy*sz-cx*sy*sz+cx*cy*cz,
+		)
+		return value, grad
+	}
+	panic("unknown TPMS kind")
+}
+
+// StrutLatticeKind is a type of strut lattice.
+type StrutLatticeKind int
+
+const (
+	// CubicLattice has struts along the edges of each
+	// cubic cell.
+	CubicLattice StrutLatticeKind = iota
+
+	// OctetLattice is an octet truss, with struts along
+	// the face diagonals of each cell and between the
+	// centers of adjacent faces.
+	OctetLattice
+
+	// BCCLattice is a body-centered cubic lattice, with
+	// struts along the diagonals of each cell.
+	BCCLattice
+)
+
+// A StrutLattice is a periodic lattice of cylindrical
+// struts, which is useful as an infill pattern.
+//
+// It implements both model3d.Solid and model3d.SDF.
+type StrutLattice struct {
+	Kind StrutLatticeKind
+
+	// MinVal and MaxVal bound the region to fill.
+	MinVal model3d.Coord3D
+	MaxVal model3d.Coord3D
+
+	// CellSize is the side length of each cubic cell.
+	// Cells are aligned so that the origin is a corner.
+	CellSize float64
+
+	// Radius is the radius of the struts.
+	Radius float64
+
+	// RadiusFunc, if non-nil, overrides Radius with a
+	// radius that varies across space.
+	RadiusFunc func(c model3d.Coord3D) float64
+}
+
+func (s *StrutLattice) Min() model3d.Coord3D {
+	return s.MinVal
+}
+
+func (s *StrutLattice) Max() model3d.Coord3D {
+	return s.MaxVal
+}
+
+func (s *StrutLattice) Contains(c model3d.Coord3D) bool {
+	return model3d.InBounds(s, c) && s.SDF(c) > 0
+}
+
+// SDF computes the signed distance to the surface of the
+// struts, which is positive inside the struts.
+//
+// This does not account for the bounds.
+func (s *StrutLattice) SDF(c model3d.Coord3D) float64 {
+	radius := s.Radius
+	if s.RadiusFunc != nil {
+		radius = s.RadiusFunc(c)
+	}
+
+	// Find the nearest lattice corner, and check the
+	// struts in the eight cells that share it.
+	scaled := c.Scale(1 / s.CellSize)
+	corner := model3d.XYZ(math.Round(scaled.X), math.Round(scaled.Y), math.Round(scaled.Z))
+	local := scaled.Sub(corner)
+
+	minDist := math.Inf(1)
+	for i := 0; i < 8; i++ {
+		offset := model3d.XYZ(float64(i&1), float64((i>>1)&1), float64((i>>2)&1))
+		cellPoint := local.Add(offset)
+		for _, seg := range latticeStruts[s.Kind] {
+			minDist = math.Min(minDist, seg.Dist(cellPoint))
+		}
+	}
+	return radius - minDist*s.CellSize
+}
+
+// latticeStruts stores the struts of each kind of lattice
+// within a unit cell.
+var latticeStruts = map[StrutLatticeKind][]model3d.Segment{
+	CubicLattice: cubeEdges(),
+	OctetLattice: octetStruts(),
+	BCCLattice: {
+		model3d.NewSegment(model3d.XYZ(0, 0, 0), model3d.XYZ(1, 1, 1)),
+		model3d.NewSegment(model3d.XYZ(1, 0, 0), model3d.XYZ(0, 1, 1)),
+		model3d.NewSegment(model3d.XYZ(0, 1, 0), model3d.XYZ(1, 0, 1)),
+		model3d.NewSegment(model3d.XYZ(0, 0, 1), model3d.XYZ(1, 1, 0)),
+	},
+}
+
+func cubeEdges() []model3d.Segment {
+	var result []model3d.Segment
+	for axis := 0; axis < 3; axis++ {
+		for i := 0; i < 4; i++ {
+			var start [3]float64
+			start[(axis+1)%3] = float64(i & 1)
+			start[(axis+2)%3] = float64(i >> 1)
+			end := start
+			end[axis] = 1
+			result = append(result, model3d.NewSegment(
+				model3d.NewCoord3DArray(start),
+				model3d.NewCoord3DArray(end),
+			))
+		}
+	}
+	return result
+}
+
+func octetStruts() []model3d.Segment {
+	var result []model3d.Segment
+	var faceCenters []model3d.Coord3D
+	for axis := 0; axis < 3; axis++ {
+		for side := 0; side < 2; side++ {
+			var center [3]float64
+			center[axis] = float64(side)
+			center[(axis+1)%3] = 0.5
+			center[(axis+2)%3] = 0.5
+			faceCenters = append(faceCenters, model3d.NewCoord3DArray(center))
+
+			// Both diagonals of the face.
+			var c1, c2, c3, c4 [3]float64
+			c1[axis], c2[axis], c3[axis], c4[axis] = center[axis], center[axis],
+				center[axis], center[axis]
+			c2[(axis+1)%3], c2[(axis+2)%3] = 1, 1
+			c3[(axis+1)%3] = 1
+			c4[(axis+2)%3] = 1
+			result = append(result,
+				model3d.NewSegment(model3d.NewCoord3DArray(c1), model3d.NewCoord3DArray(c2)),
+				model3d.NewSegment(model3d.NewCoord3DArray(c3), model3d.NewCoord3DArray(c4)),
+			)
+		}
+	}
+
+	// Connect the centers of adjacent faces, forming an
+	// octahedron.
+	for i, c1 := range faceCenters {
+		for _, c2 := range faceCenters[i+1:] {
+			if math.Abs(c1.Dist(c2)-math.Sqrt2/2) < 1e-8 {
+				result = append(result, model3d.NewSegment(c1, c2))
+			}
+		}
+	}
+	return result
+}
+
+// InfillSDF creates an SDF for a shape whose interior is
+// replaced by an infill pattern, keeping a solid skin of
+// the given thickness.
+//
+// The infill is typically a *TPMS or *StrutLattice whose
+// bounds contain the shape.
+func InfillSDF(shape, infill model3d.SDF, skinThickness float64) model3d.SDF {
+	interior := model3d.InsetSDF(shape, skinThickness)
+	return model3d.FuncSDF(shape.Min(), shape.Max(), func(c model3d.Coord3D) float64 {
+		outer := shape.SDF(c)
+		inner := interior.SDF(c)
+		skin := math.Min(outer, -inner)
+		filling := math.Min(inner, infill.SDF(c))
+		return math.Max(skin, filling)
+	})
+}
+
+// InfillSolid is like InfillSDF, but creates a solid.
+func InfillSolid(shape, infill model3d.SDF, skinThickness float64) model3d.Solid {
+	return model3d.SDFToSolid(InfillSDF(shape, infill, skinThickness), 0)
+}
@@ -0,0 +1,163 @@
+package toolbox3d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestTPMS(t *testing.T) {
+	for _, kind := range []TPMSKind{GyroidTPMS, SchwarzPTPMS, DiamondTPMS} {
+		tpms := &TPMS{
+			Kind:      kind,
+			MinVal:    model3d.XYZ(-10, -10, -10),
+			MaxVal:    model3d.XYZ(10, 10, 10),
+			CellSize:  5,
+			Thickness: 0.5,
+		}
+
+		// Check the gradient numerically.
+		for i := 0; i < 100; i++ {
+			c := model3d.NewCoord3DRandNorm()
+			value, grad := tpms.implicit(c)
+			for axis := 0; axis < 3; axis++ {
+				var delta [3]float64
+				delta[axis] = 1e-5
+				value1, _ := tpms.implicit(c.Add(model3d.NewCoord3DArray(delta)))
+				expected := (value1 - value) / 1e-5
+				if actual := grad.Array()[axis]; math.Abs(actual-expected) > 1e-3 {
+					t.Errorf("kind %d: expected gradient %f but got %f", kind, expected, actual)
+				}
+			}
+		}
+
+		// The SDF should be periodic.
+		for i := 0; i < 100; i++ {
+			c := model3d.NewCoord3DRandBounds(tpms.MinVal, tpms.MaxVal)
+			if math.Abs(tpms.SDF(c)-tpms.SDF(c.Add(model3d.Y(5)))) > 1e-8 {
+				t.Errorf("kind %d: SDF is not periodic", kind)
+			}
+		}
+
+		// The volume should be roughly proportional to the
+		// thickness.
+		fraction1 := latticeFillFraction(tpms)
+		tpms.Thickness = 1
+		fraction2 := latticeFillFraction(tpms)
+		if ratio := fraction2 / fraction1; ratio < 1.6 || ratio > 2.4 {
+			t.Errorf("kind %d: unexpected ratio of volumes: %f", kind, ratio)
+		}
+
+		// A graded thickness of zero should be empty.
+		tpms.ThicknessFunc = func(c model3d.Coord3D) float64 {
+			return math.Max(0, c.X)
+		}
+		for i := 0; i < 1000; i++ {
+			c := model3d.NewCoord3DRandBounds(tpms.MinVal, tpms.MaxVal)
+			if c.X < 0 && tpms.Contains(c) {
+				t.Fatalf("kind %d: point %v should not be filled", kind, c)
+			}
+		}
+	}
+}
+
+func TestStrutLattice(t *testing.T) {
+	cubic := &StrutLattice{
+		Kind:     CubicLattice,
+		MinVal:   model3d.XYZ(-10, -10, -10),
+		MaxVal:   model3d.XYZ(10, 10, 10),
+		CellSize: 2,
+		Radius:   0.25,
+	}
+	if sdf := cubic.SDF(model3d.XYZ(2, 4, 1)); math.Abs(sdf-0.25) > 1e-8 {
+		t.Errorf("unexpected SDF on strut: %f", sdf)
+	}
+	if sdf := cubic.SDF(model3d.XYZ(3, 3, 3)); math.Abs(sdf-(0.25-math.Sqrt2)) > 1e-8 {
+		t.Errorf("unexpected SDF at cell center: %f", sdf)
+	}
+
+	bcc := *cubic
+	bcc.Kind = BCCLattice
+	if sdf := bcc.SDF(model3d.XYZ(3, 3, 3)); math.Abs(sdf-0.25) > 1e-8 {
+		t.Errorf("unexpected BCC SDF at cell center: %f", sdf)
+	}
+	if sdf := bcc.SDF(model3d.XYZ(-4, 2, 0.5)); math.Abs(sdf-(0.25-math.Sqrt(2.0/3)/2)) > 1e-8 {
+		t.Errorf("unexpected BCC SDF on edge: %f", sdf)
+	}
+
+	octet := *cubic
+	octet.Kind = OctetLattice
+	if len(latticeStruts[OctetLattice]) != 24 {
+		t.Errorf("unexpected number of octet struts: %d", len(latticeStruts[OctetLattice]))
+	}
+	if sdf := octet.SDF(model3d.XYZ(3, 3, 2)); math.Abs(sdf-0.25) > 1e-8 {
+		t.Errorf("unexpected octet SDF at face center: %f", sdf)
+	}
+
+	// Compare the SDF to brute force over many cells.
+	for _, lattice := range []*StrutLattice{cubic, &bcc, &octet} {
+		for i := 0; i < 100; i++ {
+			c := model3d.NewCoord3DRandBounds(lattice.MinVal, lattice.MaxVal)
+			expected := math.Inf(1)
+			for x := -6.0; x <= 5; x++ {
+				for y := -6.0; y <= 5; y++ {
+					for z := -6.0; z <= 5; z++ {
+						local := c.Scale(1 / lattice.CellSize).Sub(model3d.XYZ(x, y, z))
+						for _, seg := range latticeStruts[lattice.Kind] {
+							expected = math.Min(expected, seg.Dist(local))
+						}
+					}
+				}
+			}
+			expected = lattice.Radius - expected*lattice.CellSize
+			if actual := lattice.SDF(c); math.Abs(actual-expected) > 1e-8 {
+				t.Errorf("kind %d: expected SDF %f but got %f", lattice.Kind, expected, actual)
+			}
+		}
+	}
+}
+
+func TestInfillSolid(t *testing.T) {
+	sphere := &model3d.Sphere{Radius: 10}
+	lattice := &StrutLattice{
+		MinVal:   sphere.Min(),
+		MaxVal:   sphere.Max(),
+		CellSize: 5,
+		Radius:   0.5,
+	}
+	solid := InfillSolid(sphere, lattice, 1)
+	for _, c := range []model3d.Coord3D{
+		model3d.XYZ(9.5, 0, 0),
+		model3d.XYZ(0, -9.1, 0),
+		model3d.XYZ(0, 0, 0),
+		model3d.XYZ(5, 0.2, 0),
+	} {
+		if !solid.Contains(c) {
+			t.Errorf("point %v should be inside", c)
+		}
+	}
+	for _, c := range []model3d.Coord3D{
+		model3d.XYZ(10.1, 0, 0),
+		model3d.XYZ(2.5, 2.5, 2.5),
+		model3d.XYZ(2.5, 0, 2.5),
+	} {
+		if solid.Contains(c) {
+			t.Errorf("point %v should be outside", c)
+		}
+	}
+}
+
+func latticeFillFraction(s model3d.Solid) float64 {
+	rng := rand.New(rand.NewSource(0))
+	var count int
+	for i := 0; i < 20000; i++ {
+		c := model3d.XYZ(rng.Float64(), rng.Float64(), rng.Float64())
+		c = s.Min().Add(c.Mul(s.Max().Sub(s.Min())))
+		if s.Contains(c) {
+			count++
+		}
+	}
+	return float64(count) / 20000
+}
@@ -0,0 +1,268 @@
+package toolbox3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model2d"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultOrientationNumDirections     = 256
+	DefaultOrientationNumFaceDirections = 32
+)
+
+// An OrientationOptimizer searches for rotations of a mesh
+// which make it easier to print on an FDM printer.
+//
+// Each candidate orientation is scored by a weighted sum
+// of metrics, where lower scores are better.
+// All metrics are normalized by the size of the mesh, so
+// the weights do not depend on the mesh's scale.
+type OrientationOptimizer struct {
+	// NumDirections is the number of evenly spaced build
+	// directions to try.
+	// If 0, DefaultOrientationNumDirections is used.
+	NumDirections int
+
+	// NumFaceDirections is the number of additional build
+	// directions to try which place the largest flat
+	// regions of the mesh on the build plate.
+	// If 0, DefaultOrientationNumFaceDirections is used.
+	// If negative, no such directions are tried.
+	NumFaceDirections int
+
+	// OverhangAngle is the maximum angle from vertical of a
+	// downward-facing surface that does not need support.
+	// If 0, DefaultPrintabilityOverhangAngle is used.
+	OverhangAngle float64
+
+	// BaseEpsilon is the maximum distance from the build
+	// plate of a point which is counted as touching it.
+	// If 0, a small fraction of the mesh size is used.
+	BaseEpsilon float64
+
+	// Weights for each of the metrics.
+	// If all weights are 0, they are all set to 1.
+	OverhangWeight float64
+	SupportWeight  float64
+	HeightWeight   float64
+	BaseWeight     float64
+}
+
+// An OrientationCandidate is a scored orientation.
+type OrientationCandidate struct {
+	// Rotation maps the mesh into the candidate
+	// orientation, where the build direction is +Z.
+	Rotation *model3d.Matrix3
+
+	// Score is the weighted sum of the metrics, where
+	// lower is better.
+	Score float64
+
+	// OverhangArea is the fraction of the surface area
+	// which needs support.
+	OverhangArea float64
+
+	// SupportVolume is the approximate volume of support
+	// material below the overhangs, divided by the surface
+	// area and the diameter of the mesh.
+	SupportVolume float64
+
+	// Height is the height of the print divided by the
+	// diameter of the mesh.
+	Height float64
+
+	// BaseArea is the area of the convex hull of the
+	// points touching the build plate, divided by the
+	// surface area of the mesh.
+	BaseArea float64
+}
+
+// Optimize scores candidate orientations of a mesh and
+// returns them sorted from best to worst.
+//
+// The best rotation can be applied with
+//
+//	mesh.Transform(&model3d.Matrix3Transform{Matrix: candidates[0].Rotation})
+func (o *OrientationOptimizer) Optimize(mesh *model3d.Mesh) []*OrientationCandidate {
+	if mesh.NumTriangles() == 0 {
+		return nil
+	}
+	directions := o.directions(mesh)
+	results := make([]*OrientationCandidate, len(directions))
+	essentials.ConcurrentMap(0, len(directions), func(i int) {
+		results[i] = o.evaluate(mesh, directions[i])
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score < results[j].Score
+	})
+	return results
+}
+
+// Evaluate scores a single rotation of the mesh.
+func (o *OrientationOptimizer) Evaluate(mesh *model3d.Mesh,
+	rotation *model3d.Matrix3) *OrientationCandidate {
+	up := rotation.Transpose().MulColumn(model3d.Z(1))
+	result := o.evaluate(mesh, up)
+	result.Rotation = rotation
+	return result
+}
+
+// evaluate scores the build direction up, in the original
+// coordinates of the mesh.
+func (o *OrientationOptimizer) evaluate(mesh *model3d.Mesh,
+	up model3d.Coord3D) *OrientationCandidate {
+	diameter := mesh.Max().Dist(mesh.Min())
+	minHeight, maxHeight := math.Inf(1), math.Inf(-1)
+	mesh.IterateVertices(func(c model3d.Coord3D) {
+		h := c.Dot(up)
+		minHeight = math.Min(minHeight, h)
+		maxHeight = math.Max(maxHeight, h)
+	})
+
+	minSine := math.Sin(o.overhangAngle())
+	var totalArea, overhangArea, supportVolume float64
+	mesh.Iterate(func(t *model3d.Triangle) {
+		area := t.Area()
+		totalArea += area
+		dot := t.Normal().Dot(up)
+		if -dot <= minSine {
+			return
+		}
+		center := t[0].Add(t[1]).Add(t[2]).Scale(1.0 / 3)
+		height := center.Dot(up) - minHeight
+		if height < o.baseEpsilon(diameter) {
+			return
+		}
+		overhangArea += area
+		supportVolume += area * -dot * height
+	})
+
+	b1, b2 := up.OrthoBasis()
+	var basePoints []model2d.Coord
+	eps := o.baseEpsilon(diameter)
+	mesh.IterateVertices(func(c model3d.Coord3D) {
+		if c.Dot(up)-minHeight < eps {
+			basePoints = append(basePoints, model2d.XY(c.Dot(b1), c.Dot(b2)))
+		}
+	})
+	var baseArea float64
+	if len(basePoints) >= 3 {
+		baseArea = model2d.ConvexHullMesh(basePoints).Area()
+	}
+
+	result := &OrientationCandidate{
+		Rotation:      rotationToZ(up),
+		OverhangArea:  overhangArea / totalArea,
+		SupportVolume: supportVolume / (totalArea * diameter),
+		Height:        (maxHeight - minHeight) / diameter,
+		BaseArea:      baseArea / totalArea,
+	}
+	wO, wS, wH, wB := o.weights()
+	result.Score = wO*result.OverhangArea + wS*result.SupportVolume + wH*result.Height -
+		wB*result.BaseArea
+	return result
+}
+
+// directions gets the candidate build directions.
+func (o *OrientationOptimizer) directions(mesh *model3d.Mesh) []model3d.Coord3D {
+	numDirs := o.NumDirections
+	if numDirs == 0 {
+		numDirs = DefaultOrientationNumDirections
+	}
+	var result []model3d.Coord3D
+
+	// Evenly space directions on a Fibonacci sphere.
+	goldenAngle := math.Pi * (3 - math.Sqrt(5))
+	for i := 0; i < numDirs; i++ {
+		z := 1 - 2*(float64(i)+0.5)/float64(numDirs)
+		r := math.Sqrt(1 - z*z)
+		theta := goldenAngle * float64(i)
+		result = append(result, model3d.XYZ(r*math.Cos(theta), r*math.Sin(theta), z))
+	}
+
+	numFaces := o.NumFaceDirections
+	if numFaces == 0 {
+		numFaces = DefaultOrientationNumFaceDirections
+	}
+	if numFaces > 0 {
+		// Group faces by their normals, and try placing the
+		// largest groups on the build plate.
+		areas := map[[3]int64]float64{}
+		normals := map[[3]int64]model3d.Coord3D{}
+		mesh.Iterate(func(t *model3d.Triangle) {
+			n := t.Normal()
+			key := [3]int64{
+				int64(math.Round(n.X * 100)),
+				int64(math.Round(n.Y * 100)),
+				int64(math.Round(n.Z * 100)),
+			}
+			area := t.Area()
+			areas[key] += area
+			normals[key] = normals[key].Add(n.Scale(area))
+		})
+		keys := make([][3]int64, 0, len(areas))
+		for key := range areas {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if areas[keys[i]] != areas[keys[j]] {
+				return areas[keys[i]] > areas[keys[j]]
+			}
+			// Break ties deterministically.
+			for k := 0; k < 3; k++ {
+				if keys[i][k] != keys[j][k] {
+					return keys[i][k] < keys[j][k]
+				}
+			}
+			return false
+		})
+		for i := 0; i < len(keys) && i < numFaces; i++ {
+			if n := normals[keys[i]]; n.Norm() > 0 {
+				result = append(result, n.Normalize().Scale(-1))
+			}
+		}
+	}
+	return result
+}
+
+func (o *OrientationOptimizer) overhangAngle() float64 {
+	if o.OverhangAngle == 0 {
+		return DefaultPrintabilityOverhangAngle
+	}
+	return o.OverhangAngle
+}
+
+func (o *OrientationOptimizer) baseEpsilon(diameter float64) float64 {
+	if o.BaseEpsilon == 0 {
+		return diameter * 1e-3
+	}
+	return o.BaseEpsilon
+}
+
+func (o *OrientationOptimizer) weights() (overhang, support, height, base float64) {
+	if o.OverhangWeight == 0 && o.SupportWeight == 0 && o.HeightWeight == 0 &&
+		o.BaseWeight == 0 {
+		return 1, 1, 1, 1
+	}
+	return o.OverhangWeight, o.SupportWeight, o.HeightWeight, o.BaseWeight
+}
+
+// rotationToZ creates the smallest rotation that maps the
+// unit vector up to +Z.
+func rotationToZ(up model3d.Coord3D) *model3d.Matrix3 {
+	z := model3d.Z(1)
+	axis := up.Cross(z)
+	sine := axis.Norm()
+	cosine := up.Dot(z)
+	if sine < 1e-8 {
+		if cosine > 0 {
+			return model3d.NewMatrix3Identity()
+		}
+		return model3d.NewMatrix3Rotation(model3d.X(1), math.Pi)
+	}
+	return model3d.NewMatrix3Rotation(axis.Scale(1/sine), math.Atan2(sine, cosine))
+}
@@ -0,0 +1,60 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestOrientationOptimizerFlatPlate(t *testing.T) {
+	// A thin plate standing on its edge, tilted slightly.
+	mesh := model3d.NewMeshRect(model3d.XYZ(0, 0, 0), model3d.XYZ(2, 10, 10))
+	mesh = mesh.Rotate(model3d.XYZ(1, 1, 1).Normalize(), 0.3)
+
+	candidates := (&OrientationOptimizer{}).Optimize(mesh)
+	if len(candidates) == 0 {
+		t.Fatal("no candidates")
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Score < candidates[i-1].Score {
+			t.Fatal("candidates are not sorted")
+		}
+	}
+
+	best := candidates[0]
+	if math.Abs(best.Rotation.Det()-1) > 1e-8 {
+		t.Errorf("rotation has determinant %f", best.Rotation.Det())
+	}
+	rotated := mesh.Transform(&model3d.Matrix3Transform{Matrix: best.Rotation})
+	size := rotated.Max().Sub(rotated.Min())
+	if math.Abs(size.Z-2) > 1e-5 {
+		t.Errorf("expected plate to be laid flat, but got size %v", size)
+	}
+	if best.OverhangArea != 0 || best.SupportVolume != 0 {
+		t.Errorf("unexpected overhangs for best candidate: %+v", best)
+	}
+	if math.Abs(best.BaseArea-100/mesh.Area()) > 1e-5 {
+		t.Errorf("unexpected base area: %f", best.BaseArea)
+	}
+
+	evaluated := (&OrientationOptimizer{}).Evaluate(mesh, best.Rotation)
+	if math.Abs(evaluated.Score-best.Score) > 1e-8 {
+		t.Errorf("evaluated score %f does not match %f", evaluated.Score, best.Score)
+	}
+}
+
+func TestRotationToZ(t *testing.T) {
+	for _, up := range []model3d.Coord3D{
+		model3d.X(1),
+		model3d.Z(1),
+		model3d.Z(-1),
+		model3d.XYZ(1, -2, 3).Normalize(),
+		model3d.XYZ(1e-10, 0, -1).Normalize(),
+	} {
+		rotation := rotationToZ(up)
+		if actual := rotation.MulColumn(up); actual.Dist(model3d.Z(1)) > 1e-8 {
+			t.Errorf("up %v mapped to %v", up, actual)
+		}
+	}
+}
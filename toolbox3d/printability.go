@@ -0,0 +1,577 @@
+package toolbox3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/model3d/model3d"
+	"github.com/unixpickle/model3d/render3d"
+)
+
+const (
+	DefaultPrintabilityOverhangAngle    = math.Pi / 4
+	DefaultPrintabilityMinWallThickness = 0.8
+	DefaultPrintabilityLayerHeight      = 0.2
+	DefaultPrintabilityMaxBridgeLength  = 10.0
+	DefaultPrintabilityGridResolution   = 200
+)
+
+// A PrintabilityAnalyzer finds features of a mesh which
+// may be difficult to print on an FDM printer.
+//
+// Lengths are in the units of the mesh, and the defaults
+// assume millimeters.
+type PrintabilityAnalyzer struct {
+	// BuildDirection is the direction in which layers are
+	// stacked. If zero, +Z is used.
+	BuildDirection model3d.Coord3D
+
+	// OverhangAngle is the maximum printable angle of a
+	// downward-facing surface, measured from vertical.
+	// If 0, DefaultPrintabilityOverhangAngle is used.
+	OverhangAngle float64
+
+	// MinWallThickness is the minimum printable thickness.
+	// If 0, DefaultPrintabilityMinWallThickness is used.
+	MinWallThickness float64
+
+	// LayerHeight is the height of each printed layer.
+	// If 0, DefaultPrintabilityLayerHeight is used.
+	LayerHeight float64
+
+	// MaxBridgeLength is the longest span that can be
+	// bridged between two supports.
+	// If 0, DefaultPrintabilityMaxBridgeLength is used.
+	MaxBridgeLength float64
+
+	// GridResolution is the number of grid cells along the
+	// longest horizontal side of the mesh, used to slice
+	// layers for island and bridge detection.
+	// If 0, DefaultPrintabilityGridResolution is used.
+	GridResolution int
+
+	// Epsilon is a small distance used to determine which
+	// faces rest on the build plate and to offset ray
+	// origins. If 0, a small fraction of the mesh size is
+	// used.
+	Epsilon float64
+}
+
+// An Overhang is a downward-facing triangle that is too
+// steep to print without support.
+type Overhang struct {
+	Triangle *model3d.Triangle
+
+	// Angle is the angle of the face from vertical.
+	Angle float64
+}
+
+// A ThinWall is a triangle whose part of the mesh is
+// thinner than the minimum wall thickness.
+type ThinWall struct {
+	Triangle *model3d.Triangle
+
+	// Thickness is the distance from the center of the
+	// triangle to the opposite side of the wall.
+	Thickness float64
+}
+
+// An Island is a region of a layer which is not connected
+// to anything in the previous layer, and will therefore
+// be printed in mid-air.
+type Island struct {
+	// Layer is the index of the layer, starting at 0 for
+	// the first layer on the build plate.
+	Layer int
+
+	// Min and Max are the bounds of the island.
+	Min model3d.Coord3D
+	Max model3d.Coord3D
+
+	// Area is the approximate area of the island.
+	Area float64
+}
+
+// A Bridge is an unsupported region of a layer which is
+// connected to supported parts of the same layer.
+type Bridge struct {
+	Layer int
+
+	Min model3d.Coord3D
+	Max model3d.Coord3D
+
+	// Span is the approximate length that must be bridged,
+	// which is twice the furthest distance of any point in
+	// the region from a supported point.
+	Span float64
+
+	// TooLong is true if Span exceeds MaxBridgeLength.
+	TooLong bool
+}
+
+// A PrintabilityReport summarizes the results of a
+// PrintabilityAnalyzer.
+type PrintabilityReport struct {
+	Overhangs []*Overhang
+	ThinWalls []*ThinWall
+	Islands   []*Island
+
+	// Bridges contains unsupported regions which are wider
+	// than the overhang that a single layer can print.
+	Bridges []*Bridge
+
+	mesh   *model3d.Mesh
+	scores map[*model3d.Triangle]float64
+}
+
+// Analyze finds problems with a mesh.
+//
+// The mesh should be manifold and have consistent
+// normals, since ray casting is used to measure
+// thickness and to slice layers.
+func (p *PrintabilityAnalyzer) Analyze(mesh *model3d.Mesh) *PrintabilityReport {
+	report := &PrintabilityReport{
+		mesh:   mesh,
+		scores: map[*model3d.Triangle]float64{},
+	}
+	if mesh.NumTriangles() == 0 {
+		return report
+	}
+	up := p.buildDirection()
+	collider := model3d.MeshToCollider(mesh)
+	p.findOverhangs(mesh, up, report)
+	p.findThinWalls(mesh, collider, report)
+	p.findLayerProblems(mesh, collider, up, report)
+	return report
+}
+
+func (p *PrintabilityAnalyzer) findOverhangs(mesh *model3d.Mesh, up model3d.Coord3D,
+	report *PrintabilityReport) {
+	var plateHeight float64 = math.Inf(1)
+	mesh.IterateVertices(func(c model3d.Coord3D) {
+		plateHeight = math.Min(plateHeight, c.Dot(up))
+	})
+	eps := p.epsilon(mesh)
+	maxAngle := p.overhangAngle()
+	mesh.Iterate(func(t *model3d.Triangle) {
+		maxHeight := math.Max(t[0].Dot(up), math.Max(t[1].Dot(up), t[2].Dot(up)))
+		if maxHeight-plateHeight < eps {
+			// Faces on the build plate are supported by it.
+			return
+		}
+		dot := t.Normal().Dot(up)
+		if dot >= 0 {
+			return
+		}
+		angle := math.Asin(math.Min(1, -dot))
+		if angle > maxAngle {
+			report.Overhangs = append(report.Overhangs, &Overhang{Triangle: t, Angle: angle})
+			score := 0.5 + 0.5*(angle-maxAngle)/(math.Pi/2-maxAngle)
+			report.addScore(t, score)
+		}
+	})
+}
+
+func (p *PrintabilityAnalyzer) findThinWalls(mesh *model3d.Mesh, collider model3d.Collider,
+	report *PrintabilityReport) {
+	tris := mesh.TriangleSlice()
+	thicknesses := make([]float64, len(tris))
+	eps := p.epsilon(mesh)
+	essentials.ConcurrentMap(0, len(tris), func(i int) {
+		t := tris[i]
+		normal := t.Normal()
+		center := t[0].Add(t[1]).Add(t[2]).Scale(1.0 / 3)
+		ray := &model3d.Ray{
+			Origin:    center.Sub(normal.Scale(eps)),
+			Direction: normal.Scale(-1),
+		}
+		thicknesses[i] = math.Inf(1)
+		if rc, ok := collider.FirstRayCollision(ray); ok {
+			thicknesses[i] = rc.Scale + eps
+		}
+	})
+	minThickness := p.minWallThickness()
+	for i, t := range tris {
+		if thicknesses[i] < minThickness {
+			report.ThinWalls = append(report.ThinWalls, &ThinWall{
+				Triangle:  t,
+				Thickness: thicknesses[i],
+			})
+			report.addScore(t, 1-0.5*thicknesses[i]/minThickness)
+		}
+	}
+}
+
+func (p *PrintabilityAnalyzer) findLayerProblems(mesh *model3d.Mesh, collider model3d.Collider,
+	up model3d.Coord3D, report *PrintabilityReport) {
+	grid := p.sliceLayers(mesh, collider, up)
+
+	// Any overhang narrower than this can be printed
+	// without a bridge.
+	minBridgeSpan := 2 * p.layerHeight() * math.Tan(p.overhangAngle())
+
+	for layer := 1; layer < grid.NumLayers; layer++ {
+		for _, component := range grid.Components(layer) {
+			supported := make([]bool, len(component))
+			var anySupported, anyUnsupported bool
+			for i, idx := range component {
+				supported[i] = grid.Filled[idx-grid.LayerSize]
+				anySupported = anySupported || supported[i]
+				anyUnsupported = anyUnsupported || !supported[i]
+			}
+			if !anySupported {
+				min, max := grid.Bounds(component)
+				island := &Island{
+					Layer: layer,
+					Min:   min,
+					Max:   max,
+					Area:  float64(len(component)) * grid.Spacing * grid.Spacing,
+				}
+				report.Islands = append(report.Islands, island)
+				report.addRegionScore(min, max, grid.Spacing, 1)
+			} else if anyUnsupported {
+				for _, region := range grid.UnsupportedRegions(component, supported) {
+					span := 2 * region.MaxDistance * grid.Spacing
+					if span <= minBridgeSpan+grid.Spacing {
+						continue
+					}
+					min, max := grid.Bounds(region.Indices)
+					bridge := &Bridge{
+						Layer:   layer,
+						Min:     min,
+						Max:     max,
+						Span:    span,
+						TooLong: span > p.maxBridgeLength(),
+					}
+					report.Bridges = append(report.Bridges, bridge)
+					if bridge.TooLong {
+						report.addRegionScore(min, max, grid.Spacing, 0.75)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (p *PrintabilityAnalyzer) sliceLayers(mesh *model3d.Mesh, collider model3d.Collider,
+	up model3d.Coord3D) *layerGrid {
+	b1, b2 := up.OrthoBasis()
+	min := model3d.XYZ(math.Inf(1), math.Inf(1), math.Inf(1))
+	max := min.Scale(-1)
+	mesh.IterateVertices(func(c model3d.Coord3D) {
+		local := model3d.XYZ(c.Dot(b1), c.Dot(b2), c.Dot(up))
+		min = min.Min(local)
+		max = max.Max(local)
+	})
+	size := max.Sub(min)
+	spacing := math.Max(size.X, size.Y) / float64(p.gridResolution())
+	layerHeight := p.layerHeight()
+	grid := &layerGrid{
+		B1:        b1,
+		B2:        b2,
+		Up:        up,
+		Origin:    min,
+		Spacing:   spacing,
+		Height:    layerHeight,
+		Width:     int(math.Ceil(size.X/spacing)) + 1,
+		Depth:     int(math.Ceil(size.Y/spacing)) + 1,
+		NumLayers: essentials.MaxInt(1, int(math.Ceil(size.Z/layerHeight))),
+	}
+	grid.LayerSize = grid.Width * grid.Depth
+	grid.Filled = make([]bool, grid.LayerSize*grid.NumLayers)
+
+	essentials.ConcurrentMap(0, grid.LayerSize, func(idx int) {
+		x, y := idx%grid.Width, idx/grid.Width
+		// Start the ray one layer below the bottom of the mesh,
+		// so that heights are collision scales minus one layer.
+		origin := grid.Point(x, y, 0).Sub(up.Scale(1.5 * layerHeight))
+		ray := &model3d.Ray{Origin: origin, Direction: up}
+		var collisions []model3d.RayCollision
+		collider.RayCollisions(ray, func(rc model3d.RayCollision) {
+			collisions = append(collisions, rc)
+		})
+		sort.Slice(collisions, func(i, j int) bool {
+			return collisions[i].Scale < collisions[j].Scale
+		})
+
+		// Track the depth inside the mesh using the normals,
+		// which is robust to coincident collisions.
+		var depth int
+		var enterScale float64
+		for _, rc := range collisions {
+			if rc.Normal.Dot(up) < 0 {
+				if depth == 0 {
+					enterScale = rc.Scale
+				}
+				depth++
+			} else if depth > 0 {
+				depth--
+				if depth == 0 {
+					grid.fillColumn(x, y, enterScale-layerHeight, rc.Scale-layerHeight)
+				}
+			}
+		}
+	})
+	return grid
+}
+
+func (p *PrintabilityAnalyzer) buildDirection() model3d.Coord3D {
+	if p.BuildDirection.Norm() == 0 {
+		return model3d.Z(1)
+	}
+	return p.BuildDirection.Normalize()
+}
+
+func (p *PrintabilityAnalyzer) overhangAngle() float64 {
+	if p.OverhangAngle == 0 {
+		return DefaultPrintabilityOverhangAngle
+	}
+	return p.OverhangAngle
+}
+
+func (p *PrintabilityAnalyzer) minWallThickness() float64 {
+	if p.MinWallThickness == 0 {
+		return DefaultPrintabilityMinWallThickness
+	}
+	return p.MinWallThickness
+}
+
+func (p *PrintabilityAnalyzer) layerHeight() float64 {
+	if p.LayerHeight == 0 {
+		return DefaultPrintabilityLayerHeight
+	}
+	return p.LayerHeight
+}
+
+func (p *PrintabilityAnalyzer) maxBridgeLength() float64 {
+	if p.MaxBridgeLength == 0 {
+		return DefaultPrintabilityMaxBridgeLength
+	}
+	return p.MaxBridgeLength
+}
+
+func (p *PrintabilityAnalyzer) gridResolution() int {
+	if p.GridResolution == 0 {
+		return DefaultPrintabilityGridResolution
+	}
+	return p.GridResolution
+}
+
+func (p *PrintabilityAnalyzer) epsilon(mesh *model3d.Mesh) float64 {
+	if p.Epsilon == 0 {
+		return mesh.Max().Dist(mesh.Min()) * 1e-5
+	}
+	return p.Epsilon
+}
+
+// Score gets the severity of problems with a triangle,
+// from 0 (no problems) to 1 (severe problems).
+func (p *PrintabilityReport) Score(t *model3d.Triangle) float64 {
+	return p.scores[t]
+}
+
+// HeatMap creates a CoordColorFunc that colors the mesh
+// according to the score of the nearest triangle, from
+// gray (no problems) through yellow to red (severe).
+func (p *PrintabilityReport) HeatMap() CoordColorFunc {
+	if p.mesh.NumTriangles() == 0 {
+		return ConstantCoordColorFunc(printabilityHeatColor(0))
+	}
+	sdf := model3d.MeshToSDF(p.mesh)
+	return func(c model3d.Coord3D) render3d.Color {
+		t, _, _ := sdf.FaceSDF(c)
+		return printabilityHeatColor(p.scores[t])
+	}
+}
+
+func (p *PrintabilityReport) addScore(t *model3d.Triangle, score float64) {
+	p.scores[t] = math.Max(p.scores[t], math.Max(0, math.Min(1, score)))
+}
+
+func (p *PrintabilityReport) addRegionScore(min, max model3d.Coord3D, margin, score float64) {
+	rect := &model3d.Rect{
+		MinVal: min.Sub(model3d.XYZ(margin, margin, margin)),
+		MaxVal: max.Add(model3d.XYZ(margin, margin, margin)),
+	}
+	p.mesh.Iterate(func(t *model3d.Triangle) {
+		center := t[0].Add(t[1]).Add(t[2]).Scale(1.0 / 3)
+		if rect.Contains(center) {
+			p.addScore(t, score)
+		}
+	})
+}
+
+func printabilityHeatColor(score float64) render3d.Color {
+	gray := render3d.NewColor(0.8)
+	yellow := render3d.NewColorRGB(1, 0.9, 0)
+	red := render3d.NewColorRGB(1, 0, 0)
+	if score < 0.5 {
+		return gray.Scale(1 - score*2).Add(yellow.Scale(score * 2))
+	}
+	return yellow.Scale(2 - score*2).Add(red.Scale(score*2 - 1))
+}
+
+// layerGrid stores which cells of each printed layer are
+// filled, in a coordinate system where Up is the Z axis.
+type layerGrid struct {
+	B1     model3d.Coord3D
+	B2     model3d.Coord3D
+	Up     model3d.Coord3D
+	Origin model3d.Coord3D
+
+	Spacing float64
+	Height  float64
+
+	Width     int
+	Depth     int
+	NumLayers int
+	LayerSize int
+
+	Filled []bool
+}
+
+// Point gets the world coordinate of the center of a
+// cell.
+func (l *layerGrid) Point(x, y, layer int) model3d.Coord3D {
+	local := l.Origin.Add(model3d.XYZ(
+		float64(x)*l.Spacing,
+		float64(y)*l.Spacing,
+		(float64(layer)+0.5)*l.Height,
+	))
+	return l.B1.Scale(local.X).Add(l.B2.Scale(local.Y)).Add(l.Up.Scale(local.Z))
+}
+
+// fillColumn marks the cells of a column as filled between
+// two heights above the bottom of the grid.
+func (l *layerGrid) fillColumn(x, y int, minHeight, maxHeight float64) {
+	// Layers are filled if their center is inside.
+	start := essentials.MaxInt(0, int(math.Ceil(minHeight/l.Height-0.5)))
+	end := essentials.MinInt(l.NumLayers-1, int(math.Floor(maxHeight/l.Height-0.5)))
+	for layer := start; layer <= end; layer++ {
+		l.Filled[layer*l.LayerSize+y*l.Width+x] = true
+	}
+}
+
+// Components finds the connected components of filled
+// cells in a layer.
+func (l *layerGrid) Components(layer int) [][]int {
+	visited := map[int]bool{}
+	var result [][]int
+	offset := layer * l.LayerSize
+	for i := 0; i < l.LayerSize; i++ {
+		if !l.Filled[offset+i] || visited[offset+i] {
+			continue
+		}
+		component := l.flood(offset+i, func(idx int) bool {
+			return l.Filled[idx]
+		}, visited)
+		result = append(result, component)
+	}
+	return result
+}
+
+type unsupportedRegion struct {
+	Indices     []int
+	MaxDistance float64
+}
+
+// UnsupportedRegions finds connected regions of the
+// unsupported cells in a component, along with the
+// maximum distance of each region from supported cells.
+func (l *layerGrid) UnsupportedRegions(component []int, supported []bool) []unsupportedRegion {
+	isSupported := map[int]bool{}
+	inComponent := map[int]bool{}
+	for i, idx := range component {
+		inComponent[idx] = true
+		if supported[i] {
+			isSupported[idx] = true
+		}
+	}
+
+	// Breadth-first search from all supported cells.
+	distances := map[int]int{}
+	var queue []int
+	for idx := range isSupported {
+		distances[idx] = 0
+		queue = append(queue, idx)
+	}
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		for _, n := range l.neighbors(idx) {
+			if _, ok := distances[n]; !ok && inComponent[n] {
+				distances[n] = distances[idx] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	visited := map[int]bool{}
+	var result []unsupportedRegion
+	for _, idx := range component {
+		if isSupported[idx] || visited[idx] {
+			continue
+		}
+		indices := l.flood(idx, func(i int) bool {
+			return inComponent[i] && !isSupported[i]
+		}, visited)
+		var maxDist int
+		for _, i := range indices {
+			maxDist = essentials.MaxInt(maxDist, distances[i])
+		}
+		result = append(result, unsupportedRegion{
+			Indices:     indices,
+			MaxDistance: float64(maxDist),
+		})
+	}
+	return result
+}
+
+// Bounds gets the world-space bounding box of some cells.
+func (l *layerGrid) Bounds(indices []int) (min, max model3d.Coord3D) {
+	min = model3d.XYZ(math.Inf(1), math.Inf(1), math.Inf(1))
+	max = min.Scale(-1)
+	for _, idx := range indices {
+		layer := idx / l.LayerSize
+		rem := idx % l.LayerSize
+		p := l.Point(rem%l.Width, rem/l.Width, layer)
+		min = min.Min(p)
+		max = max.Max(p)
+	}
+	return
+}
+
+func (l *layerGrid) flood(start int, include func(idx int) bool, visited map[int]bool) []int {
+	visited[start] = true
+	result := []int{start}
+	for i := 0; i < len(result); i++ {
+		for _, n := range l.neighbors(result[i]) {
+			if !visited[n] && include(n) {
+				visited[n] = true
+				result = append(result, n)
+			}
+		}
+	}
+	return result
+}
+
+func (l *layerGrid) neighbors(idx int) []int {
+	layerStart := (idx / l.LayerSize) * l.LayerSize
+	rem := idx - layerStart
+	x, y := rem%l.Width, rem/l.Width
+	res := make([]int, 0, 4)
+	if x > 0 {
+		res = append(res, idx-1)
+	}
+	if x+1 < l.Width {
+		res = append(res, idx+1)
+	}
+	if y > 0 {
+		res = append(res, idx-l.Width)
+	}
+	if y+1 < l.Depth {
+		res = append(res, idx+l.Width)
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package toolbox3d
+
+import (
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestPrintabilityAnalyzerCube(t *testing.T) {
+	mesh := model3d.NewMeshRect(model3d.XYZ(0, 0, 0), model3d.XYZ(10, 10, 10))
+	analyzer := &PrintabilityAnalyzer{GridResolution: 50}
+	report := analyzer.Analyze(mesh)
+	if len(report.Overhangs) != 0 {
+		t.Errorf("unexpected overhangs: %d", len(report.Overhangs))
+	}
+	if len(report.ThinWalls) != 0 {
+		t.Errorf("unexpected thin walls: %d", len(report.ThinWalls))
+	}
+	if len(report.Islands) != 0 {
+		t.Errorf("unexpected islands: %d", len(report.Islands))
+	}
+	if len(report.Bridges) != 0 {
+		t.Errorf("unexpected bridges: %d", len(report.Bridges))
+	}
+
+	// Tilting the build direction makes the bottom face
+	// into an overhang, while the sides are still fine.
+	analyzer.BuildDirection = model3d.XZ(1, 2)
+	report = analyzer.Analyze(mesh)
+	if len(report.Overhangs) != 2 {
+		t.Errorf("expected 2 overhangs but got %d", len(report.Overhangs))
+	}
+}
+
+func TestPrintabilityAnalyzerProblems(t *testing.T) {
+	solid := model3d.JoinedSolid{
+		// Base.
+		&model3d.Rect{MinVal: model3d.XYZ(0, 0, 0), MaxVal: model3d.XYZ(20, 20, 5)},
+		// Shelf overhanging the base.
+		&model3d.Rect{MinVal: model3d.XYZ(19, 0, 4), MaxVal: model3d.XYZ(35, 20, 5)},
+		// Thin wall.
+		&model3d.Rect{MinVal: model3d.XYZ(1, 1, 4), MaxVal: model3d.XYZ(1.5, 19, 10)},
+		// Floating island.
+		&model3d.Rect{MinVal: model3d.XYZ(10, 10, 8), MaxVal: model3d.XYZ(15, 15, 10)},
+	}
+	mesh := model3d.MarchingCubesSearch(solid, 0.25, 8)
+	report := (&PrintabilityAnalyzer{}).Analyze(mesh)
+
+	var foundShelf bool
+	for _, o := range report.Overhangs {
+		c := o.Triangle.Min().Mid(o.Triangle.Max())
+		if c.X > 21 && c.Z > 3.9 && c.Z < 4.1 {
+			foundShelf = true
+		}
+		if c.X < 19 && c.Z < 7 {
+			t.Errorf("unexpected overhang at %v", c)
+		}
+	}
+	if !foundShelf {
+		t.Error("missing shelf overhang")
+	}
+
+	var foundWall bool
+	for _, w := range report.ThinWalls {
+		c := w.Triangle.Min().Mid(w.Triangle.Max())
+		if c.X > 0.9 && c.X < 1.6 && c.Z > 6 {
+			foundWall = true
+		}
+		if w.Thickness > DefaultPrintabilityMinWallThickness {
+			t.Errorf("unexpected thickness: %f", w.Thickness)
+		}
+	}
+	if !foundWall {
+		t.Error("missing thin wall")
+	}
+	if report.Score(report.ThinWalls[0].Triangle) == 0 {
+		t.Error("thin wall should have a score")
+	}
+
+	if len(report.Islands) != 1 {
+		t.Errorf("expected 1 island but got %d", len(report.Islands))
+	} else {
+		island := report.Islands[0]
+		if island.Min.Z < 7.5 || island.Min.Z > 8.5 || island.Min.X < 9.5 || island.Max.X > 15.5 {
+			t.Errorf("unexpected island bounds: %v %v", island.Min, island.Max)
+		}
+		if island.Area < 20 || island.Area > 30 {
+			t.Errorf("unexpected island area: %f", island.Area)
+		}
+	}
+
+	var foundBridge bool
+	for _, b := range report.Bridges {
+		if b.Min.X > 19 && b.Max.X > 30 {
+			foundBridge = true
+			if !b.TooLong {
+				t.Errorf("bridge span %f should be too long", b.Span)
+			}
+		}
+	}
+	if !foundBridge {
+		t.Error("missing shelf bridge")
+	}
+
+	heatMap := report.HeatMap()
+	if color := heatMap(model3d.XYZ(30, 10, 4)); color.Y > 0.5 {
+		t.Errorf("expected red overhang but got %v", color)
+	}
+	if color := heatMap(model3d.XYZ(10, 10, 0)); color != printabilityHeatColor(0) {
+		t.Errorf("expected gray bottom but got %v", color)
+	}
+}
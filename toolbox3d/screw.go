@@ -12,6 +12,9 @@ import (
 //
 // Screws are similar to cylinders, so many of the fields
 // are analogous to model3d.CylinderSolid.
+//
+// For standard threads that mate with metal hardware,
+// see ThreadSolid and ParseThreadSpec.
 type ScrewSolid struct {
 	// P1 is the center of the start of the screw.
 	P1 model3d.Coord3D
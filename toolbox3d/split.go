@@ -0,0 +1,746 @@
+package toolbox3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultSplitConnectorRadius = 2.0
+	DefaultSplitConnectorLength = 10.0
+	DefaultSplitClearance       = 0.15
+	DefaultSplitMaxConnectors   = 2
+	DefaultSplitMinThickness    = 5.0
+	DefaultSplitDovetailAngle   = math.Pi / 12
+)
+
+// SplitConnector determines how the parts created by a
+// Splitter are aligned and joined.
+type SplitConnector int
+
+const (
+	// PinConnectors add pins to one side of each cut, and
+	// matching clearance holes to the other side.
+	PinConnectors SplitConnector = iota
+
+	// DowelConnectors add holes to both sides of each cut,
+	// which are joined by separate dowels.
+	DowelConnectors
+
+	// DovetailConnectors add a dovetail key to one side of
+	// each cut, and a matching slot to the other side.
+	// The parts are joined by sliding the key into the
+	// slot from the edge of the cut.
+	// Keys stop short of other cut planes, so they do not
+	// collide with the keys of neighboring parts.
+	DovetailConnectors
+
+	// NoConnectors does not add any connectors.
+	NoConnectors
+)
+
+// A CutPlane is a plane along which a model is split.
+type CutPlane struct {
+	Point  model3d.Coord3D
+	Normal model3d.Coord3D
+}
+
+// side gets the signed distance of c along the normal.
+func (c *CutPlane) side(p model3d.Coord3D) float64 {
+	return p.Sub(c.Point).Dot(c.Normal)
+}
+
+// A Splitter cuts a model into parts which are small
+// enough to print, with connectors to align the parts
+// when they are glued together.
+//
+// Lengths are in the units of the model, and the defaults
+// assume millimeters.
+type Splitter struct {
+	// BuildVolume is the size of the printer's build
+	// volume. If it is non-zero, then cut planes along the
+	// coordinate axes are chosen automatically, in addition
+	// to any planes in Planes.
+	BuildVolume model3d.Coord3D
+
+	// Planes are explicit cut planes.
+	Planes []*CutPlane
+
+	Connector SplitConnector
+
+	// ConnectorRadius is the radius of pins and dowels, or
+	// half the narrow width of dovetail keys.
+	// If 0, DefaultSplitConnectorRadius is used.
+	ConnectorRadius float64
+
+	// ConnectorLength is the total length of pins and
+	// dowels, or twice the depth of dovetail keys.
+	// If 0, DefaultSplitConnectorLength is used.
+	ConnectorLength float64
+
+	// Clearance is the gap between connectors and the holes
+	// they fit into.
+	// If 0, DefaultSplitClearance is used.
+	Clearance float64
+
+	// MaxConnectors is the maximum number of pins or dowels
+	// on each cut face.
+	// If 0, DefaultSplitMaxConnectors is used.
+	MaxConnectors int
+
+	// MinThickness is the minimum thickness of material on
+	// either side of an automatically chosen cut, used to
+	// avoid thin slivers.
+	// If 0, DefaultSplitMinThickness is used.
+	MinThickness float64
+
+	// Delta is the resolution at which the model is
+	// sampled to find parts and place connectors.
+	// If 0, a fraction of the model's size is used.
+	Delta float64
+}
+
+// A SplitResult is the output of a Splitter.
+type SplitResult struct {
+	// Parts are the solids for each piece of the model.
+	Parts []model3d.Solid
+
+	// Planes are all of the planes that were cut.
+	Planes []*CutPlane
+
+	// Dowels are separate pieces which join the parts when
+	// DowelConnectors are used. Each dowel is positioned
+	// where it will be inserted into the parts.
+	Dowels []model3d.Solid
+}
+
+// Split cuts a solid into parts.
+//
+// The resulting parts may be meshed separately, for
+// example to be saved with model3d.Write3MFMulti().
+func (s *Splitter) Split(solid model3d.Solid) (*SplitResult, error) {
+	if s.BuildVolume == (model3d.Coord3D{}) && len(s.Planes) == 0 {
+		return nil, errors.New("split: no build volume or cut planes")
+	}
+	planes := append([]*CutPlane{}, s.Planes...)
+	for _, p := range planes {
+		if p.Normal.Norm() == 0 {
+			return nil, errors.New("split: cut plane has zero normal")
+		}
+	}
+	for i := range planes {
+		planes[i] = &CutPlane{Point: planes[i].Point, Normal: planes[i].Normal.Normalize()}
+	}
+	if s.BuildVolume != (model3d.Coord3D{}) {
+		autoPlanes, err := s.automaticPlanes(solid)
+		if err != nil {
+			return nil, err
+		}
+		planes = append(planes, autoPlanes...)
+	}
+
+	parts := s.findParts(solid, planes)
+	partMap := map[string]*splitPart{}
+	for _, p := range parts {
+		partMap[p.key()] = p
+	}
+	result := &SplitResult{Planes: planes}
+	if s.Connector != NoConnectors {
+		for _, p := range parts {
+			for j := range planes {
+				if !p.Signs[j] {
+					continue
+				}
+				other := partMap[p.keyFlipped(j)]
+				if other == nil {
+					continue
+				}
+				result.Dowels = append(result.Dowels, s.connect(solid, p, other, j)...)
+			}
+		}
+	}
+	for _, p := range parts {
+		p.computeBounds()
+		result.Parts = append(result.Parts, p)
+	}
+	return result, nil
+}
+
+// automaticPlanes chooses axis-aligned cuts so that each
+// part fits in the build volume.
+func (s *Splitter) automaticPlanes(solid model3d.Solid) ([]*CutPlane, error) {
+	min, max := solid.Min(), solid.Max()
+	size := max.Sub(min).Array()
+	volume := s.BuildVolume.Array()
+	var result []*CutPlane
+	for axis := 0; axis < 3; axis++ {
+		if volume[axis] <= 0 {
+			return nil, errors.New("split: build volume must be positive")
+		}
+		numParts := int(math.Ceil(size[axis] / volume[axis]))
+		if numParts <= 1 {
+			continue
+		}
+		var normalArr [3]float64
+		normalArr[axis] = 1
+		normal := model3d.NewCoord3DArray(normalArr)
+
+		partSize := size[axis] / float64(numParts)
+		maxShift := (volume[axis] - partSize) / 2
+		for i := 1; i < numParts; i++ {
+			nominal := min.Array()[axis] + partSize*float64(i)
+			offset := s.bestCutOffset(solid, axis, nominal, maxShift)
+			var pointArr [3]float64
+			pointArr[axis] = offset
+			result = append(result, &CutPlane{
+				Point:  model3d.NewCoord3DArray(pointArr),
+				Normal: normal,
+			})
+		}
+	}
+	return result, nil
+}
+
+// bestCutOffset finds the position of an axis-aligned cut
+// near nominal which creates the fewest thin slivers.
+func (s *Splitter) bestCutOffset(solid model3d.Solid, axis int, nominal,
+	maxShift float64) float64 {
+	delta := s.delta(solid)
+	bestOffset := nominal
+	bestScore := s.sliverScore(solid, axis, nominal)
+	for shift := delta; shift <= maxShift && bestScore > 0; shift += delta {
+		for _, offset := range []float64{nominal - shift, nominal + shift} {
+			if score := s.sliverScore(solid, axis, offset); score < bestScore {
+				bestScore = score
+				bestOffset = offset
+			}
+		}
+	}
+	return bestOffset
+}
+
+// sliverScore counts the points on a cut which are part of
+// a thin region on either side of the cut.
+func (s *Splitter) sliverScore(solid model3d.Solid, axis int, offset float64) int {
+	delta := s.delta(solid)
+	minThickness := s.minThickness()
+	min, max := solid.Min().Array(), solid.Max().Array()
+	a1, a2 := (axis+1)%3, (axis+2)%3
+	var score int
+	for x := min[a1] + delta/2; x < max[a1]; x += delta {
+		for y := min[a2] + delta/2; y < max[a2]; y += delta {
+			var arr [3]float64
+			arr[axis], arr[a1], arr[a2] = offset, x, y
+			p := model3d.NewCoord3DArray(arr)
+			if !solid.Contains(p) {
+				continue
+			}
+			for _, sign := range []float64{-1, 1} {
+				for d := delta / 2; d < minThickness; d += delta / 2 {
+					arr[axis] = offset + sign*d
+					if !solid.Contains(model3d.NewCoord3DArray(arr)) {
+						score++
+						break
+					}
+				}
+			}
+		}
+	}
+	return score
+}
+
+// findParts finds the non-empty regions between the cut
+// planes.
+func (s *Splitter) findParts(solid model3d.Solid, planes []*CutPlane) []*splitPart {
+	delta := s.delta(solid)
+	min, max := solid.Min(), solid.Max()
+	found := map[string]*splitPart{}
+	for x := min.X + delta/2; x < max.X; x += delta {
+		for y := min.Y + delta/2; y < max.Y; y += delta {
+			for z := min.Z + delta/2; z < max.Z; z += delta {
+				c := model3d.XYZ(x, y, z)
+				if !solid.Contains(c) {
+					continue
+				}
+				signs := make([]bool, len(planes))
+				for i, p := range planes {
+					signs[i] = p.side(c) >= 0
+				}
+				part := &splitPart{Solid: solid, Planes: planes, Signs: signs}
+				if _, ok := found[part.key()]; !ok {
+					found[part.key()] = part
+				}
+			}
+		}
+	}
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]*splitPart, len(keys))
+	for i, key := range keys {
+		result[i] = found[key]
+	}
+	return result
+}
+
+// connect adds connectors between the positive part p and
+// the negative part other, across the given plane.
+//
+// It returns any separate dowels.
+func (s *Splitter) connect(solid model3d.Solid, p, other *splitPart, planeIdx int) []model3d.Solid {
+	plane := p.Planes[planeIdx]
+	candidates := s.connectorCandidates(solid, p, planeIdx)
+	if len(candidates) == 0 {
+		return nil
+	}
+	normal := plane.Normal
+	radius := s.connectorRadius()
+	halfLength := s.connectorLength() / 2
+	clearance := s.clearance()
+
+	if s.Connector == DovetailConnectors {
+		center, dir := principalLine(candidates)
+		if math.IsNaN(dir.Norm()) || math.Abs(dir.Dot(normal)) > 0.5 {
+			dir, _ = normal.OrthoBasis()
+		}
+		u := normal.Cross(dir).Normalize()
+		key := &dovetailKey{
+			Center:    center,
+			Normal:    normal.Scale(-1),
+			U:         u,
+			Depth:     halfLength,
+			HalfWidth: radius,
+			Slope:     math.Tan(DefaultSplitDovetailAngle),
+		}
+		slot := *key
+		slot.Depth += clearance
+		slot.HalfWidth += clearance
+		slot.Back = clearance
+
+		// The key and slot are infinitely long, so they are
+		// limited to the other part's side of every other
+		// plane, and stop short of those planes to avoid the
+		// keys and slots of neighboring parts.
+		cell := &splitCell{
+			Planes: other.Planes,
+			Signs:  other.Signs,
+			Ignore: planeIdx,
+			Margin: halfLength + clearance,
+		}
+		p.Added = append(p.Added, model3d.IntersectedSolid{key, solid, cell})
+		p.Protrusion = math.Max(p.Protrusion, halfLength)
+		other.Removed = append(other.Removed, model3d.IntersectedSolid{&slot, cell})
+		return nil
+	}
+
+	// Avoid connectors which collide with connectors on
+	// other faces of either part.
+	minDist := 2*(radius+clearance) + radius
+	var filtered []model3d.Coord3D
+	for _, c := range candidates {
+		axis := model3d.NewSegment(c.Add(normal.Scale(halfLength+clearance)),
+			c.Sub(normal.Scale(halfLength+clearance)))
+		if !p.connectorCollides(axis, minDist) && !other.connectorCollides(axis, minDist) {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	var dowels []model3d.Solid
+	for _, c := range s.spreadPoints(filtered) {
+		axis := model3d.NewSegment(c.Add(normal.Scale(halfLength+clearance)),
+			c.Sub(normal.Scale(halfLength+clearance)))
+		p.Connectors = append(p.Connectors, axis)
+		other.Connectors = append(other.Connectors, axis)
+		hole := &model3d.Cylinder{
+			P1:     c.Add(normal.Scale(clearance)),
+			P2:     c.Sub(normal.Scale(halfLength + clearance)),
+			Radius: radius + clearance,
+		}
+		other.Removed = append(other.Removed, hole)
+		if s.Connector == PinConnectors {
+			p.Protrusion = math.Max(p.Protrusion, halfLength)
+			p.Added = append(p.Added, &model3d.Cylinder{
+				P1:     c,
+				P2:     c.Sub(normal.Scale(halfLength)),
+				Radius: radius,
+			})
+		} else {
+			p.Removed = append(p.Removed, &model3d.Cylinder{
+				P1:     c.Sub(normal.Scale(clearance)),
+				P2:     c.Add(normal.Scale(halfLength + clearance)),
+				Radius: radius + clearance,
+			})
+			dowels = append(dowels, &model3d.Cylinder{
+				P1:     c.Sub(normal.Scale(halfLength)),
+				P2:     c.Add(normal.Scale(halfLength)),
+				Radius: radius,
+			})
+		}
+	}
+	return dowels
+}
+
+// connectorCandidates finds points on the cut face where a
+// connector would be surrounded by enough material on both
+// sides of the cut.
+func (s *Splitter) connectorCandidates(solid model3d.Solid, p *splitPart,
+	planeIdx int) []model3d.Coord3D {
+	plane := p.Planes[planeIdx]
+	normal := plane.Normal
+	b1, b2 := normal.OrthoBasis()
+	delta := s.delta(solid)
+	radius := s.connectorRadius()
+	outerRadius := 2*radius + s.clearance()
+	depth := s.connectorLength()/2 + s.clearance() + radius
+
+	// Project the bounding box onto the plane.
+	var minU, maxU, minV float64 = math.Inf(1), math.Inf(-1), math.Inf(1)
+	maxV := math.Inf(-1)
+	min, max := solid.Min(), solid.Max()
+	for i := 0; i < 8; i++ {
+		corner := min
+		if i&1 != 0 {
+			corner.X = max.X
+		}
+		if i&2 != 0 {
+			corner.Y = max.Y
+		}
+		if i&4 != 0 {
+			corner.Z = max.Z
+		}
+		offset := corner.Sub(plane.Point)
+		minU = math.Min(minU, offset.Dot(b1))
+		maxU = math.Max(maxU, offset.Dot(b1))
+		minV = math.Min(minV, offset.Dot(b2))
+		maxV = math.Max(maxV, offset.Dot(b2))
+	}
+
+	inFace := func(c model3d.Coord3D, margin float64) bool {
+		if !solid.Contains(c) {
+			return false
+		}
+		for i, other := range p.Planes {
+			if i == planeIdx {
+				continue
+			}
+			dist := other.side(c)
+			if (p.Signs[i] && dist < margin) || (!p.Signs[i] && dist > -margin) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var result []model3d.Coord3D
+	for u := minU + delta/2; u < maxU; u += delta {
+		for v := minV + delta/2; v < maxV; v += delta {
+			c := plane.Point.Add(b1.Scale(u)).Add(b2.Scale(v))
+			if !inFace(c, outerRadius) {
+				continue
+			}
+			ok := true
+			for i := 0; i < 8 && ok; i++ {
+				theta := float64(i) * math.Pi / 4
+				dir := b1.Scale(math.Cos(theta)).Add(b2.Scale(math.Sin(theta)))
+				for _, d := range []float64{-depth, -depth / 2, depth / 2, depth} {
+					if !inFace(c.Add(dir.Scale(outerRadius)).Add(normal.Scale(d)), 0) {
+						ok = false
+						break
+					}
+				}
+			}
+			if ok {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+// spreadPoints chooses up to MaxConnectors points which are
+// far apart from each other.
+func (s *Splitter) spreadPoints(candidates []model3d.Coord3D) []model3d.Coord3D {
+	var center model3d.Coord3D
+	for _, c := range candidates {
+		center = center.Add(c)
+	}
+	center = center.Scale(1 / float64(len(candidates)))
+
+	minSpacing := 4 * s.connectorRadius()
+	distances := make([]float64, len(candidates))
+	for i, c := range candidates {
+		distances[i] = c.Dist(center)
+	}
+
+	var result []model3d.Coord3D
+	if s.maxConnectors() == 1 {
+		// Use the point closest to the center.
+		for i := range distances {
+			distances[i] = -distances[i]
+		}
+	}
+	for len(result) < s.maxConnectors() {
+		bestIdx := -1
+		for i, d := range distances {
+			if bestIdx == -1 || d > distances[bestIdx] {
+				bestIdx = i
+			}
+		}
+		if len(result) > 0 && distances[bestIdx] < minSpacing {
+			break
+		}
+		chosen := candidates[bestIdx]
+		result = append(result, chosen)
+		for i, c := range candidates {
+			if len(result) == 1 {
+				distances[i] = c.Dist(chosen)
+			} else {
+				distances[i] = math.Min(distances[i], c.Dist(chosen))
+			}
+		}
+	}
+	return result
+}
+
+// principalLine finds the center and principal direction
+// of a set of points.
+func principalLine(points []model3d.Coord3D) (center, direction model3d.Coord3D) {
+	for _, c := range points {
+		center = center.Add(c)
+	}
+	center = center.Scale(1 / float64(len(points)))
+	var covariance model3d.Matrix3
+	for _, c := range points {
+		covariance = *covariance.Add(model3d.NewMatrix3Outer(c.Sub(center)))
+	}
+	var u, sigma, v model3d.Matrix3
+	covariance.SVD(&u, &sigma, &v)
+	direction = model3d.XYZ(u[0], u[3], u[6])
+	return center, direction.Normalize()
+}
+
+func (s *Splitter) connectorRadius() float64 {
+	if s.ConnectorRadius == 0 {
+		return DefaultSplitConnectorRadius
+	}
+	return s.ConnectorRadius
+}
+
+func (s *Splitter) connectorLength() float64 {
+	if s.ConnectorLength == 0 {
+		return DefaultSplitConnectorLength
+	}
+	return s.ConnectorLength
+}
+
+func (s *Splitter) clearance() float64 {
+	if s.Clearance == 0 {
+		return DefaultSplitClearance
+	}
+	return s.Clearance
+}
+
+func (s *Splitter) maxConnectors() int {
+	if s.MaxConnectors == 0 {
+		return DefaultSplitMaxConnectors
+	}
+	return s.MaxConnectors
+}
+
+func (s *Splitter) minThickness() float64 {
+	if s.MinThickness == 0 {
+		return DefaultSplitMinThickness
+	}
+	return s.MinThickness
+}
+
+func (s *Splitter) delta(solid model3d.Solid) float64 {
+	if s.Delta == 0 {
+		return solid.Max().Dist(solid.Min()) / 100
+	}
+	return s.Delta
+}
+
+// splitPart is the region of a solid on one side of each
+// cut plane, with connectors added and removed.
+type splitPart struct {
+	Solid  model3d.Solid
+	Planes []*CutPlane
+	Signs  []bool
+
+	Added   model3d.JoinedSolid
+	Removed model3d.JoinedSolid
+
+	// Connectors are the axes of the pins or holes in the
+	// part, used to prevent connectors from colliding.
+	Connectors []model3d.Segment
+
+	// Protrusion is the maximum distance that connectors
+	// extend beyond the cut planes.
+	Protrusion float64
+
+	min model3d.Coord3D
+	max model3d.Coord3D
+}
+
+func (s *splitPart) key() string {
+	res := make([]byte, len(s.Signs))
+	for i, sign := range s.Signs {
+		if sign {
+			res[i] = '1'
+		} else {
+			res[i] = '0'
+		}
+	}
+	return string(res)
+}
+
+func (s *splitPart) keyFlipped(idx int) string {
+	res := []byte(s.key())
+	if res[idx] == '1' {
+		res[idx] = '0'
+	} else {
+		res[idx] = '1'
+	}
+	return string(res)
+}
+
+func (s *splitPart) computeBounds() {
+	min, max := s.Solid.Min(), s.Solid.Max()
+	minArr, maxArr := min.Array(), max.Array()
+	for i, p := range s.Planes {
+		for axis := 0; axis < 3; axis++ {
+			n := p.Normal.Array()[axis]
+			offset := p.Point.Array()[axis]
+			if n > 1-1e-8 {
+				if s.Signs[i] {
+					minArr[axis] = math.Max(minArr[axis], offset)
+				} else {
+					maxArr[axis] = math.Min(maxArr[axis], offset)
+				}
+			} else if n < -1+1e-8 {
+				if s.Signs[i] {
+					maxArr[axis] = math.Min(maxArr[axis], offset)
+				} else {
+					minArr[axis] = math.Max(minArr[axis], offset)
+				}
+			}
+		}
+	}
+	s.min = model3d.NewCoord3DArray(minArr).AddScalar(-s.Protrusion)
+	s.max = model3d.NewCoord3DArray(maxArr).AddScalar(s.Protrusion)
+	s.min = s.min.Max(min)
+	s.max = s.max.Min(max)
+}
+
+// connectorCollides checks if a connector axis is too
+// close to an existing connector.
+func (s *splitPart) connectorCollides(axis model3d.Segment, minDist float64) bool {
+	for _, other := range s.Connectors {
+		for i := 0; i <= 10; i++ {
+			p := axis[0].Add(axis[1].Sub(axis[0]).Scale(float64(i) / 10))
+			if other.Dist(p) < minDist {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *splitPart) Min() model3d.Coord3D {
+	return s.min
+}
+
+func (s *splitPart) Max() model3d.Coord3D {
+	return s.max
+}
+
+func (s *splitPart) Contains(c model3d.Coord3D) bool {
+	if !model3d.InBounds(s, c) || s.Removed.Contains(c) {
+		return false
+	}
+	if s.Added.Contains(c) {
+		return true
+	}
+	for i, p := range s.Planes {
+		if (p.side(c) >= 0) != s.Signs[i] {
+			return false
+		}
+	}
+	return s.Solid.Contains(c)
+}
+
+// splitCell is the region on one side of each cut plane,
+// except for one ignored plane, at least Margin away from
+// each plane.
+type splitCell struct {
+	Planes []*CutPlane
+	Signs  []bool
+	Ignore int
+	Margin float64
+}
+
+func (s *splitCell) Min() model3d.Coord3D {
+	return model3d.XYZ(math.Inf(-1), math.Inf(-1), math.Inf(-1))
+}
+
+func (s *splitCell) Max() model3d.Coord3D {
+	return model3d.XYZ(math.Inf(1), math.Inf(1), math.Inf(1))
+}
+
+func (s *splitCell) Contains(c model3d.Coord3D) bool {
+	for i, p := range s.Planes {
+		if i == s.Ignore {
+			continue
+		}
+		dist := p.side(c)
+		if (s.Signs[i] && dist < s.Margin) || (!s.Signs[i] && dist > -s.Margin) {
+			return false
+		}
+	}
+	return true
+}
+
+// dovetailKey is an infinitely long prism whose cross
+// section is a trapezoid, widening away from the cut.
+type dovetailKey struct {
+	Center model3d.Coord3D
+
+	// Normal points from the cut into the key.
+	Normal model3d.Coord3D
+
+	// U is the direction across the width of the key.
+	U model3d.Coord3D
+
+	Depth     float64
+	HalfWidth float64
+	Slope     float64
+
+	// Back extends the key behind the cut.
+	Back float64
+}
+
+func (d *dovetailKey) Min() model3d.Coord3D {
+	return model3d.XYZ(math.Inf(-1), math.Inf(-1), math.Inf(-1))
+}
+
+func (d *dovetailKey) Max() model3d.Coord3D {
+	return model3d.XYZ(math.Inf(1), math.Inf(1), math.Inf(1))
+}
+
+func (d *dovetailKey) Contains(c model3d.Coord3D) bool {
+	offset := c.Sub(d.Center)
+	depth := offset.Dot(d.Normal)
+	if depth < -d.Back || depth > d.Depth {
+		return false
+	}
+	return math.Abs(offset.Dot(d.U)) <= d.HalfWidth+math.Max(0, depth)*d.Slope
+}
@@ -0,0 +1,176 @@
+package toolbox3d
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestSplitterPins(t *testing.T) {
+	solid := &model3d.Rect{MinVal: model3d.XYZ(0, 0, 0), MaxVal: model3d.XYZ(100, 40, 20)}
+	for _, connector := range []SplitConnector{PinConnectors, DowelConnectors,
+		DovetailConnectors, NoConnectors} {
+		splitter := &Splitter{
+			BuildVolume: model3d.XYZ(60, 60, 60),
+			Connector:   connector,
+		}
+		result, err := splitter.Split(solid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Planes) != 1 || len(result.Parts) != 2 {
+			t.Fatalf("connector %d: unexpected number of planes (%d) or parts (%d)",
+				connector, len(result.Planes), len(result.Parts))
+		}
+		if x := result.Planes[0].Point.X; x != 50 {
+			t.Errorf("connector %d: unexpected cut at %f", connector, x)
+		}
+		if connector == DowelConnectors {
+			if len(result.Dowels) != DefaultSplitMaxConnectors {
+				t.Errorf("unexpected number of dowels: %d", len(result.Dowels))
+			}
+		} else if len(result.Dowels) != 0 {
+			t.Errorf("connector %d: unexpected dowels", connector)
+		}
+
+		neg, pos := result.Parts[0], result.Parts[1]
+		if pos.Max().X != 100 || pos.Min().X < 45-1e-8 || neg.Min().X != 0 || neg.Max().X != 50 {
+			t.Errorf("connector %d: unexpected bounds %v-%v and %v-%v", connector,
+				neg.Min(), neg.Max(), pos.Min(), pos.Max())
+		}
+
+		// Parts should never overlap, and should only leave
+		// gaps near the connectors.
+		rng := rand.New(rand.NewSource(0))
+		var gaps int
+		for i := 0; i < 100000; i++ {
+			c := model3d.XYZ(rng.Float64()*100, rng.Float64()*40, rng.Float64()*20)
+			in1, in2 := neg.Contains(c), pos.Contains(c)
+			if in1 && in2 {
+				t.Fatalf("connector %d: parts overlap at %v", connector, c)
+			} else if !in1 && !in2 {
+				gaps++
+				if c.X < 40 || c.X > 60 {
+					t.Fatalf("connector %d: unexpected gap at %v", connector, c)
+				}
+			}
+		}
+		if connector == NoConnectors {
+			if gaps != 0 {
+				t.Errorf("unexpected gaps without connectors: %d", gaps)
+			}
+			continue
+		} else if gaps == 0 {
+			t.Errorf("connector %d: expected gaps around connectors", connector)
+		}
+
+		// The positive part should extend into the negative
+		// side for pins and dovetails.
+		var protrudes bool
+		for x := 45.5; x < 50; x += 0.5 {
+			for y := 0.0; y < 40; y += 0.5 {
+				for z := 0.0; z < 20; z += 0.5 {
+					if pos.Contains(model3d.XYZ(x, y, z)) {
+						protrudes = true
+					}
+				}
+			}
+		}
+		if protrudes != (connector != DowelConnectors) {
+			t.Errorf("connector %d: unexpected protrusion: %v", connector, protrudes)
+		}
+	}
+}
+
+func TestSplitterMultiplePlanes(t *testing.T) {
+	solid := &model3d.Rect{MinVal: model3d.XYZ(0, 0, 0), MaxVal: model3d.XYZ(100, 100, 20)}
+	for _, connector := range []SplitConnector{PinConnectors, DowelConnectors,
+		DovetailConnectors} {
+		splitter := &Splitter{
+			BuildVolume: model3d.XYZ(60, 60, 60),
+			Connector:   connector,
+		}
+		result, err := splitter.Split(solid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Parts) != 4 {
+			t.Fatalf("connector %d: expected 4 parts but got %d", connector, len(result.Parts))
+		}
+		rng := rand.New(rand.NewSource(0))
+		for i := 0; i < 200000; i++ {
+			c := model3d.XYZ(rng.Float64()*100, rng.Float64()*100, rng.Float64()*20)
+			var count int
+			for _, p := range result.Parts {
+				if p.Contains(c) {
+					count++
+				}
+			}
+			if count > 1 {
+				t.Fatalf("connector %d: parts overlap at %v", connector, c)
+			}
+		}
+	}
+}
+
+func TestSplitterAvoidSlivers(t *testing.T) {
+	solid := model3d.JoinedSolid{
+		&model3d.Rect{MinVal: model3d.XYZ(0, 0, 0), MaxVal: model3d.XYZ(52, 20, 10)},
+		&model3d.Rect{MinVal: model3d.XYZ(0, 20, 0), MaxVal: model3d.XYZ(100, 40, 10)},
+	}
+	splitter := &Splitter{
+		BuildVolume: model3d.XYZ(60, 60, 60),
+		Connector:   NoConnectors,
+		Delta:       0.5,
+	}
+	result, err := splitter.Split(solid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Planes) != 1 {
+		t.Fatalf("unexpected number of planes: %d", len(result.Planes))
+	}
+	x := result.Planes[0].Point.X
+	if x > 47 && x <= 52 {
+		t.Errorf("cut at %f creates a sliver", x)
+	}
+	if x < 45 || x > 55 {
+		t.Errorf("cut at %f makes parts too large", x)
+	}
+}
+
+func TestSplitterPlanes(t *testing.T) {
+	solid := &model3d.Sphere{Radius: 10}
+	splitter := &Splitter{
+		Planes: []*CutPlane{
+			{Normal: model3d.X(1)},
+			{Normal: model3d.XYZ(0, 1, 1)},
+		},
+		ConnectorRadius: 1,
+		ConnectorLength: 4,
+	}
+	result, err := splitter.Split(solid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Parts) != 4 {
+		t.Fatalf("expected 4 parts but got %d", len(result.Parts))
+	}
+	for i := 0; i < 10000; i++ {
+		c := model3d.NewCoord3DRandBounds(solid.Min(), solid.Max())
+		var count int
+		for _, p := range result.Parts {
+			if p.Contains(c) {
+				count++
+			}
+		}
+		if count > 1 {
+			t.Fatalf("parts overlap at %v", c)
+		}
+	}
+
+	if _, err := (&Splitter{}).Split(solid); err == nil {
+		t.Error("expected error without planes")
+	}
+}
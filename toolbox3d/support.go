@@ -0,0 +1,501 @@
+package toolbox3d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+const (
+	DefaultSupportSpacing       = 4.0
+	DefaultSupportContactRadius = 0.4
+	DefaultSupportBranchRadius  = 1.0
+	DefaultSupportTrunkRadius   = 3.0
+	DefaultSupportGap           = 0.2
+	DefaultSupportBranchAngle   = math.Pi / 4
+)
+
+// SupportStyle determines the shape of the structures
+// created by a SupportGenerator.
+type SupportStyle int
+
+const (
+	// TreeSupports grow branches from each contact point
+	// which merge into trunks as they descend.
+	TreeSupports SupportStyle = iota
+
+	// LatticeSupports create a vertical column under each
+	// contact point, with diagonal braces between
+	// neighboring columns.
+	LatticeSupports
+)
+
+// A SupportGenerator creates support structures for the
+// overhanging parts of a model, for use in 3D printing.
+//
+// Lengths are in the units of the model, and the defaults
+// assume millimeters.
+type SupportGenerator struct {
+	Style SupportStyle
+
+	// BuildDirection is the direction in which layers are
+	// stacked. If zero, +Z is used.
+	BuildDirection model3d.Coord3D
+
+	// OverhangAngle is the maximum angle from vertical of
+	// a downward-facing surface that does not need support.
+	// If 0, DefaultPrintabilityOverhangAngle is used.
+	OverhangAngle float64
+
+	// Spacing is the distance between contact points.
+	// If 0, DefaultSupportSpacing is used.
+	Spacing float64
+
+	// ContactRadius is the radius of the tip of a support
+	// where it touches the model.
+	// If 0, DefaultSupportContactRadius is used.
+	ContactRadius float64
+
+	// BranchRadius is the radius of a support below its tip.
+	// If 0, DefaultSupportBranchRadius is used.
+	BranchRadius float64
+
+	// TrunkRadius is the maximum radius that tree branches
+	// grow to as they merge.
+	// If 0, DefaultSupportTrunkRadius is used.
+	TrunkRadius float64
+
+	// Gap is the minimum distance between supports and the
+	// model, which makes the supports easier to break away.
+	// If 0, DefaultSupportGap is used.
+	// If negative, no gap is used.
+	Gap float64
+
+	// BranchAngle is the maximum angle from vertical of a
+	// tree branch.
+	// If 0, DefaultSupportBranchAngle is used.
+	BranchAngle float64
+
+	// Delta is the marching cubes resolution used to
+	// convert a model3d.Solid into a mesh in SolidSupports.
+	// If 0, a fraction of ContactRadius is used.
+	Delta float64
+}
+
+// A SupportContact is a point on a model which needs to be
+// supported.
+type SupportContact struct {
+	// Point is the point on the model's surface.
+	Point model3d.Coord3D
+
+	// Normal is the normal of the surface at Point.
+	Normal model3d.Coord3D
+
+	// Base is the point directly below Point where the
+	// support can rest, either on the build plate or on
+	// another part of the model.
+	Base model3d.Coord3D
+
+	// OnPart is true if Base is on the model rather than
+	// on the build plate.
+	OnPart bool
+}
+
+// MeshSupports creates a solid containing supports for a
+// mesh, where the build plate is at the given height along
+// the build direction.
+//
+// The resulting solid does not include the mesh itself,
+// and it is clipped to the build plate.
+func (s *SupportGenerator) MeshSupports(mesh *model3d.Mesh,
+	plateHeight float64) model3d.Solid {
+	collider := model3d.MeshToCollider(mesh)
+	contacts := s.contacts(mesh, collider, plateHeight)
+
+	var pieces model3d.JoinedSolid
+	if s.Style == LatticeSupports {
+		pieces = s.latticeSupports(contacts, collider)
+	} else {
+		pieces = s.treeSupports(contacts, collider, plateHeight)
+	}
+	if len(pieces) == 0 {
+		return model3d.JoinedSolid{}
+	}
+
+	var part model3d.Solid = model3d.NewColliderSolid(collider)
+	if gap := s.gap(); gap > 0 {
+		part = model3d.NewColliderSolidInset(collider, -gap)
+	}
+	return &supportSolid{
+		Supports:    pieces.Optimize(),
+		Part:        part,
+		Up:          s.buildDirection(),
+		PlateHeight: plateHeight,
+	}
+}
+
+// SolidSupports is like MeshSupports, but for a solid,
+// which is first converted to a mesh.
+func (s *SupportGenerator) SolidSupports(solid model3d.Solid,
+	plateHeight float64) model3d.Solid {
+	delta := s.Delta
+	if delta == 0 {
+		delta = s.contactRadius() / 2
+	}
+	mesh := model3d.MarchingCubesSearch(solid, delta, 8)
+	return s.MeshSupports(mesh, plateHeight)
+}
+
+// Contacts finds the points on a mesh which should be
+// supported, spaced in a grid perpendicular to the build
+// direction.
+func (s *SupportGenerator) Contacts(mesh *model3d.Mesh,
+	plateHeight float64) []*SupportContact {
+	return s.contacts(mesh, model3d.MeshToCollider(mesh), plateHeight)
+}
+
+func (s *SupportGenerator) contacts(mesh *model3d.Mesh, collider model3d.Collider,
+	plateHeight float64) []*SupportContact {
+	if mesh.NumTriangles() == 0 {
+		return nil
+	}
+	up := s.buildDirection()
+	b1, b2 := up.OrthoBasis()
+	min := model3d.XYZ(math.Inf(1), math.Inf(1), math.Inf(1))
+	max := min.Scale(-1)
+	mesh.IterateVertices(func(c model3d.Coord3D) {
+		local := model3d.XYZ(c.Dot(b1), c.Dot(b2), c.Dot(up))
+		min = min.Min(local)
+		max = max.Max(local)
+	})
+
+	spacing := s.spacing()
+	minSine := math.Sin(s.overhangAngle())
+	startHeight := math.Min(min.Z, plateHeight) - 1
+	var result []*SupportContact
+	for x := min.X + spacing/2; x < max.X; x += spacing {
+		for y := min.Y + spacing/2; y < max.Y; y += spacing {
+			origin := b1.Scale(x).Add(b2.Scale(y)).Add(up.Scale(startHeight))
+			ray := &model3d.Ray{Origin: origin, Direction: up}
+			var collisions []model3d.RayCollision
+			collider.RayCollisions(ray, func(rc model3d.RayCollision) {
+				collisions = append(collisions, rc)
+			})
+			sort.Slice(collisions, func(i, j int) bool {
+				return collisions[i].Scale < collisions[j].Scale
+			})
+
+			base := origin.Add(up.Scale(plateHeight - startHeight))
+			onPart := false
+			var depth int
+			for _, rc := range collisions {
+				point := ray.Origin.Add(up.Scale(rc.Scale))
+				if rc.Normal.Dot(up) < 0 {
+					if depth == 0 && point.Dot(up) > base.Dot(up)+s.gap() &&
+						-rc.Normal.Dot(up) > minSine {
+						result = append(result, &SupportContact{
+							Point:  point,
+							Normal: rc.Normal,
+							Base:   base,
+							OnPart: onPart,
+						})
+					}
+					depth++
+				} else if depth > 0 {
+					depth--
+					if depth == 0 {
+						base = point
+						onPart = true
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (s *SupportGenerator) latticeSupports(contacts []*SupportContact,
+	collider model3d.Collider) model3d.JoinedSolid {
+	up := s.buildDirection()
+	spacing := s.spacing()
+	radius := s.branchRadius()
+
+	// Group columns by their grid cell so that neighbors
+	// can be braced together.
+	b1, b2 := up.OrthoBasis()
+	columns := map[[2]int][]*SupportContact{}
+	var result model3d.JoinedSolid
+	for _, c := range contacts {
+		tipBase := s.addTip(&result, c)
+		if tipBase.Sub(c.Base).Dot(up) > 0 {
+			result = append(result, &model3d.Cylinder{
+				P1:     c.Base,
+				P2:     tipBase,
+				Radius: radius,
+			})
+		}
+		key := [2]int{
+			int(math.Round(c.Point.Dot(b1) / spacing)),
+			int(math.Round(c.Point.Dot(b2) / spacing)),
+		}
+		columns[key] = append(columns[key], c)
+	}
+
+	for key, column := range columns {
+		for _, offset := range [][2]int{{1, 0}, {0, 1}} {
+			neighbor := columns[[2]int{key[0] + offset[0], key[1] + offset[1]}]
+			for _, c1 := range column {
+				for _, c2 := range neighbor {
+					s.addBraces(&result, c1, c2, collider)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// addBraces adds zig-zag braces between the overlapping
+// parts of two support columns.
+func (s *SupportGenerator) addBraces(result *model3d.JoinedSolid, c1, c2 *SupportContact,
+	collider model3d.Collider) {
+	up := s.buildDirection()
+	spacing := s.spacing()
+	tipLength := s.tipLength()
+	minHeight := math.Max(c1.Base.Dot(up), c2.Base.Dot(up))
+	maxHeight := math.Min(c1.Point.Dot(up), c2.Point.Dot(up)) - tipLength
+	for i := 0; minHeight+float64(i+1)*spacing <= maxHeight; i++ {
+		h1 := minHeight + float64(i)*spacing
+		h2 := h1 + spacing
+		if i%2 == 1 {
+			h1, h2 = h2, h1
+		}
+		p1 := c1.Point.Add(up.Scale(h1 - c1.Point.Dot(up)))
+		p2 := c2.Point.Add(up.Scale(h2 - c2.Point.Dot(up)))
+		ray := &model3d.Ray{Origin: p1, Direction: p2.Sub(p1)}
+		if rc, ok := collider.FirstRayCollision(ray); ok && rc.Scale <= 1 {
+			continue
+		}
+		*result = append(*result, &model3d.Cylinder{
+			P1:     p1,
+			P2:     p2,
+			Radius: s.contactRadius(),
+		})
+	}
+}
+
+type supportBranch struct {
+	Pos    model3d.Coord3D
+	Radius float64
+}
+
+func (s *SupportGenerator) treeSupports(contacts []*SupportContact, collider model3d.Collider,
+	plateHeight float64) model3d.JoinedSolid {
+	up := s.buildDirection()
+	var result model3d.JoinedSolid
+	var branches []*supportBranch
+	for _, c := range contacts {
+		branches = append(branches, &supportBranch{
+			Pos:    s.addTip(&result, c),
+			Radius: s.branchRadius(),
+		})
+	}
+
+	// Descend all of the branches in lockstep, starting
+	// with the highest ones.
+	step := s.branchRadius()
+	maxShift := step * math.Tan(s.branchAngle())
+	gap := math.Max(0, s.gap())
+	for len(branches) > 0 {
+		sort.Slice(branches, func(i, j int) bool {
+			return branches[i].Pos.Dot(up) > branches[j].Pos.Dot(up)
+		})
+		height := branches[0].Pos.Dot(up)
+
+		var remaining []*supportBranch
+		for i, b := range branches {
+			bHeight := b.Pos.Dot(up)
+			if bHeight < height-step/2 {
+				// Wait for the higher branches to catch up.
+				remaining = append(remaining, b)
+				continue
+			}
+
+			ray := &model3d.Ray{Origin: b.Pos, Direction: up.Scale(-1)}
+			floor := bHeight - plateHeight
+			if rc, ok := collider.FirstRayCollision(ray); ok && rc.Scale < floor {
+				floor = rc.Scale
+			}
+			if floor <= step+b.Radius {
+				result = append(result, &model3d.Capsule{
+					P1:     b.Pos,
+					P2:     b.Pos.Sub(up.Scale(floor)),
+					Radius: b.Radius,
+				})
+				continue
+			}
+
+			next := b.Pos.Sub(up.Scale(step))
+			if target := nearestBranch(branches, i, up); target != nil {
+				offset := target.Pos.Sub(b.Pos)
+				offset = offset.Sub(up.Scale(offset.Dot(up)))
+				dist := offset.Norm()
+				shift := math.Min(maxShift, dist/2)
+				if dist > 0 {
+					moved := next.Add(offset.Scale(shift / dist))
+					if !collider.SphereCollision(moved, b.Radius+gap) {
+						next = moved
+					}
+				}
+			}
+			result = append(result, &model3d.Capsule{P1: b.Pos, P2: next, Radius: b.Radius})
+			b.Pos = next
+			remaining = append(remaining, b)
+		}
+		branches = s.mergeBranches(remaining, up)
+	}
+	return result
+}
+
+func nearestBranch(branches []*supportBranch, idx int, up model3d.Coord3D) *supportBranch {
+	b := branches[idx]
+	var result *supportBranch
+	minDist := math.Inf(1)
+	for i, other := range branches {
+		if i == idx {
+			continue
+		}
+		offset := other.Pos.Sub(b.Pos)
+		if math.Abs(offset.Dot(up)) > b.Radius+other.Radius {
+			continue
+		}
+		if dist := offset.Norm(); dist < minDist {
+			minDist = dist
+			result = other
+		}
+	}
+	return result
+}
+
+// mergeBranches combines branches which have converged,
+// growing the radius to hold the combined load.
+func (s *SupportGenerator) mergeBranches(branches []*supportBranch,
+	up model3d.Coord3D) []*supportBranch {
+	var result []*supportBranch
+	for _, b := range branches {
+		merged := false
+		for _, other := range result {
+			offset := other.Pos.Sub(b.Pos)
+			horizontal := offset.Sub(up.Scale(offset.Dot(up)))
+			if horizontal.Norm() < math.Max(b.Radius, other.Radius)/2 &&
+				math.Abs(offset.Dot(up)) < s.branchRadius() {
+				other.Radius = math.Min(s.trunkRadius(),
+					math.Sqrt(b.Radius*b.Radius+other.Radius*other.Radius))
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// addTip adds the tapered tip of a support and returns
+// the point at the bottom of the tip.
+func (s *SupportGenerator) addTip(result *model3d.JoinedSolid, c *SupportContact) model3d.Coord3D {
+	up := s.buildDirection()
+	tipLength := math.Min(s.tipLength(), c.Point.Sub(c.Base).Dot(up))
+	tipBase := c.Point.Sub(up.Scale(tipLength))
+	*result = append(*result, &model3d.ConeSlice{
+		P1: tipBase,
+		P2: c.Point,
+		R1: s.branchRadius(),
+		R2: s.contactRadius(),
+	})
+	return tipBase
+}
+
+func (s *SupportGenerator) tipLength() float64 {
+	return 2 * s.branchRadius()
+}
+
+func (s *SupportGenerator) buildDirection() model3d.Coord3D {
+	if s.BuildDirection.Norm() == 0 {
+		return model3d.Z(1)
+	}
+	return s.BuildDirection.Normalize()
+}
+
+func (s *SupportGenerator) overhangAngle() float64 {
+	if s.OverhangAngle == 0 {
+		return DefaultPrintabilityOverhangAngle
+	}
+	return s.OverhangAngle
+}
+
+func (s *SupportGenerator) spacing() float64 {
+	if s.Spacing == 0 {
+		return DefaultSupportSpacing
+	}
+	return s.Spacing
+}
+
+func (s *SupportGenerator) contactRadius() float64 {
+	if s.ContactRadius == 0 {
+		return DefaultSupportContactRadius
+	}
+	return s.ContactRadius
+}
+
+func (s *SupportGenerator) branchRadius() float64 {
+	if s.BranchRadius == 0 {
+		return DefaultSupportBranchRadius
+	}
+	return s.BranchRadius
+}
+
+func (s *SupportGenerator) trunkRadius() float64 {
+	if s.TrunkRadius == 0 {
+		return DefaultSupportTrunkRadius
+	}
+	return s.TrunkRadius
+}
+
+func (s *SupportGenerator) gap() float64 {
+	if s.Gap == 0 {
+		return DefaultSupportGap
+	} else if s.Gap < 0 {
+		return 0
+	}
+	return s.Gap
+}
+
+func (s *SupportGenerator) branchAngle() float64 {
+	if s.BranchAngle == 0 {
+		return DefaultSupportBranchAngle
+	}
+	return s.BranchAngle
+}
+
+// supportSolid removes the model and everything below the
+// build plate from a support structure.
+type supportSolid struct {
+	Supports    model3d.Solid
+	Part        model3d.Solid
+	Up          model3d.Coord3D
+	PlateHeight float64
+}
+
+func (s *supportSolid) Min() model3d.Coord3D {
+	return s.Supports.Min()
+}
+
+func (s *supportSolid) Max() model3d.Coord3D {
+	return s.Supports.Max()
+}
+
+func (s *supportSolid) Contains(c model3d.Coord3D) bool {
+	return c.Dot(s.Up) >= s.PlateHeight && s.Supports.Contains(c) && !s.Part.Contains(c)
+}
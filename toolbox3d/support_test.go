@@ -0,0 +1,91 @@
+package toolbox3d
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/model3d/model3d"
+)
+
+func TestSupportGeneratorContacts(t *testing.T) {
+	// A floating box above a box on the build plate.
+	mesh := model3d.NewMeshRect(model3d.XYZ(0, 0, 5), model3d.XYZ(10, 10, 8))
+	mesh.AddMesh(model3d.NewMeshRect(model3d.XYZ(0, 0, 0), model3d.XYZ(4.5, 10, 2)))
+
+	gen := &SupportGenerator{Spacing: 2}
+	contacts := gen.Contacts(mesh, 0)
+	if len(contacts) != 25 {
+		t.Fatalf("expected 25 contacts but got %d", len(contacts))
+	}
+	for _, c := range contacts {
+		if math.Abs(c.Point.Z-5) > 1e-8 || c.Normal.Z > -0.99 {
+			t.Errorf("unexpected contact %v with normal %v", c.Point, c.Normal)
+		}
+		expectedBase := 0.0
+		if c.Point.X < 4.5 {
+			expectedBase = 2
+		}
+		if math.Abs(c.Base.Z-expectedBase) > 1e-8 || c.OnPart != (expectedBase != 0) {
+			t.Errorf("unexpected base for contact %v: %v (on part %v)", c.Point, c.Base,
+				c.OnPart)
+		}
+		if c.Base.X != c.Point.X || c.Base.Y != c.Point.Y {
+			t.Errorf("base %v should be below point %v", c.Base, c.Point)
+		}
+	}
+}
+
+func TestSupportGeneratorSupports(t *testing.T) {
+	// A shelf overhanging a box on the build plate.
+	solid := model3d.JoinedSolid{
+		&model3d.Rect{MinVal: model3d.XYZ(0, 0, 0), MaxVal: model3d.XYZ(10, 10, 10)},
+		&model3d.Rect{MinVal: model3d.XYZ(10, 0, 8), MaxVal: model3d.XYZ(30, 10, 10)},
+	}
+	mesh := model3d.MarchingCubesSearch(solid, 0.25, 8)
+
+	footprints := map[SupportStyle]int{}
+	for _, style := range []SupportStyle{TreeSupports, LatticeSupports} {
+		gen := &SupportGenerator{Style: style}
+		supports := gen.MeshSupports(mesh, 0)
+
+		for i := 0; i < 10000; i++ {
+			c := model3d.NewCoord3DRandBounds(supports.Min(), supports.Max())
+			if !supports.Contains(c) {
+				continue
+			}
+			if c.Z < 0 {
+				t.Fatalf("style %d: point %v below plate", style, c)
+			}
+			// Allow some slack for the marching cubes mesh.
+			for _, r := range solid {
+				if r.(*model3d.Rect).SDF(c) > -0.15 {
+					t.Fatalf("style %d: point %v too close to model", style, c)
+				}
+			}
+		}
+
+		// Every contact should be supported right below the
+		// breakaway gap.
+		for _, c := range gen.Contacts(mesh, 0) {
+			if !supports.Contains(c.Point.Sub(model3d.Z(0.3))) {
+				t.Errorf("style %d: contact %v is not supported", style, c.Point)
+			}
+		}
+
+		// Count the area touching the build plate.
+		for x := 0.0; x < 40; x += 0.1 {
+			for y := -5.0; y < 15; y += 0.1 {
+				if supports.Contains(model3d.XYZ(x, y, 0.05)) {
+					footprints[style]++
+				}
+			}
+		}
+		if footprints[style] == 0 {
+			t.Errorf("style %d: supports do not reach the plate", style)
+		}
+	}
+	if footprints[TreeSupports] >= footprints[LatticeSupports] {
+		t.Errorf("tree footprint %d should be smaller than lattice footprint %d",
+			footprints[TreeSupports], footprints[LatticeSupports])
+	}
+}
@@ -0,0 +1,298 @@
+package toolbox3d
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/unixpickle/model3d/model3d"
+)
+
+// A ThreadSpec describes a standard 60 degree screw
+// thread, such as an ISO metric or unified (UNC/UNF)
+// thread.
+//
+// All dimensions are in millimeters, even for unified
+// threads.
+type ThreadSpec struct {
+	// Name is the designation of the thread, such as
+	// "M6x1" or "1/4-20 UNC".
+	Name string
+
+	// MajorDiameter is the nominal (outer) diameter.
+	MajorDiameter float64
+
+	// Pitch is the distance between adjacent crests.
+	Pitch float64
+}
+
+// FundamentalHeight gets the height of the sharp triangle
+// from which the thread profile is derived.
+func (t ThreadSpec) FundamentalHeight() float64 {
+	return t.Pitch * math.Sqrt(3) / 2
+}
+
+// MinorDiameter gets the basic minor diameter, which is
+// the diameter of the crests of an internal thread.
+func (t ThreadSpec) MinorDiameter() float64 {
+	return t.MajorDiameter - 2*t.FundamentalHeight()*5/8
+}
+
+// PitchDiameter gets the diameter at which the thread and
+// the grooves have equal widths.
+func (t ThreadSpec) PitchDiameter() float64 {
+	return t.MajorDiameter - 2*t.FundamentalHeight()*3/8
+}
+
+// External creates a solid for an external thread (e.g.
+// for a bolt) between two points.
+//
+// The radius is reduced by half of the tolerance's
+// ThreadClearance.
+func (t ThreadSpec) External(p1, p2 model3d.Coord3D, tol FastenerTolerance) *ThreadSolid {
+	return &ThreadSolid{
+		P1:        p1,
+		P2:        p2,
+		Thread:    t,
+		Clearance: -tol.ThreadClearance / 2,
+	}
+}
+
+// Internal creates a solid for an internal thread (e.g.
+// for a nut), which should be subtracted from another
+// solid.
+//
+// The radius is increased by half of the tolerance's
+// ThreadClearance.
+// If pointed is true, the end at p2 is cut off at a 45
+// degree angle, as in ScrewSolid.
+func (t ThreadSpec) Internal(p1, p2 model3d.Coord3D, tol FastenerTolerance,
+	pointed bool) *ThreadSolid {
+	return &ThreadSolid{
+		P1:        p1,
+		P2:        p2,
+		Thread:    t,
+		Clearance: tol.ThreadClearance / 2,
+		Pointed:   pointed,
+	}
+}
+
+// A ThreadSolid is a model3d.Solid implementing a right-
+// handed thread with the basic 60 degree profile shared
+// by ISO metric and unified threads.
+//
+// Unlike ScrewSolid, whose grooves have 90 degree flanks,
+// a ThreadSolid can mate with off-the-shelf hardware.
+type ThreadSolid struct {
+	// P1 is the center of the start of the thread.
+	P1 model3d.Coord3D
+
+	// P2 is the center of the end of the thread.
+	P2 model3d.Coord3D
+
+	Thread ThreadSpec
+
+	// Clearance is added to the radius of the entire
+	// profile. Use a positive value for holes and a
+	// negative value for external threads.
+	Clearance float64
+
+	// Pointed can be set to true to indicate that the tip
+	// at the P2 end should be cut off at a 45 degree
+	// angle (in the shape of a cone).
+	Pointed bool
+}
+
+func (t *ThreadSolid) Min() model3d.Coord3D {
+	return t.boundingCylinder().Min()
+}
+
+func (t *ThreadSolid) Max() model3d.Coord3D {
+	return t.boundingCylinder().Max()
+}
+
+func (t *ThreadSolid) Contains(c model3d.Coord3D) bool {
+	diff := t.P2.Sub(t.P1)
+	height := diff.Norm()
+	axis := diff.Normalize()
+	b1, b2 := axis.OrthoBasis()
+
+	// Make sure basis obeys right-hand rule.
+	if b1.Cross(b2).Dot(axis) < 0 {
+		b2, b1 = b1, b2
+	}
+
+	offset := c.Sub(t.P1)
+	offset = model3d.Coord3D{
+		X: offset.Dot(b1),
+		Y: offset.Dot(b2),
+		Z: offset.Dot(axis),
+	}
+	if offset.Z < 0 || offset.Z > height {
+		return false
+	}
+
+	radius := offset.XY().Norm()
+	if t.Pointed && radius > height-offset.Z {
+		return false
+	}
+
+	maxRadius := t.Thread.MajorDiameter/2 + t.Clearance
+	minRadius := t.Thread.MinorDiameter()/2 + t.Clearance
+	if radius > maxRadius {
+		return false
+	} else if radius <= minRadius {
+		return true
+	}
+
+	pitch := t.Thread.Pitch
+	phase := (offset.Z - math.Atan2(offset.Y, offset.X)*pitch/(2*math.Pi)) / pitch
+	crestDist := math.Abs(phase-math.Round(phase)) * pitch
+
+	// The basic profile has a flat crest of width P/8 and
+	// a flat root of width P/4, joined by 60 degree flanks.
+	var profileRadius float64
+	if crestDist <= pitch/16 {
+		profileRadius = maxRadius
+	} else if crestDist >= pitch*3/8 {
+		profileRadius = minRadius
+	} else {
+		profileRadius = maxRadius - (crestDist-pitch/16)*math.Sqrt(3)
+	}
+	return radius <= profileRadius
+}
+
+func (t *ThreadSolid) boundingCylinder() *model3d.CylinderSolid {
+	return &model3d.CylinderSolid{
+		P1:     t.P1,
+		P2:     t.P2,
+		Radius: t.Thread.MajorDiameter/2 + math.Max(0, t.Clearance),
+	}
+}
+
+var (
+	metricThreadExpr = regexp.MustCompile(`^M([0-9]+(?:\.[0-9]+)?)(?:[X×]([0-9]+(?:\.[0-9]+)?))?$`)
+	unifiedExpr      = regexp.MustCompile(`^(#?[0-9]+|(?:[0-9]+-)?[0-9]+/[0-9]+)("|IN)?(?:-([0-9]+(?:\.[0-9]+)?))?(UNC|UNF)?$`)
+	mixedNumberExpr  = regexp.MustCompile(`([0-9]) +([0-9]+/)`)
+)
+
+// ParseThreadSpec parses a thread designation.
+//
+// Metric threads are written like "M6" (for a coarse
+// pitch) or "M6x0.75".
+// Unified threads are written like "1/4-20", "#10-32",
+// "1/4 UNF", "10-24 UNC", "1-1/4-7" or "1 1/4 UNF".
+// A bare number such as "1-8" is a whole-inch size if it
+// has an inch mark (e.g. `1"-8`) or a whole-inch pitch,
+// and is otherwise a numbered size.
+func ParseThreadSpec(designation string) (*ThreadSpec, error) {
+	spec, _, err := parseThreadDesignation(designation)
+	return spec, err
+}
+
+func parseThreadDesignation(designation string) (*ThreadSpec, string, error) {
+	d := mixedNumberExpr.ReplaceAllString(strings.TrimSpace(designation), "$1-$2")
+	d = strings.ToUpper(strings.ReplaceAll(d, " ", ""))
+	if match := metricThreadExpr.FindStringSubmatch(d); match != nil {
+		size := "M" + match[1]
+		diameter, _ := strconv.ParseFloat(match[1], 64)
+		var pitch float64
+		if match[2] != "" {
+			pitch, _ = strconv.ParseFloat(match[2], 64)
+		} else if info, ok := metricFasteners[size]; ok {
+			pitch = info.Pitch
+		} else {
+			return nil, "", fmt.Errorf("parse thread: no standard pitch for %s", size)
+		}
+		if diameter <= 0 || pitch <= 0 {
+			return nil, "", fmt.Errorf("parse thread: invalid designation %#v", designation)
+		}
+		return &ThreadSpec{
+			Name:          size + "x" + strconv.FormatFloat(pitch, 'f', -1, 64),
+			MajorDiameter: diameter,
+			Pitch:         pitch,
+		}, size, nil
+	} else if match := unifiedExpr.FindStringSubmatch(d); match != nil {
+		var tpi float64
+		if match[3] != "" {
+			tpi, _ = strconv.ParseFloat(match[3], 64)
+		}
+		size := unifiedSizeName(match[1], match[2] != "", tpi)
+		info, ok := unifiedFasteners[size]
+		if !ok {
+			return nil, "", fmt.Errorf("parse thread: unknown unified size %s", size)
+		}
+		series := match[4]
+		if match[3] != "" {
+			if series == "" {
+				if tpi == info.CoarseTPI {
+					series = "UNC"
+				} else if tpi == info.FineTPI {
+					series = "UNF"
+				}
+			}
+		} else if series == "UNF" || (series == "" && info.CoarseTPI == 0) {
+			series = "UNF"
+			tpi = info.FineTPI
+		} else {
+			series = "UNC"
+			tpi = info.CoarseTPI
+		}
+		if tpi <= 0 {
+			return nil, "", fmt.Errorf("parse thread: invalid designation %#v", designation)
+		}
+		name := size + "-" + strconv.FormatFloat(tpi, 'f', -1, 64)
+		if series != "" {
+			name += " " + series
+		}
+		return &ThreadSpec{
+			Name:          name,
+			MajorDiameter: info.Diameter * 25.4,
+			Pitch:         25.4 / tpi,
+		}, size, nil
+	}
+	return nil, "", fmt.Errorf("parse thread: invalid designation %#v", designation)
+}
+
+// unifiedSizeName converts a unified size from a thread
+// designation into a key of unifiedFasteners, deciding if
+// bare numbers are numbered sizes or whole-inch sizes.
+func unifiedSizeName(size string, inchMark bool, tpi float64) string {
+	if strings.Contains(size, "/") {
+		return size
+	} else if strings.HasPrefix(size, "#") {
+		n, _ := strconv.Atoi(size[1:])
+		return "#" + strconv.Itoa(n)
+	}
+	n, _ := strconv.Atoi(size)
+	numbered := "#" + strconv.Itoa(n)
+	whole := strconv.Itoa(n)
+	if inchMark {
+		return whole
+	}
+	numberedInfo, isNumbered := unifiedFasteners[numbered]
+	wholeInfo, isWhole := unifiedFasteners[whole]
+	if !isWhole {
+		return numbered
+	} else if !isNumbered {
+		return whole
+	}
+	if tpi != 0 && tpi != numberedInfo.CoarseTPI && tpi != numberedInfo.FineTPI &&
+		(tpi == wholeInfo.CoarseTPI || tpi == wholeInfo.FineTPI) {
+		return whole
+	}
+	return numbered
+}
+
+// MustParseThreadSpec is like ParseThreadSpec, but panics
+// if the designation is invalid.
+func MustParseThreadSpec(designation string) *ThreadSpec {
+	spec, err := ParseThreadSpec(designation)
+	if err != nil {
+		panic(errors.Wrap(err, "must parse thread spec"))
+	}
+	return spec
+}